@@ -11,7 +11,10 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kenshaw/glob"
 	"github.com/kenshaw/snaker"
@@ -29,11 +32,22 @@ import (
 
 // Run runs the code generation.
 func Run(ctx context.Context, name string) {
+	// peek config
+	config, err := loadConfig(parseArg("--config", "-c", os.Args))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
 	// peek template set
+	src, err := expand(configArg(config, "src", parseArg("--src", "-d", os.Args)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
 	ts, err := newTemplateSet(
 		ctx,
-		parseArg("--src", "-d", os.Args),
-		parseArg("--template", "-t", os.Args),
+		src,
+		configArg(config, "template", parseArg("--template", "-t", os.Args)),
 	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
@@ -49,7 +63,13 @@ func Run(ctx context.Context, name string) {
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
 	}
-	ox.RunContext(ctx, opts...)
+	// add config values
+	osArgs, err := configArgs(opts, config, os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+	ox.RunContext(ctx, append(opts, ox.Args(osArgs[1:]...))...)
 }
 
 // Args contains command-line arguments.
@@ -58,6 +78,8 @@ type Args struct {
 	TemplateTypes []string
 	// Verbose enables verbose output.
 	Verbose bool
+	// Config is the config file.
+	Config string
 	// LoaderParams are database loader parameters.
 	LoaderParams LoaderParams
 	// TemplateParams are template parameters.
@@ -137,6 +159,11 @@ type SchemaParams struct {
 	//
 	// When unspecified, all types are included in the schema.
 	Exclude []*glob.Glob
+	// From is the schema document to load instead of a database.
+	From string
+	// DumpSchema is the file to write the schema document to instead of
+	// generating code.
+	DumpSchema string
 	// UseIndexNames toggles using index names.
 	//
 	// This is not enabled by default, because index names are often generated
@@ -156,6 +183,16 @@ type OutParams struct {
 	Single string
 	// Debug toggles direct writing of files to disk, skipping post processing.
 	Debug bool
+	// PostHooks are the commands post processing each generated file.
+	PostHooks []string
+	// Stream toggles writing each generated file to disk in turn.
+	Stream bool
+	// Report is the file to write the generation report to.
+	Report string
+	// MaxWarnings is the number of warnings allowed before failing.
+	MaxWarnings int
+	// StrictTypes toggles failing when a type has no go type mapping.
+	StrictTypes bool
 }
 
 // newTemplateSet creates a new templates set.
@@ -200,6 +237,11 @@ func rootCommand(name string, ts *templates.Templates, args *Args) ([]ox.Option,
 				"verbose", "enable verbose output",
 				ox.Bind(&args.Verbose),
 				ox.Short("v"),
+			).
+			String(
+				"config", "config file (flag values by name, overridden by command-line flags)",
+				ox.Bind(&args.Config),
+				ox.Short("c"),
 			),
 	}
 	// add sub commands
@@ -336,6 +378,14 @@ func schemaCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 			"use-index-names", "use index names as defined in schema for generated code",
 			ox.Bind(&args.SchemaParams.UseIndexNames),
 			ox.Short("j"),
+		).
+		String(
+			"from", "load schema from a schema document or source (SQL, Prisma, ent, gorm) instead of a database",
+			ox.Bind(&args.SchemaParams.From),
+		).
+		String(
+			"dump-schema", "write schema to a JSON or YAML document instead of generating code",
+			ox.Bind(&args.SchemaParams.DumpSchema),
 		)
 	var err error
 	if fs, err = addFlags(fs, ts, args, true, true); err != nil {
@@ -344,8 +394,8 @@ func schemaCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 	return []ox.Option{
 		ox.Usage("schema", "generate code for a database schema from a template"),
 		ox.Banner("Generate code for a database schema from a template."),
-		ox.Spec("[flags] <database url>"),
-		ox.ValidArgs(1, 1),
+		ox.Spec("[flags] [<database url>]"),
+		ox.ValidArgs(0, 1),
 		fs,
 		ox.Exec(exec("schema", ts, args)),
 	}, nil
@@ -372,6 +422,9 @@ func dumpCommand(ts *templates.Templates, args *Args) ([]ox.Option, error) {
 				return err
 			}
 			// ensure out dir exists
+			if v[0], err = expand(v[0]); err != nil {
+				return err
+			}
 			if err := checkDir(v[0]); err != nil {
 				return err
 			}
@@ -403,7 +456,10 @@ func exec(mode string, ts *templates.Templates, args *Args) func(context.Context
 		// set template
 		ts.Use(args.TemplateParams.Type)
 		// build context
-		ctx = buildContext(ctx, mode, ts, args)
+		ctx, err := buildContext(ctx, mode, ts, args)
+		if err != nil {
+			return err
+		}
 		// enable verbose output for sql queries
 		if args.Verbose {
 			models.SetLogger(func(str string, v ...any) {
@@ -413,52 +469,129 @@ func exec(mode string, ts *templates.Templates, args *Args) func(context.Context
 				}
 				fmt.Printf(s+"\n", z...)
 			})
+			ctx = context.WithValue(ctx, xo.LogKey, func(s string, v ...any) {
+				fmt.Printf(s+"\n", v...)
+			})
 		}
-		// open database
-		var err error
-		if ctx, err = open(ctx, cmdargs[0], args.LoaderParams.Schema); err != nil {
-			return err
+		// collect warnings
+		report := &Report{
+			Mode:     mode,
+			Template: ts.Target(),
+		}
+		ctx = context.WithValue(ctx, xo.WarnKey, report.add)
+		var set *xo.Set
+		start := time.Now()
+		switch {
+		case mode == "schema" && args.SchemaParams.From != "":
+			// load schema document
+			if len(cmdargs) != 0 {
+				return errors.New("--from cannot be used with a database url")
+			}
+			if ctx, set, err = loadSchemaFile(ctx, args); err != nil {
+				return err
+			}
+			xo.Logf(ctx, "PHASE: load %s (%v)", args.SchemaParams.From, time.Since(start).Round(time.Microsecond))
+		case len(cmdargs) == 0:
+			return errors.New("must provide a database url or --from")
+		default:
+			// open database
+			urlstr, err := expand(cmdargs[0])
+			if err != nil {
+				return err
+			}
+			if ctx, err = open(ctx, urlstr, args.LoaderParams.Schema); err != nil {
+				return err
+			}
+			// load
+			if set, err = load(ctx, mode, ts, args); err != nil {
+				return err
+			}
+			xo.Logf(ctx, "PHASE: load (%v)", time.Since(start).Round(time.Microsecond))
+		}
+		err = generate(ctx, mode, ts, set, args, report)
+		// write report
+		if args.OutParams.Report != "" {
+			if err := report.write(args.OutParams.Report); err != nil {
+				return err
+			}
 		}
-		// load
-		set, err := load(ctx, mode, ts, args)
 		if err != nil {
 			return err
 		}
-		return generate(ctx, mode, ts, set, args)
+		return report.check(args.OutParams.MaxWarnings)
 	}
 }
 
 // generate generates the dbtpl files with the provided templates, data, and
 // arguments.
-func generate(ctx context.Context, mode string, ts *templates.Templates, set *xo.Set, args *Args) error {
+func generate(ctx context.Context, mode string, ts *templates.Templates, set *xo.Set, args *Args, report *Report) error {
+	// write schema document
+	if mode == "schema" && args.SchemaParams.DumpSchema != "" {
+		return dumpSchema(args.SchemaParams.DumpSchema, set)
+	}
 	// create set context
 	ctx = ts.NewContext(ctx, mode)
 	if err := displayErrors(ts); err != nil {
 		return err
 	}
+	xo.Logf(ctx, "GENERATE: %s (template: %s, out: %s)", mode, ts.Target(), args.OutParams.Out)
+	for _, schema := range set.Schemas {
+		xo.Logf(ctx, "SCHEMA: %s (tables: %d, views: %d, enums: %d, procs: %d)", schema.Name, len(schema.Tables), len(schema.Views), len(schema.Enums), len(schema.Procs))
+	}
 	// preprocess
-	ts.Pre(ctx, args.OutParams.Out, mode, set)
-	if err := displayErrors(ts); err != nil {
+	if err := phase(ctx, ts, "pre", func() {
+		ts.Pre(ctx, args.OutParams.Out, mode, set)
+	}); err != nil {
 		return err
 	}
 	// process
-	ts.Process(ctx, args.OutParams.Out, mode, set)
-	if err := displayErrors(ts); err != nil {
+	if err := phase(ctx, ts, "process", func() {
+		if args.OutParams.Stream {
+			ts.Emit(ctx, mode, set)
+			return
+		}
+		ts.Process(ctx, args.OutParams.Out, mode, set)
+	}); err != nil {
 		return err
 	}
+	// abort on unmapped types
+	if args.OutParams.StrictTypes {
+		if err := report.strict(); err != nil {
+			return err
+		}
+	}
+	// stream
+	if args.OutParams.Stream {
+		return phase(ctx, ts, "stream", func() {
+			ts.Stream(ctx, args.OutParams.Out, mode, args.OutParams.PostHooks, !args.OutParams.Debug)
+		})
+	}
 	// post
 	if !args.OutParams.Debug {
-		ts.Post(ctx, mode)
-		if err := displayErrors(ts); err != nil {
+		if err := phase(ctx, ts, "post", func() {
+			ts.Post(ctx, mode)
+		}); err != nil {
+			return err
+		}
+		if err := phase(ctx, ts, "hook", func() {
+			ts.Hook(ctx, args.OutParams.PostHooks)
+		}); err != nil {
 			return err
 		}
 	}
 	// dump
-	ts.Dump(args.OutParams.Out)
-	if err := displayErrors(ts); err != nil {
-		return err
-	}
-	return nil
+	return phase(ctx, ts, "dump", func() {
+		ts.Dump(args.OutParams.Out)
+	})
+}
+
+// phase runs a generation phase, logging its duration when verbose output is
+// enabled.
+func phase(ctx context.Context, ts *templates.Templates, name string, f func()) error {
+	start := time.Now()
+	f()
+	xo.Logf(ctx, "PHASE: %s (%v)", name, time.Since(start).Round(time.Microsecond))
+	return displayErrors(ts)
 }
 
 // databaseFlags adds database flags to the flag set.
@@ -494,6 +627,27 @@ func outFlags(fs *ox.FlagSet, args *Args) *ox.FlagSet {
 			"debug", "debug generated code (writes generated code to disk without post processing)",
 			ox.Bind(&args.OutParams.Debug),
 			ox.Short("D"),
+		).
+		Slice(
+			"post-hook", "command post processing each generated file (content on stdin, replaced by stdout)",
+			ox.Bind(&args.OutParams.PostHooks),
+		).
+		Bool(
+			"stream", "write each file to disk as generated, bounding memory use for large schemas",
+			ox.Bind(&args.OutParams.Stream),
+		).
+		String(
+			"report", "write a JSON report of generation warnings to the file (- for stdout)",
+			ox.Bind(&args.OutParams.Report),
+		).
+		Int(
+			"max-warnings", "fail when the number of warnings exceeds the threshold (-1 to disable)",
+			ox.Bind(&args.OutParams.MaxWarnings),
+			ox.Default("-1"),
+		).
+		Bool(
+			"strict-types", "fail on column types without a go type mapping",
+			ox.Bind(&args.OutParams.StrictTypes),
 		)
 }
 
@@ -560,11 +714,12 @@ func addFlag(fs *ox.FlagSet, g xo.FlagSet) (*ox.FlagSet, error) {
 		}
 		opts = append(opts, ox.Default(def), ox.NoArg(true, noArgDef))
 	case ox.IntT:
+		// the help output formats defaults as strings
 		def := 0
 		if g.Flag.Default != nil {
 			def, _ = g.Flag.Default.(int)
 		}
-		opts = append(opts, ox.Default(def))
+		opts = append(opts, ox.Default(strconv.Itoa(def)))
 	case ox.SliceT:
 		def := ""
 		if g.Flag.Default != nil {
@@ -618,8 +773,21 @@ func checkArgs(mode string, ts *templates.Templates, args *Args) error {
 		}
 		args.QueryParams.Query = string(bytes.TrimRight(buf, "\r\n"))
 	}
+	// expand environment variables
+	for _, s := range []*string{
+		&args.LoaderParams.Schema,
+		&args.OutParams.Out,
+		&args.OutParams.Single,
+		&args.SchemaParams.From,
+		&args.SchemaParams.DumpSchema,
+	} {
+		var err error
+		if *s, err = expand(*s); err != nil {
+			return err
+		}
+	}
 	// check out path
-	if args.OutParams.Out != "" {
+	if args.OutParams.Out != "" && args.SchemaParams.DumpSchema == "" {
 		var err error
 		if args.OutParams.Out, err = realpath.Realpath(args.OutParams.Out); err != nil {
 			return err
@@ -631,8 +799,9 @@ func checkArgs(mode string, ts *templates.Templates, args *Args) error {
 	return nil
 }
 
-// buildContext builds a context for the mode and template.
-func buildContext(ctx context.Context, mode string, ts *templates.Templates, args *Args) context.Context {
+// buildContext builds a context for the mode and template, expanding
+// environment variables in the values of flags that are paths or package names.
+func buildContext(ctx context.Context, mode string, ts *templates.Templates, args *Args) (context.Context, error) {
 	// out params
 	ctx = context.WithValue(ctx, xo.OutKey, args.OutParams.Out)
 	ctx = context.WithValue(ctx, xo.AppendKey, args.OutParams.Append)
@@ -648,9 +817,14 @@ func buildContext(ctx context.Context, mode string, ts *templates.Templates, arg
 			panic(fmt.Sprintf("param %q was not defined in context vars", g.Key()))
 		}
 		var z any
-		if g.Flag.Type != "[]string" {
-			z = v.Val()
-		} else {
+		switch g.Flag.Type {
+		case "string":
+			s, _ := v.Val().(string)
+			var err error
+			if z, err = expandFlag(g.Flag, s); err != nil {
+				return nil, fmt.Errorf("--%s: %w", g.Key(), err)
+			}
+		case "[]string":
 			a, ok := v.Val().([]ox.Value)
 			if !ok {
 				panic(fmt.Sprintf("param %q was not []ox.Value in context vars", g.Key()))
@@ -660,12 +834,18 @@ func buildContext(ctx context.Context, mode string, ts *templates.Templates, arg
 				if s[i], ok = v.Val().(string); !ok {
 					panic(fmt.Sprintf("param %q element %d was not string in context vars", g.Key(), i))
 				}
+				var err error
+				if s[i], err = expandFlag(g.Flag, s[i]); err != nil {
+					return nil, fmt.Errorf("--%s: %w", g.Key(), err)
+				}
 			}
 			z = s
+		default:
+			z = v.Val()
 		}
 		ctx = context.WithValue(ctx, g.Flag.ContextKey, z)
 	}
-	return ctx
+	return ctx, nil
 }
 
 // open opens a connection to the database, returning a context for use in
@@ -724,6 +904,8 @@ func parseArg(full, short string, args []string) (s string) {
 			}
 		case strings.HasPrefix(s, short):
 			return strings.TrimPrefix(s, short)
+		case strings.HasPrefix(s, full+"="):
+			return strings.TrimPrefix(s, full+"=")
 		case strings.HasPrefix(s, full):
 			return strings.TrimPrefix(s, full)
 		}
@@ -731,6 +913,34 @@ func parseArg(full, short string, args []string) (s string) {
 	return ""
 }
 
+// envRE matches environment variable references.
+var envRE = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expand expands ${VAR} environment variable references in s. Bare $VAR
+// references are left as-is, as they commonly appear in passwords.
+func expand(s string) (string, error) {
+	var err error
+	s = envRE.ReplaceAllStringFunc(s, func(v string) string {
+		name := envRE.FindStringSubmatch(v)[1]
+		z, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return z
+	})
+	return s, err
+}
+
+// expandFlag expands environment variable references in the flag's value s,
+// when the flag enables expansion. Other flag values, such as templates and
+// injected code, are left as-is.
+func expandFlag(flag xo.Flag, s string) (string, error) {
+	if !flag.Expand {
+		return s, nil
+	}
+	return expand(s)
+}
+
 // displayErrors displays collected errors from the set.
 func displayErrors(ts *templates.Templates) error {
 	if errors := ts.Errors(); len(errors) != 0 {
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xo/dbtpl/loader"
+	xo "github.com/xo/dbtpl/types"
+	"github.com/xo/ox"
+)
+
+func TestPostHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh")
+	}
+	tests := []struct {
+		hooks []string
+		exp   string
+		err   bool
+	}{
+		{[]string{`echo '// Copyright (c) Acme, Inc.'; cat`, `echo "// $DBTPL_FILE"; cat`}, "// book.dbtpl.go\n// Copyright (c) Acme, Inc.\n", false},
+		{[]string{"cat >/dev/null"}, "", true},
+		{[]string{"exit 3"}, "", true},
+	}
+	for i, test := range tests {
+		set := loadFixture(t, filepath.Join("testdata", "composite_pk.json"))
+		out := filepath.Join(t.TempDir(), "models")
+		if err := os.Mkdir(out, 0o755); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		ts, err := newTemplateSet(context.Background(), "", "go")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		ts.Use("go")
+		ctx := context.WithValue(context.Background(), xo.DriverKey, set.Schemas[0].Driver)
+		ctx = context.WithValue(ctx, xo.SchemaKey, set.Schemas[0].Name)
+		ctx = context.WithValue(ctx, xo.OutKey, out)
+		for _, g := range append(ts.Flags("go"), loader.Flags()...) {
+			ctx = context.WithValue(ctx, g.Flag.ContextKey, flagDefault(g.Flag))
+		}
+		args := &Args{
+			OutParams: OutParams{
+				Out:       out,
+				PostHooks: test.hooks,
+			},
+		}
+		err = generate(ctx, "schema", ts, set, args, new(Report))
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d: expected error", i)
+			continue
+		case test.err:
+			continue
+		case err != nil:
+			t.Fatalf("test %d: expected no error, got: %v", i, err)
+		}
+		buf, err := os.ReadFile(filepath.Join(out, "book.dbtpl.go"))
+		if err != nil {
+			t.Fatalf("test %d: expected no error, got: %v", i, err)
+		}
+		if !strings.HasPrefix(string(buf), test.exp) {
+			t.Errorf("test %d: expected prefix %q, got: %q", i, test.exp, string(buf[:min(len(buf), 80)]))
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("DBTPL_TEST_OUT", "models")
+	tests := []struct {
+		s      string
+		expand bool
+		exp    string
+		err    bool
+	}{
+		{"${DBTPL_TEST_OUT}/gen", true, "models/gen", false},
+		{"${DBTPL_TEST_UNSET}", true, "", true},
+		{"pass$DBTPL_TEST_OUT", true, "pass$DBTPL_TEST_OUT", false},
+		{"$", true, "$", false},
+		{`db:"${DBTPL_TEST_UNSET}"`, false, `db:"${DBTPL_TEST_UNSET}"`, false},
+		{"${DBTPL_TEST_OUT}", false, "${DBTPL_TEST_OUT}", false},
+	}
+	for i, test := range tests {
+		s, err := expandFlag(xo.Flag{Expand: test.expand}, test.s)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d: expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d: expected no error, got: %v", i, err)
+		case !test.err && s != test.exp:
+			t.Errorf("test %d: expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestHelpDefaults(t *testing.T) {
+	ts, err := newTemplateSet(context.Background(), "", "go")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	opts, err := rootCommand("dbtpl", ts, &Args{TemplateTypes: ts.Targets()})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	buf := new(bytes.Buffer)
+	ctx, err := ox.NewContext(append(opts, ox.Args("schema"), ox.Pipe(nil, buf, buf))...)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := ctx.Parse(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := ctx.Exec.HelpContext(ctx).WriteTo(buf); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	s := buf.String()
+	if strings.Contains(s, "%!") {
+		t.Errorf("expected no formatting errors, got:\n%s", s)
+	}
+	for _, exp := range []string{
+		"(-1 to disable) (default: -1)",
+		"foreign key depth (default: 0)",
+	} {
+		if !strings.Contains(s, exp) {
+			t.Errorf("expected %q in help, got:\n%s", exp, s)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xo/ox"
+)
+
+// loadConfig loads the config file, returning the flag values it contains
+// keyed by the flag's long name.
+//
+// Example:
+//
+//	template: go
+//	out: models
+//	schema: public
+//	go-context: both
+//	go-esc: [table, column]
+//	go-field-tag: 'json:"{{ .SQLName }}" db:"{{ .SQLName }}"'
+//	go-rename:
+//	  authors.first_name: GivenName
+func loadConfig(name string) (map[string]any, error) {
+	if name == "" {
+		return nil, nil
+	}
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(buf, &config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", name, err)
+	}
+	return config, nil
+}
+
+// configArg returns the config value for the key when s is empty.
+func configArg(config map[string]any, key, s string) string {
+	if v, ok := config[key]; s == "" && ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return s
+}
+
+// configArgs returns args with the config values added as flags for the
+// command named in args. Flags already set in args are not added, so that
+// flags on the command-line override the config.
+func configArgs(opts []ox.Option, config map[string]any, args []string) ([]string, error) {
+	if len(config) == 0 {
+		return args, nil
+	}
+	root, err := ox.NewCommand(opts...)
+	if err != nil {
+		return nil, err
+	}
+	// find command
+	cmd, pos := root, len(args)
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if c := root.Command(arg); c != nil {
+			cmd, pos = c, i+1
+			break
+		}
+	}
+	// build flags
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var flags []string
+	for _, key := range keys {
+		g := cmd.Flag(key, true, false)
+		switch {
+		case g == nil && !knownFlag(root, key):
+			return nil, fmt.Errorf("config: unknown flag %q", key)
+		case g == nil, hasFlag(g, args[:pos]), hasFlag(g, args[pos:]):
+			continue
+		}
+		values, err := configValues(config[key])
+		if err != nil {
+			return nil, fmt.Errorf("config: flag %q: %w", key, err)
+		}
+		for _, v := range values {
+			flags = append(flags, "--"+g.Name+"="+v)
+		}
+	}
+	return slices.Concat(args[:pos], flags, args[pos:]), nil
+}
+
+// knownFlag determines if the flag is defined on cmd or any of its sub
+// commands.
+func knownFlag(cmd *ox.Command, name string) bool {
+	if cmd.Flag(name, false, false) != nil {
+		return true
+	}
+	for _, c := range cmd.Commands {
+		if knownFlag(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlag determines if the flag is set in args.
+func hasFlag(g *ox.Flag, args []string) bool {
+	names := append([]string{g.Name}, g.Aliases...)
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		for _, name := range names {
+			if len(name) == 1 {
+				continue
+			}
+			if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+				return true
+			}
+		}
+		if g.Short != "" && !strings.HasPrefix(arg, "--") && strings.HasPrefix(arg, "-"+g.Short) {
+			return true
+		}
+	}
+	return false
+}
+
+// configValues converts a config value to flag values.
+func configValues(v any) ([]string, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		var values []string
+		for _, z := range x {
+			switch z.(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("invalid list value %v", z)
+			}
+			values = append(values, fmt.Sprint(z))
+		}
+		return values, nil
+	case map[string]any:
+		// maps are converted to sorted <key>=<value> pairs
+		var values []string
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			switch z := x[k].(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("invalid map value %v", z)
+			}
+			values = append(values, k+"="+fmt.Sprint(x[k]))
+		}
+		return values, nil
+	}
+	return []string{fmt.Sprint(v)}, nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigArgs(t *testing.T) {
+	ts, err := newTemplateSet(context.Background(), "", "go")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	opts, err := rootCommand("dbtpl", ts, &Args{TemplateTypes: ts.Targets()})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		name   string
+		config string
+		args   string
+		exp    string
+		err    string
+	}{
+		{
+			"config only",
+			"out: gen\ngo-context: both\n",
+			"dbtpl schema pg://",
+			"dbtpl schema --go-context=both --out=gen pg://",
+			"",
+		},
+		{
+			"cli override",
+			"out: gen\ngo-context: both\n",
+			"dbtpl schema -o models --go-context=only pg://",
+			"dbtpl schema -o models --go-context=only pg://",
+			"",
+		},
+		{
+			"cli override long",
+			"out: gen\n",
+			"dbtpl schema --out=models pg://",
+			"dbtpl schema --out=models pg://",
+			"",
+		},
+		{
+			"list",
+			"go-esc: [table, column]\n",
+			"dbtpl schema pg://",
+			"dbtpl schema --go-esc=table --go-esc=column pg://",
+			"",
+		},
+		{
+			"map",
+			"go-rename:\n  authors.name: FullName\n  authors.id: AuthorID\n",
+			"dbtpl schema pg://",
+			"dbtpl schema --go-rename=authors.id=AuthorID --go-rename=authors.name=FullName pg://",
+			"",
+		},
+		{
+			"other command flag",
+			"type: Author\n",
+			"dbtpl schema pg://",
+			"dbtpl schema pg://",
+			"",
+		},
+		{
+			"no command",
+			"out: gen\n",
+			"dbtpl",
+			"dbtpl",
+			"",
+		},
+		{
+			"unknown key",
+			"go-bogus: true\n",
+			"dbtpl schema pg://",
+			"",
+			`config: unknown flag "go-bogus"`,
+		},
+		{
+			"nested list",
+			"go-esc: [[table]]\n",
+			"dbtpl schema pg://",
+			"",
+			`config: flag "go-esc": invalid list value`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "dbtpl.yaml")
+			if err := os.WriteFile(name, []byte(test.config), 0o644); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			config, err := loadConfig(name)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			args, err := configArgs(opts, config, strings.Fields(test.args))
+			switch {
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Fatalf("expected error %q, got: %v", test.err, err)
+			case test.err != "":
+				return
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s := strings.Join(args, " "); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
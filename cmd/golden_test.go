@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xo/dbtpl/loader"
+	xo "github.com/xo/dbtpl/types"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestGolden renders the go template for each xo.Set fixture in testdata,
+// comparing the generated files with the golden files in testdata/<name>.
+//
+// Run with -update to regenerate the golden files after a template change.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		t.Run(name, func(t *testing.T) {
+			set := loadFixture(t, file)
+			out := filepath.Join(t.TempDir(), "models")
+			if err := os.Mkdir(out, 0o755); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			render(context.Background(), t, set, OutParams{Out: out})
+			dir := filepath.Join("testdata", name)
+			if *update {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				if err := os.CopyFS(dir, os.DirFS(out)); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return
+			}
+			compareGolden(t, out, dir)
+		})
+	}
+}
+
+// TestDeterministic renders the go template for each xo.Set fixture in
+// testdata several times, in the default and single file modes and with the
+// flags generating the cross table funcs, checking the generated files are
+// byte-identical across generations.
+func TestDeterministic(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		name   string
+		values map[xo.ContextKey]any
+	}{
+		{"default", nil},
+		{"single", map[xo.ContextKey]any{
+			xo.SingleKey: "models.dbtpl.go",
+		}},
+		{"flags", map[xo.ContextKey]any{
+			"narrow-db":     true,
+			"hooks":         true,
+			"catalog":       true,
+			"insert-many":   true,
+			"upsert-index":  true,
+			"paged":         true,
+			"cascade":       true,
+			"unit-of-work":  true,
+			"preload-depth": 2,
+			"preload-json":  true,
+			"tests":         true,
+		}},
+	}
+	for _, file := range files {
+		for _, test := range tests {
+			name := strings.TrimSuffix(filepath.Base(file), ".json") + "/" + test.name
+			t.Run(name, func(t *testing.T) {
+				ctx := context.Background()
+				for k, v := range test.values {
+					ctx = context.WithValue(ctx, k, v)
+				}
+				var dirs []string
+				for i := 0; i < 3; i++ {
+					out := filepath.Join(t.TempDir(), "models")
+					if err := os.Mkdir(out, 0o755); err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					render(ctx, t, loadFixture(t, file), OutParams{Out: out})
+					dirs = append(dirs, out)
+				}
+				for _, out := range dirs[1:] {
+					compareGolden(t, out, dirs[0])
+				}
+			})
+		}
+	}
+}
+
+// TestStream renders the go template for each xo.Set fixture in testdata with
+// --stream, comparing the generated files with the golden files.
+func TestStream(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "models")
+			if err := os.Mkdir(out, 0o755); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			render(context.Background(), t, loadFixture(t, file), OutParams{Out: out, Stream: true})
+			compareGolden(t, out, filepath.Join("testdata", name))
+		})
+	}
+}
+
+// loadFixture loads a xo.Set fixture, setting the index and foreign key func
+// names.
+func loadFixture(t *testing.T, file string) *xo.Set {
+	t.Helper()
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	set := new(xo.Set)
+	if err := json.Unmarshal(buf, set); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i := range set.Schemas {
+		schemaFuncNames(context.Background(), &set.Schemas[i], &Args{
+			SchemaParams: SchemaParams{
+				FkMode: "smart",
+			},
+		})
+	}
+	return set
+}
+
+// render renders the go template for the set to the out params' out, using the
+// default flag values for the flags not set on the context.
+func render(ctx context.Context, t *testing.T, set *xo.Set, params OutParams) {
+	t.Helper()
+	if len(set.Schemas) != 1 {
+		t.Fatalf("expected 1 schema, got: %d", len(set.Schemas))
+	}
+	ts, err := newTemplateSet(ctx, "", "go")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ts.Use("go")
+	ctx = context.WithValue(ctx, xo.DriverKey, set.Schemas[0].Driver)
+	ctx = context.WithValue(ctx, xo.SchemaKey, set.Schemas[0].Name)
+	ctx = context.WithValue(ctx, xo.OutKey, params.Out)
+	for _, g := range append(ts.Flags("go"), loader.Flags()...) {
+		if ctx.Value(g.Flag.ContextKey) == nil {
+			ctx = context.WithValue(ctx, g.Flag.ContextKey, flagDefault(g.Flag))
+		}
+	}
+	args := &Args{
+		OutParams: params,
+	}
+	if err := generate(ctx, "schema", ts, set, args, new(Report)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+// flagDefault returns the default value of the flag, as set by addFlag.
+func flagDefault(flag xo.Flag) any {
+	switch flag.Type {
+	case "bool":
+		b, _ := flag.Default.(bool)
+		return b
+	case "int":
+		i, _ := flag.Default.(int)
+		return i
+	case "[]string":
+		switch x := flag.Default.(type) {
+		case string:
+			if x != "" {
+				return strings.Split(x, ",")
+			}
+		case []string:
+			return x
+		}
+		return []string(nil)
+	}
+	s, _ := flag.Default.(string)
+	if s == "" && len(flag.Enums) != 0 {
+		s = flag.Enums[0]
+	}
+	return s
+}
+
+// compareGolden compares the files in out with the golden files in dir.
+func compareGolden(t *testing.T, out, dir string) {
+	t.Helper()
+	names := func(dir string) []string {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v (run with -update to create)", err)
+		}
+		var v []string
+		for _, entry := range entries {
+			v = append(v, entry.Name())
+		}
+		return v
+	}
+	exp, files := names(dir), names(out)
+	if s, z := strings.Join(exp, " "), strings.Join(files, " "); s != z {
+		t.Errorf("expected files %q, got: %q", s, z)
+	}
+	for _, name := range files {
+		a, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s: expected golden output, got:\n%s", name, firstDiff(a, b))
+		}
+	}
+}
+
+// firstDiff returns the first differing line of a and b.
+func firstDiff(a, b []byte) string {
+	x, y := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var s, z string
+		if i < len(x) {
+			s = x[i]
+		}
+		if i < len(y) {
+			z = y[i]
+		}
+		if s != z {
+			return "line " + strconv.Itoa(i+1) + ":\n- " + s + "\n+ " + z
+		}
+	}
+	return ""
+}
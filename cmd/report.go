@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Report is a machine-readable generation report, collecting the non-fatal
+// issues encountered while loading and generating.
+type Report struct {
+	// Mode is the generation mode (schema/query).
+	Mode string `json:"mode"`
+	// Template is the template type.
+	Template string `json:"template"`
+	// Warnings are the collected warnings.
+	Warnings []xo.Warning `json:"warnings"`
+}
+
+// add adds a warning to the report, skipping duplicates. Warnings with level
+// warn are written to stderr.
+func (r *Report) add(w xo.Warning) {
+	if slices.Contains(r.Warnings, w) {
+		return
+	}
+	r.Warnings = append(r.Warnings, w)
+	if w.Level == "warn" {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", w.Name, w.Message)
+	}
+}
+
+// count returns the number of warnings with level warn.
+func (r *Report) count() int {
+	var n int
+	for _, w := range r.Warnings {
+		if w.Level == "warn" {
+			n++
+		}
+	}
+	return n
+}
+
+// write writes the report as JSON to the named file, or to stdout when name
+// is "-".
+func (r *Report) write(name string) error {
+	if r.Warnings == nil {
+		r.Warnings = []xo.Warning{}
+	}
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+	if name == "-" {
+		_, err := os.Stdout.Write(buf)
+		return err
+	}
+	return os.WriteFile(name, buf, 0o644)
+}
+
+// check returns an error when the number of warnings exceeds max. A negative
+// max disables the check.
+func (r *Report) check(max int) error {
+	if n := r.count(); max >= 0 && n > max {
+		return fmt.Errorf("%d warnings exceed --max-warnings=%d", n, max)
+	}
+	return nil
+}
+
+// strict returns an error listing the columns with unmapped types.
+func (r *Report) strict() error {
+	var v []string
+	for _, w := range r.Warnings {
+		if w.Kind == "type" {
+			v = append(v, w.Name+": "+w.Message)
+		}
+	}
+	if len(v) == 0 {
+		return nil
+	}
+	return fmt.Errorf("--strict-types: %d columns with unmapped types:\n  %s", len(v), strings.Join(v, "\n  "))
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	sql := `CREATE TABLE person (person_id serial PRIMARY KEY, data jsonb, flags bit varying, avatar bytea, geom geometry);
+CREATE TABLE people (people_id serial PRIMARY KEY, parent_id integer REFERENCES person, child_id integer REFERENCES person);`
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte(sql), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	report := new(Report)
+	ctx := context.WithValue(context.Background(), xo.WarnKey, report.add)
+	args := &Args{
+		SchemaParams: SchemaParams{
+			FkMode: "smart",
+			From:   filepath.Join(dir, "schema.sql"),
+		},
+	}
+	ctx, set, err := loadSchemaFile(ctx, args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	out := filepath.Join(dir, "models")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	render(ctx, t, set, OutParams{Out: out})
+	exp := map[string]int{
+		"type":        2, // person.flags, person.geom
+		"collision":   3, // people -> person, parent_id and child_id func names
+		"singularize": 1, // people
+	}
+	kinds := make(map[string]int)
+	for _, w := range report.Warnings {
+		kinds[w.Kind]++
+	}
+	for kind, n := range exp {
+		if kinds[kind] != n {
+			t.Errorf("expected %d %s warnings, got: %d (%v)", n, kind, kinds[kind], report.Warnings)
+		}
+	}
+	if n := report.count(); n != 3 {
+		t.Errorf("expected 3 warnings with level warn, got: %d", n)
+	}
+	if err := report.check(3); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if err := report.check(2); err == nil {
+		t.Errorf("expected error")
+	}
+	if err := report.strict(); err == nil || !strings.Contains(err.Error(), "person.geom") {
+		t.Errorf("expected error listing person.geom, got: %v", err)
+	}
+	name := filepath.Join(dir, "report.json")
+	if err := report.write(name); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var r Report
+	if err := json.Unmarshal(buf, &r); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(r.Warnings) != len(report.Warnings) {
+		t.Errorf("expected %d warnings, got: %d", len(report.Warnings), len(r.Warnings))
+	}
+}
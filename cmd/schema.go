@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/goccy/go-yaml"
 	"github.com/kenshaw/inflector"
 	"github.com/xo/dbtpl/loader"
 	"github.com/xo/dbtpl/models"
@@ -38,19 +43,221 @@ func loadSchema(ctx context.Context, set *xo.Set, args *Args) error {
 	}
 	// fix enums for mysql
 	if driver == "mysql" {
-		for i := range len(schema.Tables) {
-			for j := range len(schema.Tables[i].Columns) {
-				if e := schema.EnumByName(schema.Tables[i].Columns[j].Type.Type); e != nil {
-					schema.Tables[i].Columns[j].Type.Enum = e
-				}
-			}
-		}
+		mysqlEnums(&schema)
 	}
 	// emit
 	set.Schemas = append(set.Schemas, schema)
 	return nil
 }
 
+// mysqlEnums sets the enum of the schema's table columns, as mysql enums are
+// defined inline on columns.
+func mysqlEnums(schema *xo.Schema) {
+	for i := range len(schema.Tables) {
+		for j := range len(schema.Tables[i].Columns) {
+			if e := schema.EnumByName(schema.Tables[i].Columns[j].Type.Type); e != nil {
+				schema.Tables[i].Columns[j].Type.Enum = e
+			}
+		}
+	}
+}
+
+// loadSchemaFile loads a schema document (as written by --dump-schema or the
+// json and yaml templates), or a schema source (sql, Prisma, gorm or ent),
+// returning a context for use in template generation.
+func loadSchemaFile(ctx context.Context, args *Args) (context.Context, *xo.Set, error) {
+	name := args.SchemaParams.From
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	ext := filepath.Ext(name)
+	if fi.IsDir() {
+		if ext, err = sourceExt(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	var set *xo.Set
+	switch ext {
+	case ".sql", ".prisma", ".go":
+		set, err = loadSource(name, ext, args.LoaderParams.Schema)
+	default:
+		set, err = loadDocument(name)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(set.Schemas) != 1 {
+		return nil, nil, fmt.Errorf("%s: must contain exactly one schema, has %d", name, len(set.Schemas))
+	}
+	schema := &set.Schemas[0]
+	if schema.Driver == "" {
+		return nil, nil, fmt.Errorf("%s: schema type not defined", name)
+	}
+	if args.LoaderParams.Schema != "" && args.LoaderParams.Schema != schema.Name {
+		return nil, nil, fmt.Errorf("%s: schema %q not found", name, args.LoaderParams.Schema)
+	}
+	// fix enums for mysql
+	if schema.Driver == "mysql" {
+		mysqlEnums(schema)
+	}
+	schemaFuncNames(ctx, schema, args)
+	ctx = context.WithValue(ctx, xo.DriverKey, schema.Driver)
+	ctx = context.WithValue(ctx, xo.SchemaKey, schema.Name)
+	return ctx, set, nil
+}
+
+// loadDocument loads a schema document (json or yaml).
+func loadDocument(name string) (*xo.Set, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	set := new(xo.Set)
+	switch ext := filepath.Ext(name); ext {
+	case ".json":
+		err = json.Unmarshal(buf, set)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, set)
+	default:
+		return nil, fmt.Errorf("%s: unknown schema document type %q", name, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return set, nil
+}
+
+// schemaSource is a schema source.
+type schemaSource interface {
+	Parse(string) error
+	Schema() (xo.Schema, error)
+}
+
+// sourceExt returns the extension of the schema source files in a directory.
+func sourceExt(dir string) (string, error) {
+	for _, ext := range []string{".prisma", ".go", ".sql"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+		if err != nil {
+			return "", err
+		}
+		if len(files) != 0 {
+			return ext, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no schema files", dir)
+}
+
+// loadSource loads a schema from a schema source file, or from the source
+// files with the extension in a directory, in name order:
+//
+//	.sql    - postgres DDL (such as migrations, down migrations are skipped)
+//	.prisma - Prisma schema
+//	.go     - ent schema, or gorm models
+func loadSource(name, ext, schema string) (*xo.Set, error) {
+	files := []string{name}
+	if filepath.Ext(name) != ext {
+		var err error
+		if files, err = filepath.Glob(filepath.Join(name, "*"+ext)); err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+	srcs := make(map[string]string)
+	for _, file := range files {
+		if strings.HasSuffix(file, ".down.sql") || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		srcs[file] = string(buf)
+	}
+	var src schemaSource
+	switch ext {
+	case ".sql":
+		if schema == "" {
+			schema = "public"
+		}
+		src = loader.NewPostgresDDL(schema)
+	case ".prisma":
+		src = loader.NewPrisma(schema)
+	case ".go":
+		src = loader.NewGorm(schema)
+		for _, s := range srcs {
+			if strings.Contains(s, `"entgo.io/ent"`) {
+				src = loader.NewEnt(schema)
+			}
+		}
+	}
+	for _, file := range files {
+		s, ok := srcs[file]
+		if !ok {
+			continue
+		}
+		if ext == ".sql" {
+			s = upMigration(s)
+		}
+		if err := src.Parse(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	s, err := src.Schema()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &xo.Set{Schemas: []xo.Schema{s}}, nil
+}
+
+// upMigration returns the up migration of a goose or dbmate migration.
+func upMigration(s string) string {
+	for _, marker := range []string{"-- +goose Down", "-- migrate:down"} {
+		if i := strings.Index(s, marker); i != -1 {
+			s = s[:i]
+		}
+	}
+	return s
+}
+
+// schemaFuncNames sets the index and foreign key func names of the schema,
+// which are not included in schema documents.
+func schemaFuncNames(ctx context.Context, schema *xo.Schema, args *Args) {
+	for _, tables := range [][]xo.Table{schema.Tables, schema.Views} {
+		for _, table := range tables {
+			for i, index := range table.Indexes {
+				table.Indexes[i].Func = indexFuncName(index, table.Name, args.SchemaParams.UseIndexNames)
+			}
+			for i, fkey := range table.ForeignKeys {
+				table.ForeignKeys[i].Func = fkName(ctx, fkey, table, args.SchemaParams.FkMode)
+				table.ForeignKeys[i].RefFunc = indexFuncName(xo.Index{
+					IsUnique: true,
+					Fields:   fkey.RefFields,
+				}, fkey.RefTable, false)
+			}
+		}
+	}
+}
+
+// dumpSchema writes the set to a JSON or YAML schema document.
+func dumpSchema(name string, set *xo.Set) error {
+	var buf []byte
+	var err error
+	switch ext := filepath.Ext(name); ext {
+	case ".json":
+		buf, err = json.MarshalIndent(set, "", "  ")
+		buf = append(buf, '\n')
+	case ".yaml", ".yml":
+		buf, err = yaml.Marshal(set)
+	default:
+		return fmt.Errorf("%s: unknown schema document type %q", name, ext)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf, 0o644)
+}
+
 // loadEnums loads enums.
 func loadEnums(ctx context.Context, args *Args) ([]xo.Enum, error) {
 	// load enums
@@ -385,7 +592,7 @@ func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, ta
 	for _, fkey := range foreignKeys {
 		// if the referenced table is excluded, we don't want to omit it
 		if !validType(args, false, fkey.RefTableName) {
-			fmt.Fprintf(os.Stderr, "WARNING: skipping table %q foreign key %q (%q previously excluded)\n", table.Name, fkey.ForeignKeyName, fkey.RefTableName)
+			xo.Warnf(ctx, "skip", table.Name, "skipping foreign key %q (%q previously excluded)", fkey.ForeignKeyName, fkey.RefTableName)
 			continue
 		}
 		// check foreign key
@@ -420,7 +627,7 @@ func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, ta
 			fkey.Name = table.Name + "_" + strings.Join(names, "_") + "_fkey"
 		}
 		// determine foreign key func name
-		fkey.Func = resolveFkName(fkey, table, args.SchemaParams.FkMode)
+		fkey.Func = fkName(ctx, fkey, table, args.SchemaParams.FkMode)
 		// foreign key called func name
 		fkey.RefFunc = indexFuncName(xo.Index{
 			IsUnique: true,
@@ -428,9 +635,14 @@ func loadTableForeignKeys(ctx context.Context, args *Args, tables []xo.Table, ta
 		}, fkey.RefTable, false)
 		fkeys = append(fkeys, fkey)
 	}
-	// sort fkeys
-	sort.Slice(fkeys, func(i, j int) bool {
-		return fkeys[i].Name < fkeys[j].Name
+	// sort fkeys (generated names may collide, so break ties as fkMap
+	// iteration order is random)
+	slices.SortFunc(fkeys, func(a, b xo.ForeignKey) int {
+		return cmp.Or(
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.RefTable, b.RefTable),
+			cmp.Compare(a.Func, b.Func),
+		)
 	})
 	return fkeys, nil
 }
@@ -544,6 +756,16 @@ func resolveFkName(fkey xo.ForeignKey, table xo.Table, mode string) string {
 	panic(fmt.Sprintf("invalid mode %q", mode))
 }
 
+// fkName resolves the func name for a foreign key, reporting when the smart
+// mode resolved a naming conflict.
+func fkName(ctx context.Context, fkey xo.ForeignKey, table xo.Table, mode string) string {
+	name := resolveFkName(fkey, table, mode)
+	if mode == "smart" && name != resolveFkName(fkey, table, "parent") {
+		xo.Infof(ctx, "collision", table.Name, "foreign key %q func name resolved to %s", fkey.Name, name)
+	}
+	return name
+}
+
 // indexFuncName creates the func name for an index and its supplied fields.
 func indexFuncName(index xo.Index, tableName string, useIndexNames bool) string {
 	// func name
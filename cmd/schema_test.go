@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestSchemaFile(t *testing.T) {
+	args := &Args{
+		SchemaParams: SchemaParams{
+			FkMode: "smart",
+			From:   filepath.Join("testdata", "composite_pk.json"),
+		},
+	}
+	ctx, exp, err := loadSchemaFile(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := ctx.Value(xo.DriverKey); s != "postgres" {
+		t.Errorf("expected driver postgres, got: %v", s)
+	}
+	if s := exp.Schemas[0].Tables[2].ForeignKeys[0].Func; s != "author" {
+		t.Errorf("expected foreign key func author, got: %q", s)
+	}
+	for _, name := range []string{"schema.json", "schema.yaml"} {
+		args.SchemaParams.From = filepath.Join(t.TempDir(), name)
+		if err := dumpSchema(args.SchemaParams.From, exp); err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		_, set, err := loadSchemaFile(context.Background(), args)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		if !reflect.DeepEqual(exp, set) {
+			t.Errorf("%s: expected %v, got: %v", name, exp, set)
+		}
+	}
+}
+
+func TestSchemaFileDDL(t *testing.T) {
+	dir := t.TempDir()
+	for name, sql := range map[string]string{
+		"001_authors.sql":    "-- +goose Up\nCREATE TABLE authors (author_id serial PRIMARY KEY);\n-- +goose Down\nDROP TABLE authors;\n",
+		"002_books.up.sql":   "CREATE TABLE books (book_id serial PRIMARY KEY, author_id integer NOT NULL REFERENCES authors);\n",
+		"002_books.down.sql": "DROP TABLE books;\n",
+		"003_reviews.sql":    "-- migrate:up\nCREATE TABLE reviews (review_id serial PRIMARY KEY);\n-- migrate:down\nDROP TABLE books;\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0o644); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	args := &Args{
+		SchemaParams: SchemaParams{
+			FkMode: "smart",
+			From:   dir,
+		},
+	}
+	ctx, set, err := loadSchemaFile(context.Background(), args)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := ctx.Value(xo.SchemaKey); s != "public" {
+		t.Errorf("expected schema public, got: %v", s)
+	}
+	tables := set.Schemas[0].Tables
+	if len(tables) != 3 {
+		t.Fatalf("expected 3 tables, got: %d", len(tables))
+	}
+	if s := tables[1].ForeignKeys[0].Func; s != "author" {
+		t.Errorf("expected foreign key func author, got: %q", s)
+	}
+}
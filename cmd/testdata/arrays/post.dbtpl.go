@@ -0,0 +1,155 @@
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// Post represents a row from 'public.posts'.
+type Post struct {
+	PostID  int             `json:"post_id"` // post_id
+	Tags    pq.StringArray  `json:"tags"`    // tags
+	Scores  pq.GenericArray `json:"scores"`  // scores
+	Ratings pq.Float64Array `json:"ratings"` // ratings
+	// xo fields
+	_exists, _deleted bool
+}
+
+// Exists returns true when the [Post] exists in the database.
+func (p *Post) Exists() bool {
+	return p._exists
+}
+
+// Deleted returns true when the [Post] has been marked for deletion
+// from the database.
+func (p *Post) Deleted() bool {
+	return p._deleted
+}
+
+// Insert inserts the [Post] to the database.
+func (p *Post) Insert(ctx context.Context, db DB) error {
+	switch {
+	case p._exists: // already exists
+		return logerror(&ErrInsertFailed{ErrAlreadyExists})
+	case p._deleted: // deleted
+		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO public.posts (` +
+		`tags, scores, ratings` +
+		`) VALUES (` +
+		`$1, $2, $3` +
+		`) RETURNING post_id`
+	// run
+	logf(sqlstr, p.Tags, p.Scores, p.Ratings)
+	if err := db.QueryRowContext(ctx, sqlstr, p.Tags, p.Scores, p.Ratings).Scan(&p.PostID); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	p._exists = true
+	return nil
+}
+
+// Update updates a [Post] in the database.
+func (p *Post) Update(ctx context.Context, db DB) error {
+	switch {
+	case !p._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case p._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	// update with composite primary key
+	const sqlstr = `UPDATE public.posts SET ` +
+		`tags = $1, scores = $2, ratings = $3 ` +
+		`WHERE post_id = $4`
+	// run
+	logf(sqlstr, p.Tags, p.Scores, p.Ratings, p.PostID)
+	if _, err := db.ExecContext(ctx, sqlstr, p.Tags, p.Scores, p.Ratings, p.PostID); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+
+// Save saves the [Post] to the database.
+func (p *Post) Save(ctx context.Context, db DB) error {
+	if p.Exists() {
+		return p.Update(ctx, db)
+	}
+	return p.Insert(ctx, db)
+}
+
+// Upsert performs an upsert for [Post].
+func (p *Post) Upsert(ctx context.Context, db DB) error {
+	switch {
+	case p._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+	// upsert
+	const sqlstr = `INSERT INTO public.posts (` +
+		`post_id, tags, scores, ratings` +
+		`) VALUES (` +
+		`$1, $2, $3, $4` +
+		`)` +
+		` ON CONFLICT (post_id) DO ` +
+		`UPDATE SET ` +
+		`tags = EXCLUDED.tags, scores = EXCLUDED.scores, ratings = EXCLUDED.ratings `
+	// run
+	logf(sqlstr, p.PostID, p.Tags, p.Scores, p.Ratings)
+	if _, err := db.ExecContext(ctx, sqlstr, p.PostID, p.Tags, p.Scores, p.Ratings); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	p._exists = true
+	return nil
+}
+
+// Delete deletes the [Post] from the database.
+func (p *Post) Delete(ctx context.Context, db DB) error {
+	switch {
+	case !p._exists: // doesn't exist
+		return nil
+	case p._deleted: // deleted
+		return nil
+	}
+	// delete with single primary key
+	const sqlstr = `DELETE FROM public.posts ` +
+		`WHERE post_id = $1`
+	// run
+	logf(sqlstr, p.PostID)
+	if _, err := db.ExecContext(ctx, sqlstr, p.PostID); err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	p._deleted = true
+	return nil
+}
+
+// Post constraint and index names, such as for matching the
+// constraint name of a database error.
+const (
+	// ConstraintPostsPkey is the 'posts_pkey' primary key.
+	ConstraintPostsPkey = "posts_pkey"
+)
+
+// PostByPostID retrieves a row from 'public.posts' as a [Post].
+//
+// Generated from index 'posts_pkey'.
+func PostByPostID(ctx context.Context, db DB, postID int) (*Post, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`post_id, tags, scores, ratings ` +
+		`FROM public.posts ` +
+		`WHERE post_id = $1`
+	// run
+	logf(sqlstr, postID)
+	p := Post{
+		_exists: true,
+	}
+	if err := db.QueryRowContext(ctx, sqlstr, postID).Scan(&p.PostID, &p.Tags, &p.Scores, &p.Ratings); err != nil {
+		return nil, logerror(err)
+	}
+	return &p, nil
+}
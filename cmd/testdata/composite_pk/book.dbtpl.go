@@ -0,0 +1,151 @@
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+)
+
+// Book represents a row from 'public.books'.
+type Book struct {
+	BookID int    `json:"book_id"` // book_id
+	Title  string `json:"title"`   // title
+	// xo fields
+	_exists, _deleted bool
+}
+
+// Exists returns true when the [Book] exists in the database.
+func (b *Book) Exists() bool {
+	return b._exists
+}
+
+// Deleted returns true when the [Book] has been marked for deletion
+// from the database.
+func (b *Book) Deleted() bool {
+	return b._deleted
+}
+
+// Insert inserts the [Book] to the database.
+func (b *Book) Insert(ctx context.Context, db DB) error {
+	switch {
+	case b._exists: // already exists
+		return logerror(&ErrInsertFailed{ErrAlreadyExists})
+	case b._deleted: // deleted
+		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO public.books (` +
+		`title` +
+		`) VALUES (` +
+		`$1` +
+		`) RETURNING book_id`
+	// run
+	logf(sqlstr, b.Title)
+	if err := db.QueryRowContext(ctx, sqlstr, b.Title).Scan(&b.BookID); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	b._exists = true
+	return nil
+}
+
+// Update updates a [Book] in the database.
+func (b *Book) Update(ctx context.Context, db DB) error {
+	switch {
+	case !b._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case b._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	// update with composite primary key
+	const sqlstr = `UPDATE public.books SET ` +
+		`title = $1 ` +
+		`WHERE book_id = $2`
+	// run
+	logf(sqlstr, b.Title, b.BookID)
+	if _, err := db.ExecContext(ctx, sqlstr, b.Title, b.BookID); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+
+// Save saves the [Book] to the database.
+func (b *Book) Save(ctx context.Context, db DB) error {
+	if b.Exists() {
+		return b.Update(ctx, db)
+	}
+	return b.Insert(ctx, db)
+}
+
+// Upsert performs an upsert for [Book].
+func (b *Book) Upsert(ctx context.Context, db DB) error {
+	switch {
+	case b._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+	// upsert
+	const sqlstr = `INSERT INTO public.books (` +
+		`book_id, title` +
+		`) VALUES (` +
+		`$1, $2` +
+		`)` +
+		` ON CONFLICT (book_id) DO ` +
+		`UPDATE SET ` +
+		`title = EXCLUDED.title `
+	// run
+	logf(sqlstr, b.BookID, b.Title)
+	if _, err := db.ExecContext(ctx, sqlstr, b.BookID, b.Title); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	b._exists = true
+	return nil
+}
+
+// Delete deletes the [Book] from the database.
+func (b *Book) Delete(ctx context.Context, db DB) error {
+	switch {
+	case !b._exists: // doesn't exist
+		return nil
+	case b._deleted: // deleted
+		return nil
+	}
+	// delete with single primary key
+	const sqlstr = `DELETE FROM public.books ` +
+		`WHERE book_id = $1`
+	// run
+	logf(sqlstr, b.BookID)
+	if _, err := db.ExecContext(ctx, sqlstr, b.BookID); err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	b._deleted = true
+	return nil
+}
+
+// Book constraint and index names, such as for matching the
+// constraint name of a database error.
+const (
+	// ConstraintBooksPkey is the 'books_pkey' primary key.
+	ConstraintBooksPkey = "books_pkey"
+)
+
+// BookByBookID retrieves a row from 'public.books' as a [Book].
+//
+// Generated from index 'books_pkey'.
+func BookByBookID(ctx context.Context, db DB, bookID int) (*Book, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`book_id, title ` +
+		`FROM public.books ` +
+		`WHERE book_id = $1`
+	// run
+	logf(sqlstr, bookID)
+	b := Book{
+		_exists: true,
+	}
+	if err := db.QueryRowContext(ctx, sqlstr, bookID).Scan(&b.BookID, &b.Title); err != nil {
+		return nil, logerror(err)
+	}
+	return &b, nil
+}
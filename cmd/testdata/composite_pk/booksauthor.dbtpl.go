@@ -0,0 +1,207 @@
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+	"database/sql"
+)
+
+// BooksAuthor represents a row from 'public.books_authors'.
+type BooksAuthor struct {
+	BookID   int            `json:"book_id"`   // book_id
+	AuthorID int            `json:"author_id"` // author_id
+	Role     sql.NullString `json:"role"`      // role
+	// xo fields
+	_exists, _deleted bool
+}
+
+// Exists returns true when the [BooksAuthor] exists in the database.
+func (ba *BooksAuthor) Exists() bool {
+	return ba._exists
+}
+
+// Deleted returns true when the [BooksAuthor] has been marked for deletion
+// from the database.
+func (ba *BooksAuthor) Deleted() bool {
+	return ba._deleted
+}
+
+// Insert inserts the [BooksAuthor] to the database.
+func (ba *BooksAuthor) Insert(ctx context.Context, db DB) error {
+	switch {
+	case ba._exists: // already exists
+		return logerror(&ErrInsertFailed{ErrAlreadyExists})
+	case ba._deleted: // deleted
+		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO public.books_authors (` +
+		`book_id, author_id, role` +
+		`) VALUES (` +
+		`$1, $2, $3` +
+		`) RETURNING `
+	// run
+	logf(sqlstr, ba.Role)
+	if err := db.QueryRowContext(ctx, sqlstr, ba.BookID, ba.AuthorID, ba.Role).Scan(&ba.BookID); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	ba._exists = true
+	return nil
+}
+
+// Update updates a [BooksAuthor] in the database.
+func (ba *BooksAuthor) Update(ctx context.Context, db DB) error {
+	switch {
+	case !ba._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case ba._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	// update with composite primary key
+	const sqlstr = `UPDATE public.books_authors SET ` +
+		`role = $1 ` +
+		`WHERE book_id = $2 AND author_id = $3`
+	// run
+	logf(sqlstr, ba.Role, ba.BookID, ba.AuthorID)
+	if _, err := db.ExecContext(ctx, sqlstr, ba.Role, ba.BookID, ba.AuthorID); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+
+// Save saves the [BooksAuthor] to the database.
+func (ba *BooksAuthor) Save(ctx context.Context, db DB) error {
+	if ba.Exists() {
+		return ba.Update(ctx, db)
+	}
+	return ba.Insert(ctx, db)
+}
+
+// Upsert performs an upsert for [BooksAuthor].
+func (ba *BooksAuthor) Upsert(ctx context.Context, db DB) error {
+	switch {
+	case ba._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+	// upsert
+	const sqlstr = `INSERT INTO public.books_authors (` +
+		`book_id, author_id, role` +
+		`) VALUES (` +
+		`$1, $2, $3` +
+		`)` +
+		` ON CONFLICT (book_id, author_id) DO ` +
+		`UPDATE SET ` +
+		`role = EXCLUDED.role `
+	// run
+	logf(sqlstr, ba.BookID, ba.AuthorID, ba.Role)
+	if _, err := db.ExecContext(ctx, sqlstr, ba.BookID, ba.AuthorID, ba.Role); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	ba._exists = true
+	return nil
+}
+
+// Delete deletes the [BooksAuthor] from the database.
+func (ba *BooksAuthor) Delete(ctx context.Context, db DB) error {
+	switch {
+	case !ba._exists: // doesn't exist
+		return nil
+	case ba._deleted: // deleted
+		return nil
+	}
+	// delete with composite primary key
+	const sqlstr = `DELETE FROM public.books_authors ` +
+		`WHERE book_id = $1 AND author_id = $2`
+	// run
+	logf(sqlstr, ba.BookID, ba.AuthorID)
+	if _, err := db.ExecContext(ctx, sqlstr, ba.BookID, ba.AuthorID); err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	ba._deleted = true
+	return nil
+}
+
+// BooksAuthor constraint and index names, such as for matching the
+// constraint name of a database error.
+const (
+	// ConstraintBooksAuthorsPkey is the 'books_authors_pkey' primary key.
+	ConstraintBooksAuthorsPkey = "books_authors_pkey"
+	// IndexBooksAuthorsAuthorIDIdx is the 'books_authors_author_id_idx' index.
+	IndexBooksAuthorsAuthorIDIdx = "books_authors_author_id_idx"
+	// ConstraintBooksAuthorsAuthorIDFkey is the 'books_authors_author_id_fkey' foreign key.
+	ConstraintBooksAuthorsAuthorIDFkey = "books_authors_author_id_fkey"
+	// ConstraintBooksAuthorsBookIDFkey is the 'books_authors_book_id_fkey' foreign key.
+	ConstraintBooksAuthorsBookIDFkey = "books_authors_book_id_fkey"
+)
+
+// BooksAuthorsByAuthorID retrieves a row from 'public.books_authors' as a [BooksAuthor].
+//
+// Generated from index 'books_authors_author_id_idx'.
+func BooksAuthorsByAuthorID(ctx context.Context, db DB, authorID int) ([]*BooksAuthor, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`book_id, author_id, role ` +
+		`FROM public.books_authors ` +
+		`WHERE author_id = $1`
+	// run
+	logf(sqlstr, authorID)
+	rows, err := db.QueryContext(ctx, sqlstr, authorID)
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*BooksAuthor
+	for rows.Next() {
+		ba := BooksAuthor{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan(&ba.BookID, &ba.AuthorID, &ba.Role); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &ba)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
+// BooksAuthorByBookIDAuthorID retrieves a row from 'public.books_authors' as a [BooksAuthor].
+//
+// Generated from index 'books_authors_pkey'.
+func BooksAuthorByBookIDAuthorID(ctx context.Context, db DB, bookID, authorID int) (*BooksAuthor, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`book_id, author_id, role ` +
+		`FROM public.books_authors ` +
+		`WHERE book_id = $1 AND author_id = $2`
+	// run
+	logf(sqlstr, bookID, authorID)
+	ba := BooksAuthor{
+		_exists: true,
+	}
+	if err := db.QueryRowContext(ctx, sqlstr, bookID, authorID).Scan(&ba.BookID, &ba.AuthorID, &ba.Role); err != nil {
+		return nil, logerror(err)
+	}
+	return &ba, nil
+}
+
+// Author returns the Author associated with the [BooksAuthor]'s (AuthorID).
+//
+// Generated from foreign key 'books_authors_author_id_fkey'.
+func (ba *BooksAuthor) Author(ctx context.Context, db DB) (*Author, error) {
+	return AuthorByAuthorID(ctx, db, ba.AuthorID)
+}
+
+// Book returns the Book associated with the [BooksAuthor]'s (BookID).
+//
+// Generated from foreign key 'books_authors_book_id_fkey'.
+func (ba *BooksAuthor) Book(ctx context.Context, db DB) (*Book, error) {
+	return BookByBookID(ctx, db, ba.BookID)
+}
@@ -0,0 +1,177 @@
+// Package models contains generated code for schema 'public'.
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Book represents a row from 'public.books'.
+type Book struct {
+	BookID    int          `json:"book_id"`   // book_id
+	Title     string       `json:"title"`     // title
+	BookType  BookType     `json:"book_type"` // book_type
+	Published sql.NullTime `json:"published"` // published
+	// xo fields
+	_exists, _deleted bool
+}
+
+// Exists returns true when the [Book] exists in the database.
+func (b *Book) Exists() bool {
+	return b._exists
+}
+
+// Deleted returns true when the [Book] has been marked for deletion
+// from the database.
+func (b *Book) Deleted() bool {
+	return b._deleted
+}
+
+// Insert inserts the [Book] to the database.
+func (b *Book) Insert(ctx context.Context, db DB) error {
+	switch {
+	case b._exists: // already exists
+		return logerror(&ErrInsertFailed{ErrAlreadyExists})
+	case b._deleted: // deleted
+		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+	}
+	// insert (primary key generated and returned by database)
+	const sqlstr = `INSERT INTO public.books (` +
+		`title, book_type, published` +
+		`) VALUES (` +
+		`$1, $2, $3` +
+		`) RETURNING book_id`
+	// run
+	logf(sqlstr, b.Title, b.BookType, b.Published)
+	if err := db.QueryRowContext(ctx, sqlstr, b.Title, b.BookType, b.Published).Scan(&b.BookID); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	b._exists = true
+	return nil
+}
+
+// Update updates a [Book] in the database.
+func (b *Book) Update(ctx context.Context, db DB) error {
+	switch {
+	case !b._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case b._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	// update with composite primary key
+	const sqlstr = `UPDATE public.books SET ` +
+		`title = $1, book_type = $2, published = $3 ` +
+		`WHERE book_id = $4`
+	// run
+	logf(sqlstr, b.Title, b.BookType, b.Published, b.BookID)
+	if _, err := db.ExecContext(ctx, sqlstr, b.Title, b.BookType, b.Published, b.BookID); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+
+// Save saves the [Book] to the database.
+func (b *Book) Save(ctx context.Context, db DB) error {
+	if b.Exists() {
+		return b.Update(ctx, db)
+	}
+	return b.Insert(ctx, db)
+}
+
+// Upsert performs an upsert for [Book].
+func (b *Book) Upsert(ctx context.Context, db DB) error {
+	switch {
+	case b._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+	// upsert
+	const sqlstr = `INSERT INTO public.books (` +
+		`book_id, title, book_type, published` +
+		`) VALUES (` +
+		`$1, $2, $3, $4` +
+		`)` +
+		` ON CONFLICT (book_id) DO ` +
+		`UPDATE SET ` +
+		`title = EXCLUDED.title, book_type = EXCLUDED.book_type, published = EXCLUDED.published `
+	// run
+	logf(sqlstr, b.BookID, b.Title, b.BookType, b.Published)
+	if _, err := db.ExecContext(ctx, sqlstr, b.BookID, b.Title, b.BookType, b.Published); err != nil {
+		return logerror(err)
+	}
+	// set exists
+	b._exists = true
+	return nil
+}
+
+// Delete deletes the [Book] from the database.
+func (b *Book) Delete(ctx context.Context, db DB) error {
+	switch {
+	case !b._exists: // doesn't exist
+		return nil
+	case b._deleted: // deleted
+		return nil
+	}
+	// delete with single primary key
+	const sqlstr = `DELETE FROM public.books ` +
+		`WHERE book_id = $1`
+	// run
+	logf(sqlstr, b.BookID)
+	if _, err := db.ExecContext(ctx, sqlstr, b.BookID); err != nil {
+		return logerror(err)
+	}
+	// set deleted
+	b._deleted = true
+	return nil
+}
+
+// Book constraint and index names, such as for matching the
+// constraint name of a database error.
+const (
+	// ConstraintBooksPkey is the 'books_pkey' primary key.
+	ConstraintBooksPkey = "books_pkey"
+	// ConstraintBooksTitleIdx is the 'books_title_idx' unique index.
+	ConstraintBooksTitleIdx = "books_title_idx"
+)
+
+// BookByBookID retrieves a row from 'public.books' as a [Book].
+//
+// Generated from index 'books_pkey'.
+func BookByBookID(ctx context.Context, db DB, bookID int) (*Book, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`book_id, title, book_type, published ` +
+		`FROM public.books ` +
+		`WHERE book_id = $1`
+	// run
+	logf(sqlstr, bookID)
+	b := Book{
+		_exists: true,
+	}
+	if err := db.QueryRowContext(ctx, sqlstr, bookID).Scan(&b.BookID, &b.Title, &b.BookType, &b.Published); err != nil {
+		return nil, logerror(err)
+	}
+	return &b, nil
+}
+
+// BookByTitle retrieves a row from 'public.books' as a [Book].
+//
+// Generated from index 'books_title_idx'.
+func BookByTitle(ctx context.Context, db DB, title string) (*Book, error) {
+	// query
+	const sqlstr = `SELECT ` +
+		`book_id, title, book_type, published ` +
+		`FROM public.books ` +
+		`WHERE title = $1`
+	// run
+	logf(sqlstr, title)
+	b := Book{
+		_exists: true,
+	}
+	if err := db.QueryRowContext(ctx, sqlstr, title).Scan(&b.BookID, &b.Title, &b.BookType, &b.Published); err != nil {
+		return nil, logerror(err)
+	}
+	return &b, nil
+}
@@ -0,0 +1,22 @@
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+	"time"
+)
+
+// AuthorStats calls the stored function 'public.author_stats(integer) (bigint, timestamp with time zone)' on db.
+func AuthorStats(ctx context.Context, db DB, authorID int) (int64, time.Time, error) {
+	// call public.author_stats
+	const sqlstr = `SELECT * FROM public.author_stats($1)`
+	// run
+	var bookCount int64
+	var lastPublished time.Time
+	logf(sqlstr, authorID)
+	if err := db.QueryRowContext(ctx, sqlstr, authorID).Scan(&bookCount, &lastPublished); err != nil {
+		return 0, time.Time{}, logerror(err)
+	}
+	return bookCount, lastPublished, nil
+}
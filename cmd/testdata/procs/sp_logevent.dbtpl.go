@@ -0,0 +1,19 @@
+package models
+
+// Code generated by dbtpl. DO NOT EDIT.
+
+import (
+	"context"
+)
+
+// LogEvent calls the stored procedure 'public.log_event(text)' on db.
+func LogEvent(ctx context.Context, db DB, msg string) error {
+	// call public.log_event
+	const sqlstr = `CALL public.log_event($1)`
+	// run
+	logf(sqlstr)
+	if _, err := db.ExecContext(ctx, sqlstr, msg); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
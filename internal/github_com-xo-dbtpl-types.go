@@ -15,12 +15,17 @@ func init() {
 		"DbKey":          reflect.ValueOf(types.DbKey),
 		"DriverDbSchema": reflect.ValueOf(types.DriverDbSchema),
 		"DriverKey":      reflect.ValueOf(types.DriverKey),
+		"Infof":          reflect.ValueOf(types.Infof),
+		"LogKey":         reflect.ValueOf(types.LogKey),
+		"Logf":           reflect.ValueOf(types.Logf),
 		"Out":            reflect.ValueOf(types.Out),
 		"OutKey":         reflect.ValueOf(types.OutKey),
 		"ParseType":      reflect.ValueOf(types.ParseType),
 		"SchemaKey":      reflect.ValueOf(types.SchemaKey),
 		"Single":         reflect.ValueOf(types.Single),
 		"SingleKey":      reflect.ValueOf(types.SingleKey),
+		"WarnKey":        reflect.ValueOf(types.WarnKey),
+		"Warnf":          reflect.ValueOf(types.Warnf),
 
 		// type definitions
 		"ContextKey":   reflect.ValueOf((*types.ContextKey)(nil)),
@@ -38,5 +43,6 @@ func init() {
 		"Template":     reflect.ValueOf((*types.Template)(nil)),
 		"TemplateType": reflect.ValueOf((*types.TemplateType)(nil)),
 		"Type":         reflect.ValueOf((*types.Type)(nil)),
+		"Warning":      reflect.ValueOf((*types.Warning)(nil)),
 	}
 }
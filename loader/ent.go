@@ -0,0 +1,556 @@
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kenshaw/inflector"
+	"github.com/kenshaw/snaker"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Ent builds a postgres schema from ent schema definitions (the Go types in
+// an ent/schema package), using ent's default naming and postgres column
+// types.
+//
+// Fields, edges, indexes, the time mixins and local mixins are used. Field
+// defaults that are set in Go (such as time.Now) are not database defaults.
+type Ent struct {
+	schema string
+	types  map[string]*entType
+	order  []string
+}
+
+// entType is an ent schema or mixin type.
+type entType struct {
+	name    string
+	schema  bool
+	table   string
+	mixins  []string
+	fields  [][]entCall
+	edges   [][]entCall
+	indexes [][]entCall
+}
+
+// entCall is a call in an ent builder chain, such as field.String("name") or
+// Optional().
+type entCall struct {
+	name string
+	args []ast.Expr
+}
+
+// NewEnt creates an ent schema builder for the schema.
+func NewEnt(schema string) *Ent {
+	return &Ent{
+		schema: schema,
+		types:  make(map[string]*entType),
+	}
+}
+
+// Parse parses a Go source file containing ent schema definitions.
+func (e *Ent) Parse(src string) error {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return err
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				s, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				st, ok := s.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					if len(field.Names) == 0 && goTypeString(field.Type) == "ent.Schema" {
+						e.typ(s.Name.Name).schema = true
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			name := goRecvName(d)
+			if name == "" {
+				continue
+			}
+			elts := goReturnElts(d)
+			t := e.typ(name)
+			switch d.Name.Name {
+			case "Fields":
+				t.fields = entChains(elts)
+			case "Edges":
+				t.edges = entChains(elts)
+			case "Indexes":
+				t.indexes = entChains(elts)
+			case "Mixin":
+				for _, elt := range elts {
+					if lit, ok := elt.(*ast.CompositeLit); ok {
+						t.mixins = append(t.mixins, goTypeString(lit.Type))
+					}
+				}
+			case "Annotations":
+				for _, elt := range elts {
+					t.table = entTable(elt, t.table)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema defined by the parsed ent schemas.
+func (e *Ent) Schema() (xo.Schema, error) {
+	var types []*entType
+	m := make(map[string]*ddlTable)
+	for _, name := range e.order {
+		if t := e.types[name]; t.schema {
+			types = append(types, t)
+			table, err := e.table(t)
+			if err != nil {
+				return xo.Schema{}, fmt.Errorf("%s: %w", t.name, err)
+			}
+			m[t.name] = table
+		}
+	}
+	// add edges
+	tables := make([]*ddlTable, 0, len(types))
+	for _, t := range types {
+		tables = append(tables, m[t.name])
+	}
+	for _, t := range types {
+		for _, chain := range t.edges {
+			join, err := e.edge(t, chain, m)
+			if err != nil {
+				return xo.Schema{}, fmt.Errorf("%s: %w", t.name, err)
+			}
+			if join != nil {
+				tables = append(tables, join)
+			}
+		}
+	}
+	// add indexes
+	for _, t := range types {
+		table := m[t.name]
+		for _, chain := range t.indexes {
+			k := ddlKey{}
+			for _, call := range chain {
+				switch call.name {
+				case "index.Fields":
+					for _, arg := range call.args {
+						k.columns = append(k.columns, entColumn(e.types, t, entString(arg)))
+					}
+				case "Edges", "index.Edges":
+					for _, arg := range call.args {
+						k.columns = append(k.columns, e.edgeColumn(t, entString(arg)))
+					}
+				case "Unique":
+					k.unique = true
+				case "StorageKey":
+					k.name = entString(call.args[0])
+				}
+			}
+			if k.name == "" {
+				k.name = strings.ToLower(t.name) + "_" + strings.Join(k.columns, "_")
+			}
+			table.keys = append(table.keys, k)
+		}
+	}
+	schema := e.schema
+	if schema == "" {
+		schema = "public"
+	}
+	return ddlSchema("postgres", schema, nil, tables)
+}
+
+// typ returns the named type, creating it if it does not exist.
+func (e *Ent) typ(name string) *entType {
+	if t, ok := e.types[name]; ok {
+		return t
+	}
+	t := &entType{
+		name: name,
+	}
+	e.types[name], e.order = t, append(e.order, name)
+	return t
+}
+
+// table builds the table of a schema type.
+func (e *Ent) table(t *entType) (*ddlTable, error) {
+	table := &ddlTable{
+		name: t.table,
+	}
+	if table.name == "" {
+		table.name = inflector.Pluralize(snaker.CamelToSnake(t.name))
+	}
+	// collect fields
+	var fields [][]entCall
+	for _, name := range t.mixins {
+		switch name {
+		case "mixin.Time":
+			fields = append(fields, entTimeField("create_time"), entTimeField("update_time"))
+		case "mixin.CreateTime":
+			fields = append(fields, entTimeField("create_time"))
+		case "mixin.UpdateTime":
+			fields = append(fields, entTimeField("update_time"))
+		default:
+			mixin, ok := e.types[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown mixin %s", name)
+			}
+			fields = append(fields, mixin.fields...)
+		}
+	}
+	fields = append(fields, t.fields...)
+	if !slices.ContainsFunc(fields, func(chain []entCall) bool {
+		return entString(chain[0].args[0]) == "id"
+	}) {
+		fields = append([][]entCall{{{
+			name: "field.Int",
+			args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"id"`}},
+		}}}, fields...)
+	}
+	for _, chain := range fields {
+		col, err := entField(chain)
+		if err != nil {
+			return nil, err
+		}
+		if col.Name == "id" {
+			col.IsPrimary, col.Type.Nullable = true, false
+			col.IsSequence = strings.HasSuffix(col.Type.Type, "int") || col.Type.Type == "integer"
+			table.keys = append(table.keys, ddlKey{
+				name:    table.name + "_pkey",
+				columns: []string{"id"},
+				primary: true,
+			})
+		}
+		if slices.ContainsFunc(chain, func(call entCall) bool {
+			return call.name == "Unique"
+		}) {
+			table.keys = append(table.keys, ddlKey{
+				name:    table.name + "_" + col.Name + "_key",
+				columns: []string{col.Name},
+				unique:  true,
+			})
+		}
+		table.columns = append(table.columns, col)
+	}
+	return table, nil
+}
+
+// edge adds the foreign key of a To edge of t, returning the join table of
+// many to many edges. From edges are handled with their To edge.
+func (e *Ent) edge(t *entType, chain []entCall, m map[string]*ddlTable) (*ddlTable, error) {
+	if chain[0].name != "edge.To" || len(chain[0].args) != 2 {
+		return nil, nil
+	}
+	name, target := entString(chain[0].args[0]), strings.TrimSuffix(goTypeString(chain[0].args[1]), ".Type")
+	ref := e.types[target]
+	if ref == nil || m[target] == nil {
+		return nil, fmt.Errorf("edge %s: unknown type %s", name, target)
+	}
+	// find inverse edge
+	var inverse []entCall
+	for _, z := range ref.edges {
+		if z[0].name == "edge.From" && len(z[0].args) == 2 && strings.TrimSuffix(goTypeString(z[0].args[1]), ".Type") == t.name && entCallString(z, "Ref") == name {
+			inverse = z
+		}
+	}
+	unique, inverseUnique := entHas(chain, "Unique"), inverse != nil && entHas(inverse, "Unique")
+	owner, other, edge := m[t.name], m[target], chain
+	switch {
+	case !unique && inverse != nil && !inverseUnique:
+		// many to many
+		join := &ddlTable{
+			name: snaker.CamelToSnake(t.name) + "_" + name,
+		}
+		pk := ddlKey{name: join.name + "_pkey", primary: true}
+		for i, z := range []*ddlTable{owner, other} {
+			col := *z.column("id")
+			col.Name = snaker.CamelToSnake([]string{t.name, target}[i]) + "_id"
+			if t.name == target && i == 1 {
+				col.Name = strings.TrimSuffix(strings.ToLower(name), "s") + "_id"
+			}
+			col.IsPrimary, col.IsSequence, col.Default = false, false, ""
+			join.columns, pk.columns = append(join.columns, col), append(pk.columns, col.Name)
+			join.keys = append(join.keys, ddlKey{
+				name:     join.name + "_" + col.Name,
+				columns:  []string{col.Name},
+				foreign:  true,
+				refTable: z.name,
+			})
+		}
+		join.keys = append(join.keys, pk)
+		return join, nil
+	case unique && !inverseUnique:
+		// many to one, with the foreign key on t
+		owner, other = other, owner
+	case inverse != nil:
+		// one to many, one to one, with the foreign key on the target
+		edge = inverse
+	}
+	// add foreign key column
+	column := entCallString(edge, "Field")
+	if column == "" {
+		column = snaker.CamelToSnake(t.name) + "_" + name
+		col := *owner.column("id")
+		col.Name, col.IsPrimary, col.IsSequence, col.Default = column, false, false, ""
+		col.Type.Nullable = !entHas(edge, "Required")
+		other.columns = append(other.columns, col)
+	}
+	other.addForeignKey(ddlKey{
+		name:     other.name + "_" + owner.name + "_" + name,
+		columns:  []string{column},
+		foreign:  true,
+		refTable: owner.name,
+	})
+	return nil, nil
+}
+
+// edgeColumn returns the foreign key column of the named edge of t.
+func (e *Ent) edgeColumn(t *entType, name string) string {
+	for _, chain := range t.edges {
+		if entString(chain[0].args[0]) != name {
+			continue
+		}
+		if s := entCallString(chain, "Field"); s != "" {
+			return s
+		}
+		if chain[0].name == "edge.From" {
+			return snaker.CamelToSnake(strings.TrimSuffix(goTypeString(chain[0].args[1]), ".Type")) + "_" + entCallString(chain, "Ref")
+		}
+		return snaker.CamelToSnake(t.name) + "_" + name
+	}
+	return name
+}
+
+// entTypes are the postgres column types of ent field types.
+var entTypes = map[string]string{
+	"field.Bool":    "boolean",
+	"field.Int":     "bigint",
+	"field.Int8":    "smallint",
+	"field.Int16":   "smallint",
+	"field.Int32":   "integer",
+	"field.Int64":   "bigint",
+	"field.Uint":    "bigint",
+	"field.Uint8":   "smallint",
+	"field.Uint16":  "integer",
+	"field.Uint32":  "bigint",
+	"field.Uint64":  "bigint",
+	"field.Float":   "double precision",
+	"field.Float32": "real",
+	"field.String":  "character varying",
+	"field.Text":    "text",
+	"field.Bytes":   "bytea",
+	"field.Time":    "timestamp with time zone",
+	"field.JSON":    "jsonb",
+	"field.Strings": "jsonb",
+	"field.Ints":    "jsonb",
+	"field.Floats":  "jsonb",
+	"field.UUID":    "uuid",
+	"field.Enum":    "character varying",
+}
+
+// entField returns the column for a field builder chain.
+func entField(chain []entCall) (xo.Field, error) {
+	col := xo.Field{
+		Name: entString(chain[0].args[0]),
+	}
+	typ, ok := entTypes[chain[0].name]
+	if !ok && chain[0].name != "field.Other" {
+		return xo.Field{}, fmt.Errorf("field %s: unknown field type %s", col.Name, chain[0].name)
+	}
+	nullable := false
+	for _, call := range chain[1:] {
+		switch call.name {
+		case "Optional":
+			nullable = true
+		case "StorageKey":
+			col.Name = entString(call.args[0])
+		case "Comment":
+			col.Comment = entString(call.args[0])
+		case "MaxLen":
+			if typ == "character varying" {
+				typ += "(" + goIntString(call.args[0]) + ")"
+			}
+		case "SchemaType":
+			if s := entSchemaType(call.args[0]); s != "" {
+				typ = s
+			}
+		case "Default":
+			switch x := call.args[0].(type) {
+			case *ast.BasicLit:
+				if s, err := strconv.Unquote(x.Value); err == nil {
+					col.Default = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+				} else {
+					col.Default = x.Value
+				}
+			case *ast.Ident:
+				if x.Name == "true" || x.Name == "false" {
+					col.Default = x.Name
+				}
+			}
+		}
+	}
+	if typ == "" {
+		return xo.Field{}, fmt.Errorf("field %s: missing SchemaType for postgres", col.Name)
+	}
+	if ddl, ok := ddlTypes[typ]; ok {
+		typ = ddl
+	}
+	var err error
+	if col.Type, err = xo.ParseType(typ, "postgres"); err != nil {
+		return xo.Field{}, fmt.Errorf("field %s: %w", col.Name, err)
+	}
+	col.Type.Nullable = nullable
+	return col, nil
+}
+
+// entTimeField returns the field builder chain of a time mixin field.
+func entTimeField(name string) []entCall {
+	return []entCall{{
+		name: "field.Time",
+		args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(name)}},
+	}}
+}
+
+// entColumn returns the column name of the named field of t.
+func entColumn(types map[string]*entType, t *entType, name string) string {
+	fields := t.fields
+	for _, mixin := range t.mixins {
+		if z, ok := types[mixin]; ok {
+			fields = append(fields, z.fields...)
+		}
+	}
+	for _, chain := range fields {
+		if entString(chain[0].args[0]) == name {
+			if s := entCallString(chain, "StorageKey"); s != "" {
+				return s
+			}
+		}
+	}
+	return name
+}
+
+// entChains returns the builder chains of the expressions.
+func entChains(exprs []ast.Expr) [][]entCall {
+	var chains [][]entCall
+	for _, expr := range exprs {
+		var chain []entCall
+		for {
+			call, ok := expr.(*ast.CallExpr)
+			if !ok {
+				break
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				break
+			}
+			if _, ok := sel.X.(*ast.Ident); ok {
+				chain = append(chain, entCall{name: goTypeString(sel), args: call.Args})
+				break
+			}
+			chain, expr = append(chain, entCall{name: sel.Sel.Name, args: call.Args}), sel.X
+		}
+		if len(chain) != 0 && len(chain[len(chain)-1].args) != 0 {
+			slices.Reverse(chain)
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+// entHas determines if the chain has the named call.
+func entHas(chain []entCall, name string) bool {
+	return slices.ContainsFunc(chain, func(call entCall) bool {
+		return call.name == name
+	})
+}
+
+// entCallString returns the string argument of the named call in the chain.
+func entCallString(chain []entCall, name string) string {
+	for _, call := range chain {
+		if call.name == name && len(call.args) != 0 {
+			return entString(call.args[0])
+		}
+	}
+	return ""
+}
+
+// entSchemaType returns the postgres type of a SchemaType map.
+func entSchemaType(expr ast.Expr) string {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return ""
+	}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if k := goTypeString(kv.Key); k == "dialect.Postgres" || entString(kv.Key) == "postgres" {
+				return strings.ToLower(entString(kv.Value))
+			}
+		}
+	}
+	return ""
+}
+
+// entTable returns the table name of an entsql annotation, or def.
+func entTable(expr ast.Expr, def string) string {
+	switch x := expr.(type) {
+	case *ast.UnaryExpr:
+		return entTable(x.X, def)
+	case *ast.CompositeLit:
+		if goTypeString(x.Type) != "entsql.Annotation" {
+			return def
+		}
+		for _, elt := range x.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok && goTypeString(kv.Key) == "Table" {
+				return entString(kv.Value)
+			}
+		}
+	case *ast.CallExpr:
+		if goTypeString(x.Fun) == "entsql.Table" && len(x.Args) == 1 {
+			return entString(x.Args[0])
+		}
+	}
+	return def
+}
+
+// entString returns the value of a string literal.
+func entString(expr ast.Expr) string {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		s, _ := strconv.Unquote(lit.Value)
+		return s
+	}
+	return ""
+}
+
+// goIntString returns the value of an int literal.
+func goIntString(expr ast.Expr) string {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		return lit.Value
+	}
+	return "0"
+}
+
+// goReturnElts returns the elements of the composite literal returned by a
+// func.
+func goReturnElts(d *ast.FuncDecl) []ast.Expr {
+	if d.Body == nil || len(d.Body.List) == 0 {
+		return nil
+	}
+	ret, ok := d.Body.List[len(d.Body.List)-1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	if lit, ok := ret.Results[0].(*ast.CompositeLit); ok {
+		return lit.Elts
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestEnt(t *testing.T) {
+	e := NewEnt("")
+	if err := e.Parse(`package schema
+
+import "entgo.io/ent"
+
+type User struct {
+	ent.Schema
+}
+
+func (User) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		mixin.CreateTime{},
+	}
+}
+
+func (User) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").MaxLen(100).Unique(),
+		field.Int("age").Optional(),
+		field.Enum("status").Values("active", "disabled").Default("active"),
+	}
+}
+
+func (User) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.To("pets", Pet.Type),
+		edge.To("groups", Group.Type),
+	}
+}
+
+type Pet struct {
+	ent.Schema
+}
+
+func (Pet) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("owner", User.Type).Ref("pets").Unique(),
+	}
+}
+
+func (Pet) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Edges("owner"),
+	}
+}
+
+type Group struct {
+	ent.Schema
+}
+
+func (Group) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("users", User.Type).Ref("groups"),
+	}
+}
+`); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	schema, err := e.Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(schema.Tables) != 4 {
+		t.Fatalf("expected 4 tables, got: %d", len(schema.Tables))
+	}
+	groups, pets, join, users := schema.Tables[0], schema.Tables[1], schema.Tables[2], schema.Tables[3]
+	checkFields(t, users.Columns, []xo.Field{
+		{Name: "id", Type: xo.Type{Type: "bigint"}, IsPrimary: true, IsSequence: true},
+		{Name: "create_time", Type: xo.Type{Type: "timestamp with time zone"}},
+		{Name: "name", Type: xo.Type{Type: "character varying", Prec: 100}},
+		{Name: "age", Type: xo.Type{Type: "bigint", Nullable: true}},
+		{Name: "status", Type: xo.Type{Type: "character varying"}, Default: "'active'"},
+	})
+	checkFields(t, pets.Columns, []xo.Field{
+		{Name: "id", Type: xo.Type{Type: "bigint"}, IsPrimary: true, IsSequence: true},
+		{Name: "user_pets", Type: xo.Type{Type: "bigint", Nullable: true}},
+	})
+	if len(pets.ForeignKeys) != 1 || pets.ForeignKeys[0].Name != "pets_users_pets" {
+		t.Errorf("expected foreign key pets_users_pets, got: %v", pets.ForeignKeys)
+	}
+	if len(pets.Indexes) != 2 || pets.Indexes[0].Name != "pet_user_pets" {
+		t.Errorf("expected index pet_user_pets, got: %v", pets.Indexes)
+	}
+	if groups.Name != "groups" || join.Name != "user_groups" || len(join.ForeignKeys) != 2 {
+		t.Errorf("expected join table user_groups, got: %v", join)
+	}
+}
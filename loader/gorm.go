@@ -0,0 +1,533 @@
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kenshaw/inflector"
+	"github.com/kenshaw/snaker"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Gorm builds a postgres schema from gorm model definitions (Go struct
+// types), using gorm's default naming strategy and postgres column types.
+//
+// All exported struct types in the parsed files are models, except for those
+// embedded in other models. Belongs to, has one, has many and many to many
+// relations are converted to foreign keys (and join tables).
+type Gorm struct {
+	schema string
+	types  []*ast.TypeSpec
+	names  map[string]string
+}
+
+// gormModel is a parsed gorm model.
+type gormModel struct {
+	name      string
+	table     *ddlTable
+	fields    []gormField
+	relations []gormField
+}
+
+// gormField is a field of a gorm model.
+type gormField struct {
+	name   string
+	column string
+	typ    string
+	tags   map[string]string
+}
+
+// NewGorm creates a gorm schema builder for the schema.
+func NewGorm(schema string) *Gorm {
+	return &Gorm{
+		schema: schema,
+		names:  make(map[string]string),
+	}
+}
+
+// Parse parses a Go source file containing gorm models.
+func (g *Gorm) Parse(src string) error {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return err
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if s, ok := spec.(*ast.TypeSpec); ok {
+					if _, ok := s.Type.(*ast.StructType); ok && s.Name.IsExported() {
+						g.types = append(g.types, s)
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			// TableName overrides
+			if name := goRecvName(d); name != "" && d.Name.Name == "TableName" {
+				if s := goReturnString(d); s != "" {
+					g.names[name] = s
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema defined by the parsed gorm models.
+func (g *Gorm) Schema() (xo.Schema, error) {
+	types := make(map[string]*ast.StructType)
+	embedded := make(map[string]bool)
+	for _, s := range g.types {
+		typ := s.Type.(*ast.StructType)
+		types[s.Name.Name] = typ
+		for _, f := range typ.Fields.List {
+			tags := gormTags(f.Tag)
+			if _, ok := tags["EMBEDDED"]; len(f.Names) == 0 || ok {
+				embedded[strings.TrimPrefix(goTypeString(f.Type), "*")] = true
+			}
+		}
+	}
+	// build models
+	var models []*gormModel
+	m := make(map[string]*gormModel)
+	for _, s := range g.types {
+		if embedded[s.Name.Name] {
+			continue
+		}
+		name := g.names[s.Name.Name]
+		if name == "" {
+			name = inflector.Pluralize(snaker.CamelToSnake(s.Name.Name))
+		}
+		model := &gormModel{
+			name:  s.Name.Name,
+			table: &ddlTable{name: name},
+		}
+		models, m[model.name] = append(models, model), model
+	}
+	for _, model := range models {
+		if err := g.fields(model, types, m, types[model.name], ""); err != nil {
+			return xo.Schema{}, fmt.Errorf("%s: %w", model.name, err)
+		}
+		if err := model.build(); err != nil {
+			return xo.Schema{}, fmt.Errorf("%s: %w", model.name, err)
+		}
+	}
+	// add relations
+	tables := make([]*ddlTable, 0, len(models))
+	for _, model := range models {
+		tables = append(tables, model.table)
+	}
+	for _, model := range models {
+		for _, f := range model.relations {
+			t, err := model.relation(f, m)
+			if err != nil {
+				return xo.Schema{}, fmt.Errorf("%s: %s: %w", model.name, f.name, err)
+			}
+			if t != nil && !slices.ContainsFunc(tables, func(z *ddlTable) bool {
+				return z.name == t.name
+			}) {
+				tables = append(tables, t)
+			}
+		}
+	}
+	schema := g.schema
+	if schema == "" {
+		schema = "public"
+	}
+	return ddlSchema("postgres", schema, nil, tables)
+}
+
+// fields adds the fields of a struct type to the model, including the fields
+// of embedded structs.
+func (g *Gorm) fields(model *gormModel, types map[string]*ast.StructType, m map[string]*gormModel, typ *ast.StructType, prefix string) error {
+	for _, f := range typ.Fields.List {
+		tags, typ := gormTags(f.Tag), goTypeString(f.Type)
+		if v, ok := tags["-"]; ok && (v == "" || v == "all" || v == "migration") {
+			continue
+		}
+		name := strings.TrimPrefix(typ, "*")
+		// embedded
+		if _, ok := tags["EMBEDDED"]; len(f.Names) == 0 || ok {
+			switch {
+			case name == "gorm.Model":
+				model.fields = append(model.fields, gormModelFields()...)
+			case types[name] != nil:
+				if err := g.fields(model, types, m, types[name], prefix+tags["EMBEDDEDPREFIX"]); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown embedded type %s", typ)
+			}
+			continue
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			field := gormField{
+				name:   n.Name,
+				column: tags["COLUMN"],
+				typ:    typ,
+				tags:   tags,
+			}
+			if field.column == "" {
+				field.column = prefix + snaker.CamelToSnake(n.Name)
+			}
+			if m[strings.TrimPrefix(strings.TrimPrefix(name, "[]"), "*")] != nil {
+				model.relations = append(model.relations, field)
+			} else {
+				model.fields = append(model.fields, field)
+			}
+		}
+	}
+	return nil
+}
+
+// build builds the columns and indexes of the model's table.
+func (model *gormModel) build() error {
+	t := model.table
+	// determine primary key
+	var pk []string
+	for _, f := range model.fields {
+		if _, ok := gormTag(f.tags, "PRIMARYKEY", "PRIMARY_KEY"); ok {
+			pk = append(pk, f.name)
+		}
+	}
+	if len(pk) == 0 && slices.ContainsFunc(model.fields, func(f gormField) bool {
+		return f.name == "ID"
+	}) {
+		pk = []string{"ID"}
+	}
+	indexes := make(map[string]int)
+	for _, f := range model.fields {
+		primary := slices.Contains(pk, f.name)
+		typ, err := gormType(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		col := xo.Field{
+			Name:    f.column,
+			Default: f.tags["DEFAULT"],
+			Comment: f.tags["COMMENT"],
+		}
+		if col.Type, err = xo.ParseType(typ, "postgres"); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		_, notNull := f.tags["NOT NULL"]
+		col.Type.Nullable = !primary && !notNull
+		if v, ok := gormTag(f.tags, "AUTOINCREMENT"); ok && v != "false" || primary && len(pk) == 1 && v != "false" && (typ == "smallint" || typ == "integer" || typ == "bigint") {
+			col.IsSequence, col.Default = true, ""
+		}
+		t.columns = append(t.columns, col)
+		switch k := t.primaryKey(); {
+		case primary && k != nil:
+			k.columns = append(k.columns, col.Name)
+		case primary:
+			t.keys = append(t.keys, ddlKey{
+				name:    t.name + "_pkey",
+				columns: []string{col.Name},
+				primary: true,
+			})
+		}
+		if _, ok := f.tags["UNIQUE"]; ok {
+			t.keys = append(t.keys, ddlKey{
+				name:    "uni_" + t.name + "_" + col.Name,
+				columns: []string{col.Name},
+				unique:  true,
+			})
+		}
+		// indexes
+		for _, tag := range []string{"INDEX", "UNIQUEINDEX"} {
+			v, ok := f.tags[tag]
+			if !ok {
+				continue
+			}
+			name, opts, _ := strings.Cut(v, ",")
+			if name == "" {
+				name = "idx_" + t.name + "_" + col.Name
+			}
+			unique := tag == "UNIQUEINDEX" || strings.Contains(","+strings.ToLower(opts)+",", ",unique,")
+			if i, ok := indexes[name]; ok {
+				t.keys[i].columns = append(t.keys[i].columns, col.Name)
+				t.keys[i].unique = t.keys[i].unique || unique
+				continue
+			}
+			indexes[name] = len(t.keys)
+			t.keys = append(t.keys, ddlKey{
+				name:    name,
+				columns: []string{col.Name},
+				unique:  unique,
+			})
+		}
+	}
+	return nil
+}
+
+// relation adds the foreign key for a relation field, returning the join
+// table of many to many relations.
+func (model *gormModel) relation(f gormField, m map[string]*gormModel) (*ddlTable, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(f.typ, "*"), "[]"), "*")
+	ref, many := m[name], strings.HasPrefix(strings.TrimPrefix(f.typ, "*"), "[]")
+	fkName := "fk_" + model.table.name + "_" + snaker.CamelToSnake(f.name)
+	// many to many
+	if join, ok := gormTag(f.tags, "MANY2MANY"); ok {
+		t := &ddlTable{name: join}
+		for _, z := range []*gormModel{model, ref} {
+			prefix := snaker.CamelToSnake(z.name) + "_"
+			k := ddlKey{
+				name:     "fk_" + join + "_" + snaker.CamelToSnake(z.name),
+				foreign:  true,
+				refTable: z.table.name,
+			}
+			pk := z.table.primaryKey()
+			if pk == nil {
+				return nil, fmt.Errorf("%s has no primary key", z.name)
+			}
+			for _, c := range pk.columns {
+				col := *z.table.column(c)
+				col.Name, col.IsSequence, col.Default = prefix+col.Name, false, ""
+				if z == ref && ref == model {
+					col.Name = "ref_" + col.Name
+				}
+				t.columns = append(t.columns, col)
+				k.columns, k.refColumns = append(k.columns, col.Name), append(k.refColumns, c)
+			}
+			t.keys = append(t.keys, k)
+		}
+		pk := ddlKey{name: join + "_pkey", primary: true}
+		for _, col := range t.columns {
+			pk.columns = append(pk.columns, col.Name)
+		}
+		t.keys = append([]ddlKey{pk}, t.keys...)
+		return t, nil
+	}
+	// belongs to
+	if !many {
+		if fk := model.field(gormFieldName(f, "FOREIGNKEY", f.name+"ID")); fk != nil {
+			refCols, err := ref.refColumns(f)
+			if err != nil {
+				return nil, err
+			}
+			model.table.addForeignKey(ddlKey{
+				name:       fkName,
+				columns:    []string{fk.column},
+				foreign:    true,
+				refTable:   ref.table.name,
+				refColumns: refCols,
+			})
+			return nil, nil
+		}
+	}
+	// has one, has many
+	fk := ref.field(gormFieldName(f, "FOREIGNKEY", model.name+"ID"))
+	if fk == nil {
+		return nil, fmt.Errorf("could not determine foreign key for relation to %s", ref.name)
+	}
+	refCols, err := model.refColumns(f)
+	if err != nil {
+		return nil, err
+	}
+	ref.table.addForeignKey(ddlKey{
+		name:       fkName,
+		columns:    []string{fk.column},
+		foreign:    true,
+		refTable:   model.table.name,
+		refColumns: refCols,
+	})
+	return nil, nil
+}
+
+// refColumns returns the referenced columns of the model for a relation
+// field.
+func (model *gormModel) refColumns(f gormField) ([]string, error) {
+	if name, ok := gormTag(f.tags, "REFERENCES"); ok {
+		if field := model.field(name); field != nil {
+			return []string{field.column}, nil
+		}
+		return nil, fmt.Errorf("%s has no field %s", model.name, name)
+	}
+	if pk := model.table.primaryKey(); pk != nil {
+		return pk.columns, nil
+	}
+	return nil, fmt.Errorf("%s has no primary key", model.name)
+}
+
+// field returns the named field.
+func (model *gormModel) field(name string) *gormField {
+	for i := range model.fields {
+		if model.fields[i].name == name {
+			return &model.fields[i]
+		}
+	}
+	return nil
+}
+
+// gormFieldName returns the field name in the tag, or def.
+func gormFieldName(f gormField, tag, def string) string {
+	if v, ok := gormTag(f.tags, tag); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// gormModelFields returns the fields of gorm.Model.
+func gormModelFields() []gormField {
+	return []gormField{
+		{name: "ID", column: "id", typ: "uint", tags: map[string]string{"PRIMARYKEY": ""}},
+		{name: "CreatedAt", column: "created_at", typ: "time.Time", tags: map[string]string{}},
+		{name: "UpdatedAt", column: "updated_at", typ: "time.Time", tags: map[string]string{}},
+		{name: "DeletedAt", column: "deleted_at", typ: "gorm.DeletedAt", tags: map[string]string{"INDEX": ""}},
+	}
+}
+
+// gormTypes are the postgres column types of Go types, as created by gorm.
+var gormTypes = map[string]string{
+	"bool":              "boolean",
+	"int":               "bigint",
+	"int8":              "smallint",
+	"int16":             "smallint",
+	"int32":             "integer",
+	"int64":             "bigint",
+	"uint":              "bigint",
+	"uint8":             "smallint",
+	"uint16":            "integer",
+	"uint32":            "bigint",
+	"uint64":            "bigint",
+	"float32":           "numeric",
+	"float64":           "numeric",
+	"string":            "text",
+	"[]byte":            "bytea",
+	"time.Time":         "timestamp with time zone",
+	"sql.NullBool":      "boolean",
+	"sql.NullByte":      "smallint",
+	"sql.NullInt16":     "smallint",
+	"sql.NullInt32":     "integer",
+	"sql.NullInt64":     "bigint",
+	"sql.NullFloat64":   "numeric",
+	"sql.NullString":    "text",
+	"sql.NullTime":      "timestamp with time zone",
+	"gorm.DeletedAt":    "timestamp with time zone",
+	"datatypes.JSON":    "jsonb",
+	"datatypes.JSONMap": "jsonb",
+	"datatypes.Date":    "date",
+	"datatypes.Time":    "time without time zone",
+	"uuid.UUID":         "uuid",
+	"decimal.Decimal":   "numeric",
+	"pq.StringArray":    "text[]",
+	"pq.Int64Array":     "bigint[]",
+}
+
+// gormType returns the postgres column type of a field.
+func gormType(f gormField) (string, error) {
+	if v, ok := gormTag(f.tags, "TYPE"); ok && v != "" {
+		if typ, ok := ddlTypes[strings.ToLower(v)]; ok {
+			return typ, nil
+		}
+		return strings.ToLower(v), nil
+	}
+	name := strings.TrimPrefix(f.typ, "*")
+	if v, ok := gormTag(f.tags, "SERIALIZER"); ok && v != "" {
+		name = "[]byte"
+	}
+	typ, ok := gormTypes[name]
+	switch {
+	case !ok:
+		return "", fmt.Errorf("unknown type %s (add a type tag)", f.typ)
+	case typ == "text":
+		if size, _ := strconv.Atoi(f.tags["SIZE"]); size > 0 {
+			return "character varying(" + strconv.Itoa(size) + ")", nil
+		}
+	case typ == "numeric":
+		if p := f.tags["PRECISION"]; p != "" {
+			if s := f.tags["SCALE"]; s != "" {
+				return "numeric(" + p + "," + s + ")", nil
+			}
+			return "numeric(" + p + ")", nil
+		}
+	case typ == "timestamp with time zone":
+		if p := f.tags["PRECISION"]; p != "" {
+			return "timestamp with time zone(" + p + ")", nil
+		}
+	}
+	return typ, nil
+}
+
+// gormTag returns the value of the first of the named tags.
+func gormTag(tags map[string]string, names ...string) (string, bool) {
+	for _, name := range names {
+		if v, ok := tags[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// gormTags parses the gorm struct tag of a field, keyed by the upper case
+// tag name.
+func gormTags(lit *ast.BasicLit) map[string]string {
+	tags := make(map[string]string)
+	if lit == nil {
+		return tags
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return tags
+	}
+	for _, tag := range strings.Split(reflect.StructTag(s).Get("gorm"), ";") {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(tag, ":")
+		tags[strings.ToUpper(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return tags
+}
+
+// goTypeString returns the string representation of a type expression.
+func goTypeString(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.StarExpr:
+		return "*" + goTypeString(x.X)
+	case *ast.SelectorExpr:
+		return goTypeString(x.X) + "." + x.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + goTypeString(x.Elt)
+	case *ast.MapType:
+		return "map[" + goTypeString(x.Key) + "]" + goTypeString(x.Value)
+	case *ast.IndexExpr:
+		return goTypeString(x.X) + "[" + goTypeString(x.Index) + "]"
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// goRecvName returns the receiver type name of a method.
+func goRecvName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) != 1 {
+		return ""
+	}
+	return strings.TrimPrefix(goTypeString(d.Recv.List[0].Type), "*")
+}
+
+// goReturnString returns the string literal returned by a func.
+func goReturnString(d *ast.FuncDecl) string {
+	if d.Body == nil || len(d.Body.List) != 1 {
+		return ""
+	}
+	ret, ok := d.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return ""
+	}
+	if lit, ok := ret.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		s, _ := strconv.Unquote(lit.Value)
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestGorm(t *testing.T) {
+	g := NewGorm("")
+	if err := g.Parse(`package models
+
+type User struct {
+	gorm.Model
+	Name      string     ` + "`" + `gorm:"size:100;not null;uniqueIndex"` + "`" + `
+	Age       *int
+	Posts     []Post     ` + "`" + `gorm:"foreignKey:AuthorID"` + "`" + `
+	Languages []Language ` + "`" + `gorm:"many2many:user_languages"` + "`" + `
+	Ignored   string     ` + "`" + `gorm:"-"` + "`" + `
+}
+
+type Post struct {
+	ID       int64
+	AuthorID uint
+	Author   User
+}
+
+type Language struct {
+	Code string ` + "`" + `gorm:"primaryKey"` + "`" + `
+}
+
+func (Language) TableName() string {
+	return "langs"
+}
+`); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	schema, err := g.Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(schema.Tables) != 4 {
+		t.Fatalf("expected 4 tables, got: %d", len(schema.Tables))
+	}
+	langs, posts, join, users := schema.Tables[0], schema.Tables[1], schema.Tables[2], schema.Tables[3]
+	checkFields(t, users.Columns, []xo.Field{
+		{Name: "id", Type: xo.Type{Type: "bigint"}, IsPrimary: true, IsSequence: true},
+		{Name: "created_at", Type: xo.Type{Type: "timestamp with time zone", Nullable: true}},
+		{Name: "updated_at", Type: xo.Type{Type: "timestamp with time zone", Nullable: true}},
+		{Name: "deleted_at", Type: xo.Type{Type: "timestamp with time zone", Nullable: true}},
+		{Name: "name", Type: xo.Type{Type: "character varying", Prec: 100}},
+		{Name: "age", Type: xo.Type{Type: "bigint", Nullable: true}},
+	})
+	if len(users.Indexes) != 3 || users.Indexes[1].Name != "idx_users_name" || !users.Indexes[1].IsUnique {
+		t.Errorf("expected unique index idx_users_name, got: %v", users.Indexes)
+	}
+	if langs.Name != "langs" || !langs.Manual {
+		t.Errorf("expected manual table langs, got: %s", langs.Name)
+	}
+	if len(posts.ForeignKeys) != 1 || posts.ForeignKeys[0].RefTable != "users" || posts.ForeignKeys[0].Fields[0].Name != "author_id" {
+		t.Errorf("expected 1 foreign key to users, got: %v", posts.ForeignKeys)
+	}
+	if join.Name != "user_languages" || len(join.PrimaryKeys) != 2 || len(join.ForeignKeys) != 2 {
+		t.Errorf("expected join table user_languages, got: %v", join)
+	}
+}
@@ -0,0 +1,682 @@
+package loader
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	xo "github.com/xo/dbtpl/types"
+)
+
+// PostgresDDL builds a schema from postgres DDL statements (such as migration
+// files), as an alternative to loading the schema from a database.
+//
+// CREATE TABLE, CREATE INDEX, CREATE TYPE ... AS ENUM, ALTER TABLE, ALTER
+// TABLE ... RENAME, DROP and COMMENT ON COLUMN statements are applied in
+// order. All other statements are ignored.
+type PostgresDDL struct {
+	schema  string
+	version int32
+	enums   []xo.Enum
+	tables  []*ddlTable
+}
+
+// ddlTable is a table defined by DDL statements.
+type ddlTable struct {
+	name    string
+	columns []xo.Field
+	keys    []ddlKey
+}
+
+// ddlKey is a index or foreign key of a table defined by DDL statements.
+type ddlKey struct {
+	name       string
+	columns    []string
+	unique     bool
+	primary    bool
+	foreign    bool
+	refTable   string
+	refColumns []string
+}
+
+// NewPostgresDDL creates a postgres DDL schema builder for the schema.
+func NewPostgresDDL(schema string) *PostgresDDL {
+	return &PostgresDDL{
+		schema: schema,
+	}
+}
+
+// Parse parses and applies the DDL statements in sql.
+func (d *PostgresDDL) Parse(sql string) error {
+	res, err := pg_query.Parse(sql)
+	if err != nil {
+		return err
+	}
+	d.version = res.Version
+	for _, raw := range res.Stmts {
+		var err error
+		switch n := raw.Stmt; {
+		case n.GetCreateStmt() != nil:
+			err = d.createTable(n.GetCreateStmt())
+		case n.GetIndexStmt() != nil:
+			err = d.createIndex(n.GetIndexStmt())
+		case n.GetCreateEnumStmt() != nil:
+			d.createEnum(n.GetCreateEnumStmt())
+		case n.GetAlterTableStmt() != nil:
+			err = d.alterTable(n.GetAlterTableStmt())
+		case n.GetRenameStmt() != nil:
+			err = d.rename(n.GetRenameStmt())
+		case n.GetDropStmt() != nil:
+			d.drop(n.GetDropStmt())
+		case n.GetCommentStmt() != nil:
+			d.comment(n.GetCommentStmt())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema defined by the parsed DDL statements.
+func (d *PostgresDDL) Schema() (xo.Schema, error) {
+	return ddlSchema("postgres", d.schema, d.enums, d.tables)
+}
+
+// ddlSchema builds a schema from enums and table definitions, resolving the
+// primary keys, indexes and foreign keys of the tables.
+func ddlSchema(driver, name string, enums []xo.Enum, ddlTables []*ddlTable) (xo.Schema, error) {
+	schema := xo.Schema{
+		Driver: driver,
+		Name:   name,
+		Enums:  slices.Clone(enums),
+	}
+	slices.SortFunc(schema.Enums, func(a, b xo.Enum) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	tables := slices.Clone(ddlTables)
+	slices.SortFunc(tables, func(a, b *ddlTable) int {
+		return strings.Compare(a.name, b.name)
+	})
+	// build columns
+	m := make(map[string]xo.Table)
+	for _, t := range tables {
+		table := xo.Table{
+			Type:    "table",
+			Name:    t.name,
+			Columns: slices.Clone(t.columns),
+			Manual:  true,
+		}
+		if k := t.primaryKey(); k != nil {
+			for i, col := range table.Columns {
+				if slices.Contains(k.columns, col.Name) {
+					table.Columns[i].IsPrimary, table.Columns[i].Type.Nullable = true, false
+				}
+			}
+		}
+		for _, col := range table.Columns {
+			if col.IsPrimary {
+				table.PrimaryKeys = append(table.PrimaryKeys, col)
+			}
+			if col.IsSequence {
+				table.Manual = false
+			}
+		}
+		m[t.name] = table
+	}
+	// build indexes and foreign keys
+	for _, t := range tables {
+		table := m[t.name]
+		for _, k := range t.keys {
+			fields, err := ddlFields(table, k.columns)
+			if err != nil {
+				return xo.Schema{}, err
+			}
+			if !k.foreign {
+				table.Indexes = append(table.Indexes, xo.Index{
+					Name:      k.name,
+					Fields:    fields,
+					IsUnique:  k.unique || k.primary,
+					IsPrimary: k.primary,
+				})
+				continue
+			}
+			ref, ok := m[k.refTable]
+			if !ok {
+				return xo.Schema{}, fmt.Errorf("foreign key %s: table %s not defined", k.name, k.refTable)
+			}
+			refColumns := k.refColumns
+			if len(refColumns) == 0 {
+				for _, col := range ref.PrimaryKeys {
+					refColumns = append(refColumns, col.Name)
+				}
+			}
+			refFields, err := ddlFields(ref, refColumns)
+			switch {
+			case err != nil:
+				return xo.Schema{}, err
+			case len(refFields) != len(fields):
+				return xo.Schema{}, fmt.Errorf("foreign key %s: column count does not match %s", k.name, k.refTable)
+			}
+			table.ForeignKeys = append(table.ForeignKeys, xo.ForeignKey{
+				Name:      k.name,
+				Fields:    fields,
+				RefTable:  k.refTable,
+				RefFields: refFields,
+			})
+		}
+		slices.SortFunc(table.Indexes, func(a, b xo.Index) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		slices.SortFunc(table.ForeignKeys, func(a, b xo.ForeignKey) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		schema.Tables = append(schema.Tables, table)
+	}
+	return schema, nil
+}
+
+// createTable handles CREATE TABLE.
+func (d *PostgresDDL) createTable(stmt *pg_query.CreateStmt) error {
+	if !d.inSchema(stmt.Relation) {
+		return nil
+	}
+	name := stmt.Relation.Relname
+	if d.table(name) != nil {
+		if stmt.IfNotExists {
+			return nil
+		}
+		return fmt.Errorf("table %s already defined", name)
+	}
+	t := &ddlTable{
+		name: name,
+	}
+	d.tables = append(d.tables, t)
+	for _, n := range stmt.TableElts {
+		switch {
+		case n.GetColumnDef() != nil:
+			if err := t.addColumn(n.GetColumnDef(), d.version); err != nil {
+				return err
+			}
+		case n.GetConstraint() != nil:
+			t.addConstraint(n.GetConstraint(), nil)
+		}
+	}
+	return nil
+}
+
+// createIndex handles CREATE INDEX.
+func (d *PostgresDDL) createIndex(stmt *pg_query.IndexStmt) error {
+	if !d.inSchema(stmt.Relation) {
+		return nil
+	}
+	t := d.table(stmt.Relation.Relname)
+	if t == nil {
+		return fmt.Errorf("index %s: table %s not defined", stmt.Idxname, stmt.Relation.Relname)
+	}
+	var columns []string
+	for _, n := range stmt.IndexParams {
+		elem := n.GetIndexElem()
+		// expression indexes have no columns
+		if elem == nil || elem.Name == "" {
+			return nil
+		}
+		columns = append(columns, elem.Name)
+	}
+	name := stmt.Idxname
+	if name == "" {
+		name = t.name + "_" + strings.Join(columns, "_") + "_idx"
+	}
+	t.keys = append(t.keys, ddlKey{
+		name:    name,
+		columns: columns,
+		unique:  stmt.Unique,
+		primary: stmt.Primary,
+	})
+	return nil
+}
+
+// createEnum handles CREATE TYPE ... AS ENUM.
+func (d *PostgresDDL) createEnum(stmt *pg_query.CreateEnumStmt) {
+	names := ddlNames(stmt.TypeName)
+	if len(names) == 2 && names[0] != d.schema {
+		return
+	}
+	enum := xo.Enum{
+		Name: names[len(names)-1],
+	}
+	for i, n := range stmt.Vals {
+		constValue := i + 1
+		enum.Values = append(enum.Values, xo.Field{
+			Name:       n.GetString_().GetSval(),
+			ConstValue: &constValue,
+		})
+	}
+	d.enums = append(d.enums, enum)
+}
+
+// alterTable handles ALTER TABLE.
+func (d *PostgresDDL) alterTable(stmt *pg_query.AlterTableStmt) error {
+	if stmt.Objtype != pg_query.ObjectType_OBJECT_TABLE || !d.inSchema(stmt.Relation) {
+		return nil
+	}
+	t := d.table(stmt.Relation.Relname)
+	if t == nil {
+		if stmt.MissingOk {
+			return nil
+		}
+		return fmt.Errorf("alter table: table %s not defined", stmt.Relation.Relname)
+	}
+	for _, n := range stmt.Cmds {
+		cmd := n.GetAlterTableCmd()
+		if cmd == nil {
+			continue
+		}
+		switch cmd.Subtype {
+		case pg_query.AlterTableType_AT_AddColumn:
+			if def := cmd.Def.GetColumnDef(); def != nil && (!cmd.MissingOk || t.column(def.Colname) == nil) {
+				if err := t.addColumn(def, d.version); err != nil {
+					return err
+				}
+			}
+			continue
+		case pg_query.AlterTableType_AT_DropColumn:
+			t.dropColumn(cmd.Name)
+			continue
+		case pg_query.AlterTableType_AT_AddConstraint:
+			if c := cmd.Def.GetConstraint(); c != nil {
+				t.addConstraint(c, nil)
+			}
+			continue
+		case pg_query.AlterTableType_AT_DropConstraint:
+			t.keys = slices.DeleteFunc(t.keys, func(k ddlKey) bool {
+				return k.name == cmd.Name
+			})
+			continue
+		}
+		col := t.column(cmd.Name)
+		if col == nil {
+			continue
+		}
+		switch cmd.Subtype {
+		case pg_query.AlterTableType_AT_SetNotNull:
+			col.Type.Nullable = false
+		case pg_query.AlterTableType_AT_DropNotNull:
+			col.Type.Nullable = true
+		case pg_query.AlterTableType_AT_AlterColumnType:
+			typ, _, err := ddlType(cmd.Def.GetColumnDef().GetTypeName())
+			if err != nil {
+				return err
+			}
+			typ.Nullable = col.Type.Nullable
+			col.Type = typ
+		case pg_query.AlterTableType_AT_ColumnDefault:
+			if col.IsSequence {
+				continue
+			}
+			def, err := ddlExpr(cmd.Def, d.version)
+			if err != nil {
+				return err
+			}
+			col.Default = def
+		case pg_query.AlterTableType_AT_AddIdentity:
+			col.IsSequence, col.Default = true, ""
+		case pg_query.AlterTableType_AT_DropIdentity:
+			col.IsSequence = false
+		}
+	}
+	return nil
+}
+
+// rename handles ALTER TABLE ... RENAME.
+func (d *PostgresDDL) rename(stmt *pg_query.RenameStmt) error {
+	if stmt.Relation == nil || !d.inSchema(stmt.Relation) {
+		return nil
+	}
+	t := d.table(stmt.Relation.Relname)
+	if t == nil {
+		return nil
+	}
+	switch stmt.RenameType {
+	case pg_query.ObjectType_OBJECT_TABLE:
+		for _, other := range d.tables {
+			for i, k := range other.keys {
+				if k.refTable == t.name {
+					other.keys[i].refTable = stmt.Newname
+				}
+			}
+		}
+		t.name = stmt.Newname
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		col := t.column(stmt.Subname)
+		if col == nil {
+			return fmt.Errorf("rename: column %s.%s not defined", t.name, stmt.Subname)
+		}
+		col.Name = stmt.Newname
+		rename := func(columns []string) {
+			for i, name := range columns {
+				if name == stmt.Subname {
+					columns[i] = stmt.Newname
+				}
+			}
+		}
+		for i := range t.keys {
+			rename(t.keys[i].columns)
+		}
+		for _, other := range d.tables {
+			for i, k := range other.keys {
+				if k.refTable == t.name {
+					rename(other.keys[i].refColumns)
+				}
+			}
+		}
+	case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+		for i, k := range t.keys {
+			if k.name == stmt.Subname {
+				t.keys[i].name = stmt.Newname
+			}
+		}
+	}
+	return nil
+}
+
+// drop handles DROP TABLE, DROP INDEX, and DROP TYPE.
+func (d *PostgresDDL) drop(stmt *pg_query.DropStmt) {
+	for _, n := range stmt.Objects {
+		var names []string
+		switch {
+		case n.GetList() != nil:
+			names = ddlNames(n.GetList().Items)
+		case n.GetTypeName() != nil:
+			names = ddlNames(n.GetTypeName().Names)
+		}
+		if len(names) == 0 || len(names) == 2 && names[0] != d.schema {
+			continue
+		}
+		name := names[len(names)-1]
+		switch stmt.RemoveType {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			d.tables = slices.DeleteFunc(d.tables, func(t *ddlTable) bool {
+				return t.name == name
+			})
+		case pg_query.ObjectType_OBJECT_INDEX:
+			for _, t := range d.tables {
+				t.keys = slices.DeleteFunc(t.keys, func(k ddlKey) bool {
+					return k.name == name && !k.foreign
+				})
+			}
+		case pg_query.ObjectType_OBJECT_TYPE:
+			d.enums = slices.DeleteFunc(d.enums, func(e xo.Enum) bool {
+				return e.Name == name
+			})
+		}
+	}
+}
+
+// comment handles COMMENT ON COLUMN.
+func (d *PostgresDDL) comment(stmt *pg_query.CommentStmt) {
+	if stmt.Objtype != pg_query.ObjectType_OBJECT_COLUMN || stmt.Object.GetList() == nil {
+		return
+	}
+	names := ddlNames(stmt.Object.GetList().Items)
+	if len(names) < 2 || len(names) == 3 && names[0] != d.schema {
+		return
+	}
+	if t := d.table(names[len(names)-2]); t != nil {
+		if col := t.column(names[len(names)-1]); col != nil {
+			col.Comment = strings.ReplaceAll(stmt.Comment, "\n", " ")
+		}
+	}
+}
+
+// inSchema returns true when the relation is in the schema.
+func (d *PostgresDDL) inSchema(rel *pg_query.RangeVar) bool {
+	return rel != nil && (rel.Schemaname == "" || rel.Schemaname == d.schema)
+}
+
+// table returns the named table.
+func (d *PostgresDDL) table(name string) *ddlTable {
+	for _, t := range d.tables {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// column returns the named column.
+func (t *ddlTable) column(name string) *xo.Field {
+	for i := range t.columns {
+		if t.columns[i].Name == name {
+			return &t.columns[i]
+		}
+	}
+	return nil
+}
+
+// primaryKey returns the primary key.
+func (t *ddlTable) primaryKey() *ddlKey {
+	for i := range t.keys {
+		if t.keys[i].primary {
+			return &t.keys[i]
+		}
+	}
+	return nil
+}
+
+// addForeignKey adds a foreign key, unless a foreign key on the same columns
+// to the same table already exists.
+func (t *ddlTable) addForeignKey(k ddlKey) {
+	if !slices.ContainsFunc(t.keys, func(z ddlKey) bool {
+		return z.foreign && z.refTable == k.refTable && slices.Equal(z.columns, k.columns)
+	}) {
+		t.keys = append(t.keys, k)
+	}
+}
+
+// addColumn adds a column, including its column constraints.
+func (t *ddlTable) addColumn(def *pg_query.ColumnDef, version int32) error {
+	typ, serial, err := ddlType(def.TypeName)
+	if err != nil {
+		return fmt.Errorf("column %s.%s: %w", t.name, def.Colname, err)
+	}
+	col := xo.Field{
+		Name:       def.Colname,
+		Type:       typ,
+		IsSequence: serial || def.Identity != "",
+	}
+	col.Type.Nullable = !def.IsNotNull
+	for _, n := range def.Constraints {
+		c := n.GetConstraint()
+		if c == nil {
+			continue
+		}
+		switch c.Contype {
+		case pg_query.ConstrType_CONSTR_NOTNULL:
+			col.Type.Nullable = false
+		case pg_query.ConstrType_CONSTR_IDENTITY:
+			col.IsSequence = true
+		case pg_query.ConstrType_CONSTR_DEFAULT:
+			if col.Default, err = ddlExpr(c.RawExpr, version); err != nil {
+				return fmt.Errorf("column %s.%s: %w", t.name, def.Colname, err)
+			}
+		default:
+			t.addConstraint(c, []string{def.Colname})
+		}
+	}
+	if col.IsSequence {
+		col.Default = ""
+	}
+	t.columns = append(t.columns, col)
+	return nil
+}
+
+// dropColumn drops a column, and any of the table's indexes and foreign keys
+// using the column.
+func (t *ddlTable) dropColumn(name string) {
+	t.columns = slices.DeleteFunc(t.columns, func(col xo.Field) bool {
+		return col.Name == name
+	})
+	t.keys = slices.DeleteFunc(t.keys, func(k ddlKey) bool {
+		return slices.Contains(k.columns, name)
+	})
+}
+
+// addConstraint adds a primary key, unique, or foreign key constraint. When
+// columns is not nil, the constraint is a column constraint.
+func (t *ddlTable) addConstraint(c *pg_query.Constraint, columns []string) {
+	k := ddlKey{
+		name:    c.Conname,
+		columns: columns,
+	}
+	suffix := ""
+	switch c.Contype {
+	case pg_query.ConstrType_CONSTR_PRIMARY:
+		k.primary, suffix = true, "pkey"
+		if columns == nil {
+			k.columns = ddlNames(c.Keys)
+		}
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		k.unique, suffix = true, "key"
+		if columns == nil {
+			k.columns = ddlNames(c.Keys)
+		}
+	case pg_query.ConstrType_CONSTR_FOREIGN:
+		k.foreign, suffix = true, "fkey"
+		if columns == nil {
+			k.columns = ddlNames(c.FkAttrs)
+		}
+		k.refTable, k.refColumns = c.Pktable.GetRelname(), ddlNames(c.PkAttrs)
+	default:
+		return
+	}
+	if k.name == "" {
+		switch {
+		case k.primary:
+			k.name = t.name + "_pkey"
+		default:
+			k.name = t.name + "_" + strings.Join(k.columns, "_") + "_" + suffix
+		}
+	}
+	t.keys = append(t.keys, k)
+}
+
+// ddlFields returns the named fields of the table.
+func ddlFields(table xo.Table, columns []string) ([]xo.Field, error) {
+	var fields []xo.Field
+	for _, name := range columns {
+		i := slices.IndexFunc(table.Columns, func(col xo.Field) bool {
+			return col.Name == name
+		})
+		if i == -1 {
+			return nil, fmt.Errorf("column %s.%s not defined", table.Name, name)
+		}
+		fields = append(fields, table.Columns[i])
+	}
+	return fields, nil
+}
+
+// ddlNames returns the string values of the nodes.
+func ddlNames(nodes []*pg_query.Node) []string {
+	var names []string
+	for _, n := range nodes {
+		if s := n.GetString_(); s != nil {
+			names = append(names, s.Sval)
+		}
+	}
+	return names
+}
+
+// ddlTypes are the postgres internal type names and their names as reported
+// by format_type.
+var ddlTypes = map[string]string{
+	"bool":        "boolean",
+	"int2":        "smallint",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"varchar":     "character varying",
+	"bpchar":      "character",
+	"varbit":      "bit varying",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+}
+
+// ddlType parses a type name in the same way as a type loaded from the
+// database, returning true if the type is a serial type.
+func ddlType(tn *pg_query.TypeName) (xo.Type, bool, error) {
+	if tn == nil {
+		return xo.Type{}, false, fmt.Errorf("missing type")
+	}
+	names := ddlNames(tn.Names)
+	if len(names) == 0 {
+		return xo.Type{}, false, fmt.Errorf("missing type")
+	}
+	name := names[len(names)-1]
+	typ, ok := ddlTypes[name]
+	if !ok {
+		typ = name
+	}
+	serial := strings.Contains(name, "serial") && (len(names) == 1 || names[0] == "pg_catalog")
+	// type modifiers (precision, scale)
+	var mods []string
+	for _, n := range tn.Typmods {
+		if c := n.GetAConst(); c != nil && c.GetIval() != nil {
+			mods = append(mods, strconv.Itoa(int(c.GetIval().Ival)))
+		}
+	}
+	typ = pgTypeMods(typ, mods)
+	if len(tn.ArrayBounds) != 0 {
+		typ += "[]"
+	}
+	d, err := xo.ParseType(typ, "postgres")
+	return d, serial, err
+}
+
+// pgTypeMods adds the type modifiers (precision, scale) to a postgres type
+// name. Unlike format_type, modifiers are always added to the end (as with
+// "timestamp with time zone(6)"), so that xo.ParseType strips the precision
+// from the type.
+func pgTypeMods(typ string, mods []string) string {
+	if len(mods) == 0 || typ == "interval" {
+		return typ
+	}
+	return typ + "(" + strings.Join(mods, ",") + ")"
+}
+
+// ddlExpr deparses a default expression, using the parse tree version.
+func ddlExpr(n *pg_query.Node, version int32) (string, error) {
+	if n == nil {
+		return "", nil
+	}
+	res := &pg_query.ParseResult{
+		Version: version,
+		Stmts: []*pg_query.RawStmt{{
+			Stmt: &pg_query.Node{
+				Node: &pg_query.Node_SelectStmt{
+					SelectStmt: &pg_query.SelectStmt{
+						TargetList: []*pg_query.Node{
+							pg_query.MakeResTargetNodeWithVal(n, 0),
+						},
+					},
+				},
+			},
+		}},
+	}
+	s, err := pg_query.Deparse(res)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(s, "SELECT "), nil
+}
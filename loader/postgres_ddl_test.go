@@ -0,0 +1,118 @@
+package loader
+
+import (
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestPostgresDDL(t *testing.T) {
+	ddl := NewPostgresDDL("public")
+	for _, sql := range []string{
+		`CREATE TYPE status AS ENUM ('draft', 'published');
+		CREATE TABLE authors (
+			author_id bigserial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			created timestamptz NOT NULL DEFAULT now()
+		);
+		CREATE TABLE other.ignored (id integer);
+		CREATE TABLE posts (
+			post_id integer GENERATED ALWAYS AS IDENTITY,
+			author integer NOT NULL REFERENCES authors,
+			title text,
+			tags text[],
+			price numeric(10, 2),
+			CONSTRAINT posts_pkey PRIMARY KEY (post_id)
+		);
+		CREATE UNIQUE INDEX posts_author_title_idx ON posts (author, title);
+		CREATE INDEX posts_lower_title_idx ON posts (lower(title));`,
+		`ALTER TABLE posts ADD COLUMN status status NOT NULL DEFAULT 'draft';
+		ALTER TABLE posts RENAME COLUMN author TO author_id;
+		ALTER TABLE posts ALTER COLUMN title SET NOT NULL;
+		ALTER TABLE posts DROP COLUMN price;
+		COMMENT ON COLUMN posts.title IS 'the title';`,
+	} {
+		if err := ddl.Parse(sql); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	schema, err := ddl.Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(schema.Enums) != 1 || len(schema.Enums[0].Values) != 2 || *schema.Enums[0].Values[1].ConstValue != 2 {
+		t.Errorf("expected enum status with 2 values, got: %v", schema.Enums)
+	}
+	if len(schema.Tables) != 2 {
+		t.Fatalf("expected 2 tables, got: %d", len(schema.Tables))
+	}
+	authors, posts := schema.Tables[0], schema.Tables[1]
+	checkFields(t, authors.Columns, []xo.Field{
+		{Name: "author_id", Type: xo.Type{Type: "bigint"}, IsPrimary: true, IsSequence: true},
+		{Name: "name", Type: xo.Type{Type: "character varying", Prec: 255}},
+		{Name: "created", Type: xo.Type{Type: "timestamp with time zone"}, Default: "now()"},
+	})
+	checkFields(t, posts.Columns, []xo.Field{
+		{Name: "post_id", Type: xo.Type{Type: "integer"}, IsPrimary: true, IsSequence: true},
+		{Name: "author_id", Type: xo.Type{Type: "integer"}},
+		{Name: "title", Type: xo.Type{Type: "text"}, Comment: "the title"},
+		{Name: "tags", Type: xo.Type{Type: "text", Nullable: true, IsArray: true}},
+		{Name: "status", Type: xo.Type{Type: "status"}, Default: "'draft'"},
+	})
+	if authors.Manual || posts.Manual {
+		t.Errorf("expected tables with sequences to not be manual")
+	}
+	if len(posts.Indexes) != 2 {
+		t.Fatalf("expected 2 indexes, got: %v", posts.Indexes)
+	}
+	if idx := posts.Indexes[0]; idx.Name != "posts_author_title_idx" || !idx.IsUnique || len(idx.Fields) != 2 || idx.Fields[0].Name != "author_id" {
+		t.Errorf("expected unique index posts_author_title_idx on author_id, title, got: %v", idx)
+	}
+	if idx := posts.Indexes[1]; idx.Name != "posts_pkey" || !idx.IsPrimary {
+		t.Errorf("expected primary index posts_pkey, got: %v", idx)
+	}
+	if len(posts.ForeignKeys) != 1 {
+		t.Fatalf("expected 1 foreign key, got: %v", posts.ForeignKeys)
+	}
+	if fk := posts.ForeignKeys[0]; fk.Name != "posts_author_fkey" || fk.RefTable != "authors" || fk.Fields[0].Name != "author_id" || fk.RefFields[0].Name != "author_id" {
+		t.Errorf("expected foreign key posts_author_fkey to authors, got: %v", fk)
+	}
+}
+
+func TestPostgresDDLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"syntax error", `CREATE TABLE (`},
+		{"duplicate table", `CREATE TABLE a (id integer); CREATE TABLE a (id integer);`},
+		{"missing index table", `CREATE INDEX a_idx ON a (id);`},
+		{"missing index column", `CREATE TABLE a (id integer); CREATE INDEX a_idx ON a (other);`},
+		{"missing foreign table", `CREATE TABLE a (id integer REFERENCES b);`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ddl := NewPostgresDDL("public")
+			err := ddl.Parse(test.sql)
+			if err == nil {
+				_, err = ddl.Schema()
+			}
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func checkFields(t *testing.T, fields, exp []xo.Field) {
+	t.Helper()
+	if len(fields) != len(exp) {
+		t.Fatalf("expected %d fields, got: %d", len(exp), len(fields))
+	}
+	for i, f := range exp {
+		g := fields[i]
+		if g.Name != f.Name || g.Type != f.Type || g.Default != f.Default || g.IsPrimary != f.IsPrimary || g.IsSequence != f.IsSequence || g.Comment != f.Comment {
+			t.Errorf("field %d expected %+v, got: %+v", i, f, g)
+		}
+	}
+}
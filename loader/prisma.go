@@ -0,0 +1,566 @@
+package loader
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+// Prisma builds a schema from Prisma schema files, as an alternative to
+// loading the schema from a database.
+//
+// The datasource provider, models and enums are used. Relation fields with
+// fields and references arguments are converted to foreign keys. Views,
+// composite types and implicit many-to-many relations are ignored.
+type Prisma struct {
+	schema   string
+	provider string
+	models   []*prismaBlock
+	enums    []*prismaBlock
+}
+
+// prismaBlock is a Prisma model or enum.
+type prismaBlock struct {
+	name  string
+	lines []prismaLine
+	attrs []prismaAttr
+}
+
+// prismaLine is a model field or an enum value.
+type prismaLine struct {
+	name     string
+	typ      string
+	optional bool
+	list     bool
+	attrs    []prismaAttr
+	comment  string
+}
+
+// prismaAttr is a field (@) or block (@@) attribute.
+type prismaAttr struct {
+	name string
+	args []prismaArg
+}
+
+// prismaArg is an attribute argument. Positional arguments have no name.
+type prismaArg struct {
+	name  string
+	value string
+}
+
+// NewPrisma creates a Prisma schema builder for the schema. When schema is
+// empty, the default schema of the datasource provider is used.
+func NewPrisma(schema string) *Prisma {
+	return &Prisma{
+		schema: schema,
+	}
+}
+
+// Parse parses a Prisma schema file.
+func (p *Prisma) Parse(src string) error {
+	var kind string
+	var block *prismaBlock
+	var comment []string
+	for i, line := range strings.Split(src, "\n") {
+		line, doc := prismaComment(line)
+		switch {
+		case doc:
+			comment = append(comment, line)
+			continue
+		case line == "":
+			continue
+		case kind == "":
+			v := strings.Fields(line)
+			if len(v) != 3 || v[2] != "{" {
+				return fmt.Errorf("line %d: expected block, got: %q", i+1, line)
+			}
+			kind, block = v[0], &prismaBlock{name: v[1]}
+			switch kind {
+			case "model":
+				p.models = append(p.models, block)
+			case "enum":
+				p.enums = append(p.enums, block)
+			}
+		case line == "}":
+			kind, block = "", nil
+		case kind == "datasource":
+			if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == "provider" {
+				p.provider = prismaString(strings.TrimSpace(v))
+			}
+		case kind == "model", kind == "enum":
+			v := prismaSplit(line, ' ')
+			if strings.HasPrefix(line, "@@") {
+				for _, s := range v {
+					block.attrs = append(block.attrs, prismaParseAttr(s))
+				}
+				break
+			}
+			l := prismaLine{
+				name:    v[0],
+				comment: strings.Join(comment, " "),
+			}
+			if kind == "model" {
+				if len(v) < 2 {
+					return fmt.Errorf("line %d: field %s: missing type", i+1, v[0])
+				}
+				typ := v[1]
+				l.optional, l.list = strings.HasSuffix(typ, "?"), strings.HasSuffix(typ, "[]")
+				l.typ, v = strings.TrimSuffix(strings.TrimSuffix(typ, "?"), "[]"), v[1:]
+			}
+			for _, s := range v[1:] {
+				if !strings.HasPrefix(s, "@") {
+					return fmt.Errorf("line %d: %s: invalid attribute %q", i+1, l.name, s)
+				}
+				l.attrs = append(l.attrs, prismaParseAttr(s))
+			}
+			block.lines = append(block.lines, l)
+		}
+		comment = nil
+	}
+	if kind != "" {
+		return fmt.Errorf("unterminated %s block", kind)
+	}
+	return nil
+}
+
+// Schema returns the schema defined by the parsed Prisma schema files.
+func (p *Prisma) Schema() (xo.Schema, error) {
+	var driver, schema string
+	switch p.provider {
+	case "postgresql", "postgres", "cockroachdb":
+		driver, schema = "postgres", "public"
+	case "mysql":
+		driver = "mysql"
+	case "sqlite":
+		driver = "sqlite3"
+	case "sqlserver":
+		driver, schema = "sqlserver", "dbo"
+	case "":
+		return xo.Schema{}, fmt.Errorf("datasource provider not defined")
+	default:
+		return xo.Schema{}, fmt.Errorf("unsupported datasource provider %q", p.provider)
+	}
+	if p.schema != "" {
+		schema = p.schema
+	}
+	// build enums
+	enums := make(map[string]xo.Enum)
+	for _, e := range p.enums {
+		enum := xo.Enum{
+			Name: prismaMap(e.attrs, e.name),
+		}
+		for i, l := range e.lines {
+			constValue := i + 1
+			enum.Values = append(enum.Values, xo.Field{
+				Name:       prismaMap(l.attrs, l.name),
+				ConstValue: &constValue,
+			})
+		}
+		enums[e.name] = enum
+	}
+	var schemaEnums []xo.Enum
+	if driver == "postgres" {
+		for _, e := range p.enums {
+			schemaEnums = append(schemaEnums, enums[e.name])
+		}
+	}
+	// build tables
+	models := make(map[string]*prismaBlock)
+	for _, m := range p.models {
+		models[m.name] = m
+	}
+	var tables []*ddlTable
+	for _, m := range p.models {
+		if prismaFind(m.attrs, "ignore") != nil {
+			continue
+		}
+		t := &ddlTable{
+			name: prismaMap(m.attrs, m.name),
+		}
+		for _, l := range m.lines {
+			switch {
+			case prismaFind(l.attrs, "ignore") != nil:
+				continue
+			case models[l.typ] != nil:
+				if err := p.addRelation(t, m, l, models[l.typ]); err != nil {
+					return xo.Schema{}, fmt.Errorf("model %s: %w", m.name, err)
+				}
+				continue
+			}
+			col, err := p.column(driver, l, enums)
+			if err != nil {
+				return xo.Schema{}, fmt.Errorf("model %s: field %s: %w", m.name, l.name, err)
+			}
+			if e, ok := enums[l.typ]; ok && driver == "mysql" && !slices.ContainsFunc(schemaEnums, func(z xo.Enum) bool {
+				return z.Name == col.Name
+			}) {
+				e.Name = col.Name
+				schemaEnums = append(schemaEnums, e)
+			}
+			t.columns = append(t.columns, col)
+			if a := prismaFind(l.attrs, "id"); a != nil {
+				t.keys = append(t.keys, ddlKey{
+					name:    prismaArgValue(a.args, "map", prismaPrimaryName(driver, t.name)),
+					columns: []string{col.Name},
+					primary: true,
+				})
+			}
+			if a := prismaFind(l.attrs, "unique"); a != nil {
+				t.keys = append(t.keys, ddlKey{
+					name:    prismaArgValue(a.args, "map", t.name+"_"+col.Name+"_key"),
+					columns: []string{col.Name},
+					unique:  true,
+				})
+			}
+		}
+		// block attributes
+		for _, a := range m.attrs {
+			if a.name != "id" && a.name != "unique" && a.name != "index" {
+				continue
+			}
+			var columns []string
+			for _, name := range prismaList(prismaArgValue(a.args, "fields", "")) {
+				columns = append(columns, prismaColumn(m, name))
+			}
+			k := ddlKey{
+				columns: columns,
+				primary: a.name == "id",
+				unique:  a.name == "unique",
+			}
+			switch a.name {
+			case "id":
+				k.name = prismaArgValue(a.args, "map", prismaPrimaryName(driver, t.name))
+			case "unique":
+				k.name = prismaArgValue(a.args, "map", t.name+"_"+strings.Join(columns, "_")+"_key")
+			case "index":
+				k.name = prismaArgValue(a.args, "map", t.name+"_"+strings.Join(columns, "_")+"_idx")
+			}
+			t.keys = append(t.keys, k)
+		}
+		tables = append(tables, t)
+	}
+	return ddlSchema(driver, schema, schemaEnums, tables)
+}
+
+// addRelation adds the foreign key for a relation field of model m to table
+// t. Relation fields without fields and references are the other side of a
+// relation, and are ignored.
+func (p *Prisma) addRelation(t *ddlTable, m *prismaBlock, l prismaLine, ref *prismaBlock) error {
+	a := prismaFind(l.attrs, "relation")
+	if a == nil {
+		return nil
+	}
+	// the positional argument of @relation is the relation name
+	args := slices.DeleteFunc(slices.Clone(a.args), func(arg prismaArg) bool {
+		return arg.name == ""
+	})
+	fields, references := prismaList(prismaArgValue(args, "fields", "")), prismaList(prismaArgValue(args, "references", ""))
+	switch {
+	case len(fields) == 0 && len(references) == 0:
+		return nil
+	case len(fields) != len(references):
+		return fmt.Errorf("relation %s: fields and references do not match", l.name)
+	}
+	k := ddlKey{
+		foreign:  true,
+		refTable: prismaMap(ref.attrs, ref.name),
+	}
+	for i := range fields {
+		k.columns = append(k.columns, prismaColumn(m, fields[i]))
+		k.refColumns = append(k.refColumns, prismaColumn(ref, references[i]))
+	}
+	k.name = prismaArgValue(args, "map", t.name+"_"+strings.Join(k.columns, "_")+"_fkey")
+	t.keys = append(t.keys, k)
+	return nil
+}
+
+// column returns the column for a field.
+func (p *Prisma) column(driver string, l prismaLine, enums map[string]xo.Enum) (xo.Field, error) {
+	col := xo.Field{
+		Name:    prismaMap(l.attrs, l.name),
+		Comment: l.comment,
+	}
+	// determine type
+	var typ string
+	switch e, ok := enums[l.typ]; {
+	case ok && driver == "postgres":
+		typ = e.Name
+	case ok && driver == "mysql":
+		typ = col.Name
+	case ok:
+		typ = prismaTypes[driver]["String"]
+	case strings.HasPrefix(l.typ, "Unsupported("):
+		typ = prismaString(strings.TrimSuffix(strings.TrimPrefix(l.typ, "Unsupported("), ")"))
+	default:
+		if typ = prismaTypes[driver][l.typ]; typ == "" {
+			return xo.Field{}, fmt.Errorf("unknown type %q", l.typ)
+		}
+	}
+	for _, a := range l.attrs {
+		if name, ok := strings.CutPrefix(a.name, "db."); ok {
+			typ = prismaNativeType(driver, name, a.args)
+		}
+	}
+	if l.list {
+		typ += "[]"
+	}
+	var err error
+	if col.Type, err = xo.ParseType(typ, driver); err != nil {
+		return xo.Field{}, err
+	}
+	col.Type.Nullable = l.optional
+	// determine default
+	if a := prismaFind(l.attrs, "default"); a != nil && len(a.args) != 0 {
+		switch v := a.args[0].value; {
+		case v == "autoincrement()", v == "sequence()" || strings.HasPrefix(v, "sequence("):
+			col.IsSequence = true
+		case v == "now()":
+			col.Default = "CURRENT_TIMESTAMP"
+		case strings.HasPrefix(v, "dbgenerated("):
+			col.Default = prismaString(strings.TrimSuffix(strings.TrimPrefix(v, "dbgenerated("), ")"))
+		case strings.HasSuffix(v, ")"), strings.HasPrefix(v, "["):
+			// generated by the client (uuid(), cuid(), ...), or a list
+		case strings.HasPrefix(v, `"`):
+			col.Default = "'" + strings.ReplaceAll(prismaString(v), "'", "''") + "'"
+		case enums[l.typ].Name != "":
+			col.Default = "'" + v + "'"
+		default:
+			col.Default = v
+		}
+	}
+	return col, nil
+}
+
+// prismaTypes are the default column types of the Prisma scalar types for
+// each driver.
+var prismaTypes = map[string]map[string]string{
+	"postgres": {
+		"String":   "text",
+		"Boolean":  "boolean",
+		"Int":      "integer",
+		"BigInt":   "bigint",
+		"Float":    "double precision",
+		"Decimal":  "numeric(65,30)",
+		"DateTime": "timestamp without time zone(3)",
+		"Json":     "jsonb",
+		"Bytes":    "bytea",
+	},
+	"mysql": {
+		"String":   "varchar(191)",
+		"Boolean":  "tinyint(1)",
+		"Int":      "int",
+		"BigInt":   "bigint",
+		"Float":    "double",
+		"Decimal":  "decimal(65,30)",
+		"DateTime": "datetime(3)",
+		"Json":     "json",
+		"Bytes":    "longblob",
+	},
+	"sqlite3": {
+		"String":   "text",
+		"Boolean":  "boolean",
+		"Int":      "integer",
+		"BigInt":   "bigint",
+		"Float":    "real",
+		"Decimal":  "decimal",
+		"DateTime": "datetime",
+		"Json":     "jsonb",
+		"Bytes":    "blob",
+	},
+	"sqlserver": {
+		"String":   "nvarchar(1000)",
+		"Boolean":  "bit",
+		"Int":      "int",
+		"BigInt":   "bigint",
+		"Float":    "float(53)",
+		"Decimal":  "decimal(32,16)",
+		"DateTime": "datetime2",
+		"Json":     "nvarchar",
+		"Bytes":    "varbinary",
+	},
+}
+
+// prismaPostgresTypes are the postgres native type attributes whose names
+// differ from the type names as reported by the database.
+var prismaPostgresTypes = map[string]string{
+	"VarChar":         "character varying",
+	"Char":            "character",
+	"Integer":         "integer",
+	"DoublePrecision": "double precision",
+	"Decimal":         "numeric",
+	"Timestamp":       "timestamp without time zone",
+	"Timestamptz":     "timestamp with time zone",
+	"Time":            "time without time zone",
+	"Timetz":          "time with time zone",
+	"VarBit":          "bit varying",
+	"ByteA":           "bytea",
+	"JsonB":           "jsonb",
+}
+
+// prismaNativeType returns the column type of a native type attribute (such
+// as @db.VarChar(255)).
+func prismaNativeType(driver, name string, args []prismaArg) string {
+	var mods []string
+	for _, arg := range args {
+		if _, err := strconv.Atoi(arg.value); err == nil {
+			mods = append(mods, arg.value)
+		}
+	}
+	if driver == "postgres" {
+		typ, ok := prismaPostgresTypes[name]
+		if !ok {
+			typ = strings.ToLower(name)
+		}
+		return pgTypeMods(typ, mods)
+	}
+	unsigned := false
+	if s, ok := strings.CutPrefix(name, "Unsigned"); ok && driver == "mysql" {
+		name, unsigned = s, true
+	}
+	typ := strings.ToLower(name)
+	if len(mods) != 0 {
+		typ += "(" + strings.Join(mods, ",") + ")"
+	}
+	if unsigned {
+		typ += " unsigned"
+	}
+	return typ
+}
+
+// prismaPrimaryName returns the default primary key name of a table.
+func prismaPrimaryName(driver, table string) string {
+	if driver == "mysql" {
+		return "PRIMARY"
+	}
+	return table + "_pkey"
+}
+
+// prismaColumn returns the column name of the named field of model m.
+func prismaColumn(m *prismaBlock, name string) string {
+	for _, l := range m.lines {
+		if l.name == name {
+			return prismaMap(l.attrs, l.name)
+		}
+	}
+	return name
+}
+
+// prismaMap returns the mapped (@map or @@map) database name, or name when
+// not mapped.
+func prismaMap(attrs []prismaAttr, name string) string {
+	if a := prismaFind(attrs, "map"); a != nil {
+		return prismaArgValue(a.args, "name", name)
+	}
+	return name
+}
+
+// prismaFind returns the named attribute.
+func prismaFind(attrs []prismaAttr, name string) *prismaAttr {
+	for i := range attrs {
+		if attrs[i].name == name {
+			return &attrs[i]
+		}
+	}
+	return nil
+}
+
+// prismaArgValue returns the value of the named argument, or the first
+// positional argument when name is fields or name (as with @@id([a, b]) and
+// @map("a")). Strings are unquoted.
+func prismaArgValue(args []prismaArg, name, def string) string {
+	for i, arg := range args {
+		if arg.name == name || arg.name == "" && i == 0 && (name == "fields" || name == "name") {
+			return prismaString(arg.value)
+		}
+	}
+	return def
+}
+
+// prismaParseAttr parses an attribute.
+func prismaParseAttr(s string) prismaAttr {
+	s = strings.TrimLeft(s, "@")
+	name, args, _ := strings.Cut(s, "(")
+	a := prismaAttr{
+		name: name,
+	}
+	for _, arg := range prismaSplit(strings.TrimSuffix(args, ")"), ',') {
+		if v := prismaSplit(arg, ':'); len(v) == 2 {
+			a.args = append(a.args, prismaArg{name: v[0], value: v[1]})
+		} else {
+			a.args = append(a.args, prismaArg{value: arg})
+		}
+	}
+	return a
+}
+
+// prismaList returns the field names in a list (such as [a, b(sort: Desc)]).
+func prismaList(s string) []string {
+	var v []string
+	for _, name := range prismaSplit(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), ',') {
+		name, _, _ = strings.Cut(name, "(")
+		v = append(v, name)
+	}
+	return v
+}
+
+// prismaString unquotes a string value.
+func prismaString(s string) string {
+	if v, err := strconv.Unquote(s); err == nil {
+		return v
+	}
+	return s
+}
+
+// prismaComment strips the comment from a line, returning true if the line
+// is a documentation (///) comment.
+func prismaComment(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if s, ok := strings.CutPrefix(line, "///"); ok {
+		return strings.TrimSpace(s), true
+	}
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == '/' && !quoted && strings.HasPrefix(line[i:], "//"):
+			return strings.TrimSpace(line[:i]), false
+		}
+	}
+	return line, false
+}
+
+// prismaSplit splits s on sep, ignoring separators that are quoted or within
+// brackets. Empty values are removed.
+func prismaSplit(s string, sep byte) []string {
+	var v []string
+	depth, quoted, start := 0, false, 0
+	add := func(i int) {
+		if z := strings.TrimSpace(s[start:i]); z != "" {
+			v = append(v, z)
+		}
+		start = i + 1
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && (c == sep || sep == ' ' && c == '\t'):
+			add(i)
+		}
+	}
+	add(len(s))
+	return v
+}
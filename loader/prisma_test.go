@@ -0,0 +1,73 @@
+package loader
+
+import (
+	"testing"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestPrisma(t *testing.T) {
+	p := NewPrisma("")
+	if err := p.Parse(`datasource db {
+  provider = "postgresql" // comment
+  url      = "postgres://localhost/db"
+}
+
+enum Role {
+  USER
+  ADMIN @map("admin")
+  @@map("role")
+}
+
+model User {
+  id        Int      @id @default(autoincrement())
+  /// The email address.
+  email     String   @unique @db.VarChar(255)
+  role      Role     @default(USER)
+  createdAt DateTime @default(now()) @map("created_at") @db.Timestamptz(6)
+  posts     Post[]
+  @@map("users")
+}
+
+model Post {
+  id       Int     @id @default(autoincrement())
+  title    String? @default("untitled")
+  authorId Int     @map("author_id")
+  author   User    @relation("posts", fields: [authorId], references: [id])
+  @@index([authorId, title(sort: Desc)])
+}
+`); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	schema, err := p.Schema()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if schema.Driver != "postgres" || schema.Name != "public" {
+		t.Errorf("expected postgres public, got: %s %s", schema.Driver, schema.Name)
+	}
+	if len(schema.Enums) != 1 || schema.Enums[0].Name != "role" || schema.Enums[0].Values[1].Name != "admin" {
+		t.Errorf("expected enum role, got: %v", schema.Enums)
+	}
+	if len(schema.Tables) != 2 {
+		t.Fatalf("expected 2 tables, got: %d", len(schema.Tables))
+	}
+	posts, users := schema.Tables[0], schema.Tables[1]
+	checkFields(t, users.Columns, []xo.Field{
+		{Name: "id", Type: xo.Type{Type: "integer"}, IsPrimary: true, IsSequence: true},
+		{Name: "email", Type: xo.Type{Type: "character varying", Prec: 255}, Comment: "The email address."},
+		{Name: "role", Type: xo.Type{Type: "role"}, Default: "'USER'"},
+		{Name: "created_at", Type: xo.Type{Type: "timestamp with time zone", Prec: 6}, Default: "CURRENT_TIMESTAMP"},
+	})
+	checkFields(t, posts.Columns, []xo.Field{
+		{Name: "id", Type: xo.Type{Type: "integer"}, IsPrimary: true, IsSequence: true},
+		{Name: "title", Type: xo.Type{Type: "text", Nullable: true}, Default: "'untitled'"},
+		{Name: "author_id", Type: xo.Type{Type: "integer"}},
+	})
+	if len(posts.Indexes) != 2 || posts.Indexes[0].Name != "Post_author_id_title_idx" || len(posts.Indexes[0].Fields) != 2 {
+		t.Errorf("expected index Post_author_id_title_idx, got: %v", posts.Indexes)
+	}
+	if len(posts.ForeignKeys) != 1 || posts.ForeignKeys[0].Name != "Post_author_id_fkey" || posts.ForeignKeys[0].RefTable != "users" {
+		t.Errorf("expected foreign key Post_author_id_fkey, got: %v", posts.ForeignKeys)
+	}
+}
@@ -34,6 +34,7 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Type:       "string",
 				Desc:       "fmt command",
 				Default:    fmtCmd,
+				Expand:     true,
 			},
 			{
 				ContextKey: FmtOptsKey,
@@ -1,11 +1,30 @@
 {{ define "db" -}}
 var (
-	// logf is used by generated code to log SQL queries.
-	logf = func(string, ...any) {}
-	// errf is used by generated code to log SQL errors.
-	errf = func(string, ...any) {}
+	// loggerMu guards the package loggers, which can be set while generated
+	// code is running.
+	loggerMu sync.RWMutex
+	// queryLogger is used by generated code to log SQL queries.
+	queryLogger = func(string, ...any) {}
+	// errorLogger is used by generated code to log SQL errors.
+	errorLogger = func(string, ...any) {}
 )
 
+// logf logs a SQL query using the package logger.
+func logf(s string, v ...any) {
+	loggerMu.RLock()
+	f := queryLogger
+	loggerMu.RUnlock()
+	f(s, v...)
+}
+
+// errf logs a SQL error using the package error logger.
+func errf(s string, v ...any) {
+	loggerMu.RLock()
+	f := errorLogger
+	loggerMu.RUnlock()
+	f(s, v...)
+}
+
 // logerror logs the error and returns it.
 func logerror(err error) error {
 	errf("ERROR: %v", err)
@@ -24,7 +43,10 @@ func Logf(s string, v ...any) {
 //     func(string, ...any) // log.Printf
 //
 func SetLogger(logger any) {
-	logf = convLogger(logger)
+	f := convLogger(logger)
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	queryLogger = f
 }
 
 // Errorf logs an error message using the package error logger.
@@ -39,7 +61,10 @@ func Errorf(s string, v ...any) {
 //     func(string, ...any) // log.Printf
 //
 func SetErrorLogger(logger any) {
-	errf = convLogger(logger)
+	f := convLogger(logger)
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	errorLogger = f
 }
 
 // convLogger converts logger to the standard logger interface.
@@ -59,11 +84,51 @@ func convLogger(logger any) func(string, ...any) {
 	panic(fmt.Sprintf("unsupported logger type %T", logger))
 }
 
-// DB is the common interface for database operations that can be used with
+{{ if narrow_db -}}
+// Execer is the interface for database operations that execute statements
+// without returning rows.
+type Execer interface {
+{{ if context -}}
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+{{- end -}}{{- if or context_both context_disable }}
+	Exec(string, ...any) (sql.Result, error)
+{{- end }}
+}
+
+// Querier is the interface for database operations that return rows.
+type Querier interface {
+{{ if context -}}
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+{{- end -}}{{- if or context_both context_disable }}
+	Query(string, ...any) (*sql.Rows, error)
+{{- end }}
+}
+
+// RowQuerier is the interface for database operations that return a single
+// row.
+type RowQuerier interface {
+{{ if context -}}
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+{{- end -}}{{- if or context_both context_disable }}
+	QueryRow(string, ...any) *sql.Row
+{{- end }}
+}
+
+// {{ db_type }} is the common interface for database operations that can be used with
+// types from schema '{{ schema }}'.
+//
+// This works with both [database/sql.DB] and [database/sql.Tx].
+type {{ db_type }} interface {
+	Execer
+	Querier
+	RowQuerier
+}
+{{- else -}}
+// {{ db_type }} is the common interface for database operations that can be used with
 // types from schema '{{ schema }}'.
 //
 // This works with both [database/sql.DB] and [database/sql.Tx].
-type DB interface {
+type {{ db_type }} interface {
 {{ if context -}}
 	ExecContext(context.Context, string, ...any) (sql.Result, error)
 	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
@@ -74,6 +139,1097 @@ type DB interface {
 	QueryRow(string, ...any) *sql.Row
 {{- end }}
 }
+{{- end }}
+{{- if shard }}
+
+// ShardKeyFunc computes a shard key from primary key values.
+type ShardKeyFunc func(...any) uint64
+
+// ShardRouter routes primary key lookups to one of a set of database shards,
+// and fans out all other lookups across every shard.
+type ShardRouter struct {
+	// Shards are the database shards.
+	Shards []{{ db_type }}
+	// Key computes the shard key for primary key values.
+	Key ShardKeyFunc
+}
+
+// NewShardRouter creates a shard router for the shards, returning
+// [ErrNoShards] when there are no shards. When key is nil, [DefaultShardKey]
+// is used.
+func NewShardRouter(key ShardKeyFunc, shards ...{{ db_type }}) (*ShardRouter, error) {
+	if len(shards) == 0 {
+		return nil, ErrNoShards
+	}
+	if key == nil {
+		key = DefaultShardKey
+	}
+	return &ShardRouter{
+		Shards: shards,
+		Key:    key,
+	}, nil
+}
+
+// Shard returns the shard for the primary key values. The router must have at
+// least one shard.
+func (r *ShardRouter) Shard(v ...any) {{ db_type }} {
+	return r.Shards[r.Key(v...)%uint64(len(r.Shards))]
+}
+
+// DefaultShardKey is the default shard key func, hashing the primary key
+// values using FNV-1a.
+func DefaultShardKey(v ...any) uint64 {
+	h := fnv.New64a()
+	for _, z := range v {
+		fmt.Fprintf(h, "%v\x00", z)
+	}
+	return h.Sum64()
+}
+{{- end }}
+{{- if retry }}
+
+// OpClass is a class of read operation that can be retried.
+type OpClass string
+
+// Operation classes.
+const (
+	// OpIndex is the class for index lookups.
+	OpIndex OpClass = "index"
+	// OpQuery is the class for custom queries.
+	OpQuery OpClass = "query"
+)
+
+// RetryPolicy determines whether a read operation of the class is retried
+// after a transient error. attempt is the number of attempts made so far.
+type RetryPolicy func(op OpClass, attempt int, err error) bool
+
+var (
+	// retryPolicyMu guards the package retry policy.
+	retryPolicyMu sync.RWMutex
+	// retryPolicy is used by generated code to retry transient errors.
+	retryPolicy RetryPolicy
+)
+
+// SetRetryPolicy sets the package retry policy. A nil policy disables
+// retries.
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicyMu.Lock()
+	defer retryPolicyMu.Unlock()
+	retryPolicy = policy
+}
+
+// MaxAttempts returns a retry policy that retries read operations of the
+// classes (or all classes, when none are provided) up to n attempts.
+func MaxAttempts(n int, classes ...OpClass) RetryPolicy {
+	return func(op OpClass, attempt int, _ error) bool {
+		if attempt >= n {
+			return false
+		}
+		if len(classes) == 0 {
+			return true
+		}
+		for _, c := range classes {
+			if c == op {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsTransient returns true when the error is a transient error, such as a
+// reset connection, an admin shutdown (57P01) or a serialization failure
+// (40001).
+func IsTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		switch e.SQLState() {
+		case "57P01", "40001":
+			return true
+		}
+	}
+	return false
+}
+
+// Retry backoff. The delay before a retry is a random duration up to the
+// backoff, which starts at retryBackoff and doubles after each attempt up to
+// retryMaxBackoff.
+const (
+	retryBackoff    = 10 * time.Millisecond
+	retryMaxBackoff = time.Second
+)
+
+// retry runs f, retrying transient errors as determined by the retry policy.
+{{- if context }}
+// Returns the last error without retrying once the context is done.
+{{- end }}
+func retry({{ if context }}ctx context.Context, {{ end }}op OpClass, f func() error) error {
+	retryPolicyMu.RLock()
+	policy := retryPolicy
+	retryPolicyMu.RUnlock()
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || policy == nil || !IsTransient(err) || !policy(op, attempt, err) {
+			return err
+		}
+		// wait with full jitter
+		d := time.Duration(rand.Int63n(int64(backoff)))
+{{- if context }}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+{{- else }}
+		time.Sleep(d)
+{{- end }}
+		if backoff *= 2; backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
+{{- end }}
+{{- if hooks }}
+{{- $args := "" }}{{ if context }}{{ $args = "ctx context.Context" }}{{ end }}
+
+// BeforeInsertHook is the interface for types with a hook called by Insert
+// before inserting the row. Returning an error aborts the insert.
+type BeforeInsertHook interface {
+	BeforeInsert({{ $args }}) error
+}
+
+// AfterInsertHook is the interface for types with a hook called by Insert
+// after inserting the row.
+type AfterInsertHook interface {
+	AfterInsert({{ $args }}) error
+}
+
+// BeforeUpdateHook is the interface for types with a hook called by Update
+// before updating the row. Returning an error aborts the update.
+type BeforeUpdateHook interface {
+	BeforeUpdate({{ $args }}) error
+}
+
+// AfterUpdateHook is the interface for types with a hook called by Update
+// after updating the row.
+type AfterUpdateHook interface {
+	AfterUpdate({{ $args }}) error
+}
+
+// BeforeUpsertHook is the interface for types with a hook called by Upsert
+// before upserting the row. Returning an error aborts the upsert.
+type BeforeUpsertHook interface {
+	BeforeUpsert({{ $args }}) error
+}
+
+// AfterUpsertHook is the interface for types with a hook called by Upsert
+// after upserting the row.
+type AfterUpsertHook interface {
+	AfterUpsert({{ $args }}) error
+}
+
+// BeforeDeleteHook is the interface for types with a hook called by Delete
+// before deleting the row. Returning an error aborts the delete.
+type BeforeDeleteHook interface {
+	BeforeDelete({{ $args }}) error
+}
+
+// AfterDeleteHook is the interface for types with a hook called by Delete
+// after deleting the row.
+type AfterDeleteHook interface {
+	AfterDelete({{ $args }}) error
+}
+{{- end }}
+{{- if catalog }}
+
+// QueryInfo is the metadata for a generated SQL statement.
+type QueryInfo struct {
+	// Name is the name of the generated func.
+	Name string
+	// SQL is the SQL statement.
+	SQL string
+	// Args are the Go types of the statement's arguments.
+	Args []string
+	// Result is the Go result type.
+	Result string
+{{- if insert_many }}
+	// many is true for the statement of an InsertMany func inserting a single
+	// row, which is run with any number of rows.
+	many bool
+{{- end }}
+}
+
+// queries are the generated SQL statements.
+var queries []QueryInfo
+
+// addQueries adds generated SQL statements to the query catalog.
+func addQueries(v ...QueryInfo) {
+	queries = append(queries, v...)
+}
+
+// Queries returns the metadata for all generated SQL statements, sorted by
+// name.
+func Queries() []QueryInfo {
+{{- if go_version 21 }}
+	v := slices.Clone(queries)
+	slices.SortFunc(v, func(a, b QueryInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+{{- else }}
+	v := make([]QueryInfo, len(queries))
+	copy(v, queries)
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].Name < v[j].Name
+	})
+{{- end }}
+	return v
+}
+
+// ErrQueryNotAllowed is the query not allowed error.
+const ErrQueryNotAllowed Error = "query not allowed"
+
+// AllowList wraps a [{{ db_type }}], rejecting SQL statements not in the query
+// catalog returned by [Queries].
+//
+// As a [database/sql.Row] cannot be created with an error, a rejected
+// QueryRow is run with a canceled context, causing Scan to return
+// [context.Canceled] without the statement being sent to the database. When
+// the wrapped db does not support contexts, a rejected QueryRow panics.
+type AllowList struct {
+	db      {{ db_type }}
+	allowed map[string]bool
+{{- if insert_many }}
+	many    []string
+{{- end }}
+}
+
+// NewAllowList creates an allow list for db.
+func NewAllowList(db {{ db_type }}) *AllowList {
+	allowed := make(map[string]bool, len(queries))
+{{- if insert_many }}
+	var many []string
+{{- end }}
+	for _, q := range queries {
+		allowed[q.SQL] = true
+{{- if insert_many }}
+		if q.many {
+			many = append(many, q.SQL)
+		}
+{{- end }}
+	}
+	return &AllowList{
+		db:      db,
+		allowed: allowed,
+{{- if insert_many }}
+		many:    many,
+{{- end }}
+	}
+}
+
+// check checks that the SQL statement is in the query catalog.
+func (a *AllowList) check(sqlstr string) error {
+	if !a.allowed[sqlstr]{{ if insert_many }} && !a.allowedMany(sqlstr){{ end }} {
+		errf("%v: %s", ErrQueryNotAllowed, sqlstr)
+		return ErrQueryNotAllowed
+	}
+	return nil
+}
+{{- if insert_many }}
+
+// allowedMany returns true when the SQL statement is the statement of an
+// InsertMany func in the query catalog, inserting any number of rows.
+func (a *AllowList) allowedMany(sqlstr string) bool {
+	digits := func(r rune) rune {
+		if '0' <= r && r <= '9' {
+			return -1
+		}
+		return r
+	}
+	for _, s := range a.many {
+		i, j := strings.LastIndex(s, " VALUES ")+8, strings.LastIndex(s, ")")+1
+		prefix, row, suffix := s[:i], strings.Map(digits, s[i:j]), s[j:]
+		if len(sqlstr) < len(s) || !strings.HasPrefix(sqlstr, prefix) || !strings.HasSuffix(sqlstr, suffix) {
+			continue
+		}
+		rows := strings.Map(digits, sqlstr[len(prefix):len(sqlstr)-len(suffix)])
+		if rows == strings.TrimSuffix(strings.Repeat(row+", ", strings.Count(rows, "(")), ", ") {
+			return true
+		}
+	}
+	return false
+}
+{{- end }}
+{{ if context }}
+// ExecContext satisfies the [{{ db_type }}] interface.
+func (a *AllowList) ExecContext(ctx context.Context, sqlstr string, v ...any) (sql.Result, error) {
+	if err := a.check(sqlstr); err != nil {
+		return nil, err
+	}
+	return a.db.ExecContext(ctx, sqlstr, v...)
+}
+
+// QueryContext satisfies the [{{ db_type }}] interface.
+func (a *AllowList) QueryContext(ctx context.Context, sqlstr string, v ...any) (*sql.Rows, error) {
+	if err := a.check(sqlstr); err != nil {
+		return nil, err
+	}
+	return a.db.QueryContext(ctx, sqlstr, v...)
+}
+
+// QueryRowContext satisfies the [{{ db_type }}] interface.
+func (a *AllowList) QueryRowContext(ctx context.Context, sqlstr string, v ...any) *sql.Row {
+	if err := a.check(sqlstr); err != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	return a.db.QueryRowContext(ctx, sqlstr, v...)
+}
+{{ end -}}
+{{ if or context_both context_disable }}
+// Exec satisfies the [{{ db_type }}] interface.
+func (a *AllowList) Exec(sqlstr string, v ...any) (sql.Result, error) {
+	if err := a.check(sqlstr); err != nil {
+		return nil, err
+	}
+	return a.db.Exec(sqlstr, v...)
+}
+
+// Query satisfies the [{{ db_type }}] interface.
+func (a *AllowList) Query(sqlstr string, v ...any) (*sql.Rows, error) {
+	if err := a.check(sqlstr); err != nil {
+		return nil, err
+	}
+	return a.db.Query(sqlstr, v...)
+}
+
+// QueryRow satisfies the [{{ db_type }}] interface.
+func (a *AllowList) QueryRow(sqlstr string, v ...any) *sql.Row {
+	if err := a.check(sqlstr); err != nil {
+		if db, ok := a.db.(interface {
+			QueryRowContext(context.Context, string, ...any) *sql.Row
+		}); ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return db.QueryRowContext(ctx, sqlstr, v...)
+		}
+		panic(err)
+	}
+	return a.db.QueryRow(sqlstr, v...)
+}
+{{ end -}}
+{{- end }}
+{{- if encrypt }}
+
+// Cipher is the interface for encrypting and decrypting column values.
+type Cipher interface {
+	Encrypt([]byte) ([]byte, error)
+	Decrypt([]byte) ([]byte, error)
+}
+
+var (
+	// columnCipherMu guards the package cipher.
+	columnCipherMu sync.RWMutex
+	// columnCipher is used by generated code to encrypt and decrypt column
+	// values.
+	columnCipher Cipher
+)
+
+// SetCipher sets the package cipher used for encrypted columns.
+func SetCipher(c Cipher) {
+	columnCipherMu.Lock()
+	defer columnCipherMu.Unlock()
+	columnCipher = c
+}
+
+// packageCipher returns the package cipher, or [ErrNoCipher] when not set.
+func packageCipher() (Cipher, error) {
+	columnCipherMu.RLock()
+	defer columnCipherMu.RUnlock()
+	if columnCipher == nil {
+		return nil, ErrNoCipher
+	}
+	return columnCipher, nil
+}
+
+// ErrNoCipher is the no cipher error.
+const ErrNoCipher Error = "no cipher"
+
+// EncryptedString is a string stored encrypted in the database, using the
+// package [Cipher] to encrypt on write and decrypt on read.
+{{- if encrypt_base64 }} The ciphertext is
+// stored base64 encoded.
+{{- end }}
+type EncryptedString string
+
+// String satisfies the [fmt.Stringer] interface, masking the value so that
+// it is not written to logs.
+func (s EncryptedString) String() string {
+	return "[encrypted]"
+}
+
+// Value satisfies the [driver.Valuer] interface.
+func (s EncryptedString) Value() (driver.Value, error) {
+	c, err := packageCipher()
+	if err != nil {
+		return nil, err
+	}
+{{- if encrypt_base64 }}
+	buf, err := c.Encrypt([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+{{- else }}
+	return c.Encrypt([]byte(s))
+{{- end }}
+}
+
+// Scan satisfies the [sql.Scanner] interface.
+func (s *EncryptedString) Scan(v any) error {
+	var buf []byte
+	switch x := v.(type) {
+	case []byte:
+		buf = x
+	case string:
+		buf = []byte(x)
+	default:
+		return fmt.Errorf("unsupported encrypted value type %T", v)
+	}
+	c, err := packageCipher()
+	if err != nil {
+		return err
+	}
+{{- if encrypt_base64 }}
+	if buf, err = base64.StdEncoding.DecodeString(string(buf)); err != nil {
+		return err
+	}
+{{- end }}
+	plain, err := c.Decrypt(buf)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plain)
+	return nil
+}
+
+// NullEncryptedString is a nullable [EncryptedString].
+type NullEncryptedString struct {
+	EncryptedString EncryptedString
+	// Valid is true if [EncryptedString] is not null.
+	Valid bool
+}
+
+// Value satisfies the [driver.Valuer] interface.
+func (ns NullEncryptedString) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.EncryptedString.Value()
+}
+
+// Scan satisfies the [sql.Scanner] interface.
+func (ns *NullEncryptedString) Scan(v any) error {
+	if v == nil {
+		ns.EncryptedString, ns.Valid = "", false
+		return nil
+	}
+	err := ns.EncryptedString.Scan(v)
+	ns.Valid = err == nil
+	return err
+}
+{{- end }}
+{{- if or cascade outbox temporal }}
+
+// beginner is the interface for databases that can begin a transaction.
+type beginner interface {
+{{- if context }}
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+{{- else }}
+	Begin() (*sql.Tx, error)
+{{- end }}
+}
+{{- end }}
+{{- if cascade }}
+
+// cascade runs the cascading delete statements on db. When db can begin a
+// transaction (i.e. a [*sql.DB]), the statements are run within a new
+// transaction.
+func cascade({{ if context }}ctx context.Context, {{ end }}db {{ if narrow_db }}Execer{{ else }}{{ db_type }}{{ end }}, stmts []string, v ...any) error {
+	if b, ok := db.(beginner); ok {
+		tx, err := b.{{ if context }}BeginTx(ctx, nil){{ else }}Begin(){{ end }}
+		if err != nil {
+			return logerror(err)
+		}
+		if err := cascade({{ if context }}ctx, {{ end }}tx, stmts, v...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return logerror(err)
+		}
+		return nil
+	}
+	for _, sqlstr := range stmts {
+		logf(sqlstr, v...)
+		if _, err := db.{{ if context }}ExecContext(ctx, {{ else }}Exec({{ end }}sqlstr, v...); err != nil {
+			return logerror(err)
+		}
+	}
+	return nil
+}
+{{- end }}
+{{- if or outbox temporal }}
+
+// inTx runs f on db. When db can begin a transaction (i.e. a [*sql.DB]), f is
+// run within a new transaction.
+func inTx({{ if context }}ctx context.Context, {{ end }}db {{ db_type }}, f func({{ db_type }}) error) error {
+	b, ok := db.(beginner)
+	if !ok {
+		return f(db)
+	}
+	tx, err := b.{{ if context }}BeginTx(ctx, nil){{ else }}Begin(){{ end }}
+	if err != nil {
+		return logerror(err)
+	}
+	if err := f(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return logerror(err)
+	}
+	return nil
+}
+{{- end }}
+{{- if test_helpers }}
+
+// TB is the subset of [testing.TB] used by the test helpers.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(string, ...any)
+}
+
+// RunInRollbackTx runs f with a transaction begun on db that is rolled back
+// when the test completes, so that rows written by f are not left in the
+// database and do not need to be cleaned up by the test.
+func RunInRollbackTx(t TB, db *sql.DB, f func({{ db_type }})) {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("unable to begin transaction: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = tx.Rollback()
+	})
+	f(tx)
+}
+{{- if driver "postgres" }}
+
+// testSchemaCount is the count of created test schemas.
+var testSchemaCount int64
+
+// NewTestSchema creates a uniquely named schema on a connection of db, sets it
+// as the connection's search path, and applies the ddl statements in order.
+// The schema is dropped when the test completes.
+//
+// As each test has its own schema, tests using the returned connection can run
+// in parallel against the same database. The names in the queries run on the
+// returned connection are qualified with the test's schema instead of schema
+// '{{ schema }}'. Queries are not run in transactions begun by generated funcs.
+func NewTestSchema(t TB, db *sql.DB, ddl ...string) {{ db_type }} {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("unable to get connection: %v", err)
+	}
+	name := fmt.Sprintf("test_%x_%d", time.Now().UnixNano(), atomic.AddInt64(&testSchemaCount, 1))
+	t.Cleanup(func() {
+		_, _ = conn.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+name+" CASCADE")
+		_, _ = conn.ExecContext(ctx, "RESET search_path")
+		_ = conn.Close()
+	})
+	c := testConn{conn: conn, schema: name}
+	for _, sqlstr := range append([]string{"CREATE SCHEMA " + name, "SET search_path TO " + name}, ddl...) {
+		if _, err := conn.ExecContext(ctx, c.qualify(sqlstr)); err != nil {
+			t.Fatalf("unable to create test schema %s: %v", name, err)
+		}
+	}
+	return c
+}
+
+// testConn wraps a [sql.Conn] as a [{{ db_type }}], qualifying the names in the
+// queries run on it with the test schema.
+type testConn struct {
+	conn   *sql.Conn
+	schema string
+}
+
+// qualify replaces the schema qualifier of the names in sqlstr with the test
+// schema.
+func (c testConn) qualify(sqlstr string) string {
+	return strings.ReplaceAll(sqlstr, {{ test_schema_qualifier }}, c.schema+".")
+}
+
+// ExecContext satisfies the [{{ db_type }}] interface.
+func (c testConn) ExecContext(ctx context.Context, sqlstr string, v ...any) (sql.Result, error) {
+	return c.conn.ExecContext(ctx, c.qualify(sqlstr), v...)
+}
+
+// QueryContext satisfies the [{{ db_type }}] interface.
+func (c testConn) QueryContext(ctx context.Context, sqlstr string, v ...any) (*sql.Rows, error) {
+	return c.conn.QueryContext(ctx, c.qualify(sqlstr), v...)
+}
+
+// QueryRowContext satisfies the [{{ db_type }}] interface.
+func (c testConn) QueryRowContext(ctx context.Context, sqlstr string, v ...any) *sql.Row {
+	return c.conn.QueryRowContext(ctx, c.qualify(sqlstr), v...)
+}
+{{- if or context_both context_disable }}
+
+// Exec satisfies the [{{ db_type }}] interface.
+func (c testConn) Exec(sqlstr string, v ...any) (sql.Result, error) {
+	return c.ExecContext(context.Background(), sqlstr, v...)
+}
+
+// Query satisfies the [{{ db_type }}] interface.
+func (c testConn) Query(sqlstr string, v ...any) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), sqlstr, v...)
+}
+
+// QueryRow satisfies the [{{ db_type }}] interface.
+func (c testConn) QueryRow(sqlstr string, v ...any) *sql.Row {
+	return c.QueryRowContext(context.Background(), sqlstr, v...)
+}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if exec_result }}
+
+// ExecResult is the result of a custom exec query.
+type ExecResult struct {
+	// Result is the original result returned by the driver.
+	sql.Result
+	// Op is the statement type (i.e. INSERT, UPDATE, DELETE).
+	Op string
+}
+
+// affected returns the number of rows affected when the statement type is op.
+func (res ExecResult) affected(op string) int64 {
+	if res.Result == nil || res.Op != op {
+		return 0
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Inserted returns the number of rows inserted.
+func (res ExecResult) Inserted() int64 {
+	return res.affected("INSERT")
+}
+
+// Updated returns the number of rows updated.
+func (res ExecResult) Updated() int64 {
+	return res.affected("UPDATE")
+}
+
+// Deleted returns the number of rows deleted.
+func (res ExecResult) Deleted() int64 {
+	return res.affected("DELETE")
+}
+{{- end }}
+{{- if cdc }}
+
+// ChangeKind is the kind of a change to a table row.
+type ChangeKind string
+
+// Change kinds.
+const (
+	ChangeInsert ChangeKind = "insert"
+	ChangeUpdate ChangeKind = "update"
+	ChangeDelete ChangeKind = "delete"
+)
+
+// Change is a change data capture record for a table row.
+//
+// Column values are in the PostgreSQL text format, with nil for null values.
+// Unchanged TOAST values are omitted.
+type Change struct {
+	Kind   ChangeKind
+	Schema string
+	Table  string
+	// Columns are the new column values of inserts and updates.
+	Columns map[string]any
+	// Identity are the old column values of updates and deletes. Change data
+	// capture records only include the replica identity columns.
+	Identity map[string]any
+}
+
+// ChangeEvent is the payload envelope of a change to a table row, shared by
+// change data capture records and NOTIFY payloads. Rows are encoded as JSON
+// objects keyed by column name.
+type ChangeEvent[T any] struct {
+	Op     ChangeKind `json:"op"`
+	Schema string     `json:"schema"`
+	Table  string     `json:"table"`
+	Old    *T         `json:"old,omitempty"`
+	New    *T         `json:"new,omitempty"`
+}
+
+// MarshalJSON satisfies the [json.Marshaler] interface, encoding the rows as
+// objects of their column values.
+func (ev ChangeEvent[T]) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Op     ChangeKind `json:"op"`
+		Schema string     `json:"schema"`
+		Table  string     `json:"table"`
+		Old    any        `json:"old,omitempty"`
+		New    any        `json:"new,omitempty"`
+	}{
+		Op:     ev.Op,
+		Schema: ev.Schema,
+		Table:  ev.Table,
+	}
+	var err error
+	if ev.Old != nil {
+		if v.Old, err = changeRowValues(ev.Old); err != nil {
+			return nil, err
+		}
+	}
+	if ev.New != nil {
+		if v.New, err = changeRowValues(ev.New); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(v)
+}
+
+// changeRow is the interface for rows having change column values.
+type changeRow interface {
+	changeValues() (map[string]any, error)
+}
+
+// changeRowValues returns the column values of the row, or the row when it
+// has no change column values.
+func changeRowValues(row any) (any, error) {
+	if r, ok := row.(changeRow); ok {
+		return r.changeValues()
+	}
+	return row, nil
+}
+
+// changeValues returns the column values with driver values in place of
+// [driver.Valuer] values, and bytes in the text format.
+func changeValues(values map[string]any) (map[string]any, error) {
+	for name, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if buf, ok := v.([]byte); ok {
+			v = `\x` + hex.EncodeToString(buf)
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// DecodeNotify decodes the change of a [ChangeEvent] NOTIFY payload.
+func DecodeNotify(payload string) (Change, error) {
+	var ev ChangeEvent[map[string]json.RawMessage]
+	if err := json.Unmarshal([]byte(payload), &ev); err != nil {
+		return Change{}, err
+	}
+	c := Change{Kind: ev.Op, Schema: ev.Schema, Table: ev.Table}
+	var err error
+	if ev.New != nil {
+		if c.Columns, err = jsonValues(*ev.New); err != nil {
+			return Change{}, err
+		}
+	}
+	if ev.Old != nil {
+		if c.Identity, err = jsonValues(*ev.Old); err != nil {
+			return Change{}, err
+		}
+	}
+	return c, nil
+}
+
+// jsonValues returns the JSON column values as text format values.
+func jsonValues(values map[string]json.RawMessage) (map[string]any, error) {
+	m := make(map[string]any, len(values))
+	for name, v := range values {
+		var err error
+		if m[name], err = jsonValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// jsonValue returns the JSON value as a text format value.
+func jsonValue(v json.RawMessage) (any, error) {
+	switch {
+	case len(v) == 0 || string(v) == "null":
+		return nil, nil
+	case v[0] == '"':
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return string(v), nil
+}
+
+// wal2jsonColumn is a wal2json format version 2 column.
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// DecodeWal2JSON decodes the changes of a wal2json record, in either format
+// version 1 (a transaction of changes) or format version 2 (a single tuple).
+// Records other than inserts, updates, and deletes (i.e. begin, commit, and
+// truncate) decode to no changes.
+func DecodeWal2JSON(buf []byte) ([]Change, error) {
+	var rec struct {
+		// format version 1
+		Change []struct {
+			Kind         string            `json:"kind"`
+			Schema       string            `json:"schema"`
+			Table        string            `json:"table"`
+			ColumnNames  []string          `json:"columnnames"`
+			ColumnValues []json.RawMessage `json:"columnvalues"`
+			OldKeys      struct {
+				KeyNames  []string          `json:"keynames"`
+				KeyValues []json.RawMessage `json:"keyvalues"`
+			} `json:"oldkeys"`
+		} `json:"change"`
+		// format version 2
+		Action   string           `json:"action"`
+		Schema   string           `json:"schema"`
+		Table    string           `json:"table"`
+		Columns  []wal2jsonColumn `json:"columns"`
+		Identity []wal2jsonColumn `json:"identity"`
+	}
+	if err := json.Unmarshal(buf, &rec); err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, z := range rec.Change {
+		var kind ChangeKind
+		switch z.Kind {
+		case "insert":
+			kind = ChangeInsert
+		case "update":
+			kind = ChangeUpdate
+		case "delete":
+			kind = ChangeDelete
+		default:
+			continue
+		}
+		c := Change{Kind: kind, Schema: z.Schema, Table: z.Table}
+		var err error
+		if c.Columns, err = wal2jsonValues(z.ColumnNames, z.ColumnValues); err != nil {
+			return nil, err
+		}
+		if c.Identity, err = wal2jsonValues(z.OldKeys.KeyNames, z.OldKeys.KeyValues); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	var kind ChangeKind
+	switch rec.Action {
+	case "I":
+		kind = ChangeInsert
+	case "U":
+		kind = ChangeUpdate
+	case "D":
+		kind = ChangeDelete
+	default:
+		return changes, nil
+	}
+	c := Change{Kind: kind, Schema: rec.Schema, Table: rec.Table}
+	var names []string
+	var values []json.RawMessage
+	for _, z := range rec.Columns {
+		names, values = append(names, z.Name), append(values, z.Value)
+	}
+	var err error
+	if c.Columns, err = wal2jsonValues(names, values); err != nil {
+		return nil, err
+	}
+	names, values = nil, nil
+	for _, z := range rec.Identity {
+		names, values = append(names, z.Name), append(values, z.Value)
+	}
+	if c.Identity, err = wal2jsonValues(names, values); err != nil {
+		return nil, err
+	}
+	return append(changes, c), nil
+}
+
+// wal2jsonValues returns the wal2json column values as text format values.
+func wal2jsonValues(names []string, values []json.RawMessage) (map[string]any, error) {
+	if len(names) != len(values) {
+		return nil, fmt.Errorf("wal2json: %d column names with %d values", len(names), len(values))
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	m := make(map[string]any, len(names))
+	for i, name := range names {
+		var err error
+		if m[name], err = jsonValue(values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// TupleColumn is a column of pgoutput tuple data.
+type TupleColumn struct {
+	// Name is the column name from the relation message.
+	Name string
+	// Kind is the column data kind: 'n' (null), 'u' (unchanged TOAST value),
+	// or 't' (text format).
+	Kind byte
+	// Data is the text format column value.
+	Data []byte
+}
+
+// DecodePgoutput decodes the change of kind to the table from the new and old
+// pgoutput tuple data. Old tuple data is only sent for updates and deletes.
+func DecodePgoutput(kind ChangeKind, schema, table string, newTuple, oldTuple []TupleColumn) Change {
+	return Change{
+		Kind:     kind,
+		Schema:   schema,
+		Table:    table,
+		Columns:  tupleValues(newTuple),
+		Identity: tupleValues(oldTuple),
+	}
+}
+
+// tupleValues returns the pgoutput tuple data as text format values, omitting
+// unchanged TOAST values.
+func tupleValues(tuple []TupleColumn) map[string]any {
+	if len(tuple) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(tuple))
+	for _, z := range tuple {
+		switch z.Kind {
+		case 'n':
+			m[z.Name] = nil
+		case 't':
+			m[z.Name] = string(z.Data)
+		}
+	}
+	return m
+}
+
+// changeAssign assigns the text format value v to dest, converting the value
+// in the same way as [sql.Rows.Scan].
+func changeAssign[T any](dest *T, v any) error {
+	if s, ok := v.(string); ok {
+		switch any(dest).(type) {
+		case *time.Time, *sql.NullTime, **time.Time:
+			t, err := parseChangeTime(s)
+			if err != nil {
+				return err
+			}
+			v = t
+		case *[]byte:
+			if strings.HasPrefix(s, `\x`) {
+				buf, err := hex.DecodeString(s[2:])
+				if err != nil {
+					return err
+				}
+				v = buf
+			}
+		}
+	}
+	var n sql.Null[T]
+	if err := n.Scan(v); err != nil {
+		return err
+	}
+	*dest = n.V
+	return nil
+}
+
+// parseChangeTime parses a text format or JSON date or timestamp.
+func parseChangeTime(s string) (time.Time, error) {
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999-07",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+{{- end }}
+{{- if reconcile }}
+
+// DiffKind is the kind of a difference between source and destination rows.
+type DiffKind string
+
+// Diff kinds.
+const (
+	// DiffMissing is a source row missing from the destination.
+	DiffMissing DiffKind = "missing"
+	// DiffExtra is a destination row missing from the source.
+	DiffExtra DiffKind = "extra"
+	// DiffChanged is a row differing between the source and destination.
+	DiffChanged DiffKind = "changed"
+)
+
+// Diff is a difference between a source and destination row.
+type Diff struct {
+	Table string
+	Kind  DiffKind
+	// Src is the source row, or nil for extra rows.
+	Src any
+	// Dst is the destination row, or nil for missing rows.
+	Dst any
+}
+
+// ReconcileOptions are reconciliation options.
+type ReconcileOptions struct {
+	// Report is called with each difference.
+	Report func(Diff)
+	// Apply applies the differences to the destination once both sides have
+	// been compared, upserting missing and changed rows and deleting extra
+	// rows.
+	Apply bool
+}
+
+// ReconcileResult is the number of differences of a reconciliation.
+type ReconcileResult struct {
+	Missing int
+	Extra   int
+	Changed int
+}
+{{- end }}
 
 // Error is an error.
 type Error string
@@ -91,7 +1247,45 @@ const (
 	ErrDoesNotExist Error = "does not exist"
 	// ErrMarkedForDeletion is the marked for deletion error.
 	ErrMarkedForDeletion Error = "marked for deletion"
+{{- if check_rows }}
+	// ErrNoRowsAffected is the no rows affected error.
+	ErrNoRowsAffected Error = "no rows affected"
+{{- end }}
+{{- if temporal }}
+	// ErrSuperseded is the already superseded error.
+	ErrSuperseded Error = "already superseded"
+{{- end }}
+{{- if shard }}
+	// ErrNoShards is the no shards error.
+	ErrNoShards Error = "no shards"
+{{- end }}
+{{- if tenant }}
+	// ErrTenantMismatch is the row of a different tenant error.
+	ErrTenantMismatch Error = "row of a different tenant"
+{{- end }}
+{{- if cdc }}
+	// ErrChangeTable is the change for another table error.
+	ErrChangeTable Error = "change for another table"
+{{- end }}
 )
+{{ if check_rows }}
+// checkRows returns [ErrNoRowsAffected] when res reports no affected rows.
+{{- if driver "mysql" }}
+//
+// MySQL reports the changed rows as affected, so an update not changing any
+// of the row's values returns [ErrNoRowsAffected], unless the connection is
+// opened with the clientFoundRows=true DSN parameter.
+{{- end }}
+func checkRows(res sql.Result) error {
+	switch n, err := res.RowsAffected(); {
+	case err != nil:
+		return err
+	case n == 0:
+		return ErrNoRowsAffected
+	}
+	return nil
+}
+{{ end }}
 
 // ErrInsertFailed is the insert failed error.
 type ErrInsertFailed struct {
@@ -123,6 +1317,24 @@ func (err *ErrUpdateFailed) Unwrap() error {
 	return err.Err
 }
 
+{{- if check_rows }}
+
+// ErrDeleteFailed is the delete failed error.
+type ErrDeleteFailed struct {
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *ErrDeleteFailed) Error() string {
+	return fmt.Sprintf("delete failed: %v", err.Err)
+}
+
+// Unwrap satisfies the unwrap interface.
+func (err *ErrDeleteFailed) Unwrap() error {
+	return err.Err
+}
+{{- end }}
+
 // ErrUpsertFailed is the upsert failed error.
 type ErrUpsertFailed struct {
 	Err error
@@ -237,3 +1449,14 @@ var TimestampFormats = []string{
 }
 {{- end }}
 {{- end }}
+
+{{ define "catalog" }}
+{{- $stmts := .Data -}}
+func init() {
+	addQueries(
+{{- range $stmts }}{{ range query_infos .Type .Data }}
+		QueryInfo{{ . }},
+{{- end }}{{ end }}
+	)
+}
+{{ end }}
@@ -7,15 +7,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/kenshaw/inflector"
 	"github.com/kenshaw/snaker"
@@ -124,27 +129,41 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				ContextKey: PkgKey,
 				Type:       "string",
 				Desc:       "package name",
+				Expand:     true,
 			},
 			{
 				ContextKey: TagKey,
 				Type:       "[]string",
 				Desc:       "build tags",
 			},
+			{
+				ContextKey: NolintKey,
+				Type:       "[]string",
+				Desc:       "linters excluded by a nolint directive in generated files (i.e. all, or funlen)",
+			},
+			{
+				ContextKey: MaxLinesKey,
+				Type:       "int",
+				Desc:       "max lines of Load graph funcs, splitting their scan blocks into funcs when exceeded",
+			},
 			{
 				ContextKey: ImportKey,
 				Type:       "[]string",
 				Desc:       "package imports",
+				Expand:     true,
 			},
 			{
 				ContextKey: UUIDKey,
 				Type:       "string",
 				Desc:       "uuid type package",
 				Default:    "github.com/google/uuid",
+				Expand:     true,
 			},
 			{
 				ContextKey: CustomKey,
 				Type:       "string",
 				Desc:       "package name for custom types",
+				Expand:     true,
 			},
 			{
 				ContextKey: ConflictKey,
@@ -157,6 +176,16 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Type:       "[]string",
 				Desc:       "add initialism (e.g. ID, API, URI, ...)",
 			},
+			{
+				ContextKey: IdentMapKey,
+				Type:       "[]string",
+				Desc:       "map non-ASCII identifiers to Go names (e.g. straße=Street)",
+			},
+			{
+				ContextKey: RenameKey,
+				Type:       "[]string",
+				Desc:       "rename fields (i.e. authors.first_name=GivenName, or first_name=GivenName for all tables)",
+			},
 			{
 				ContextKey: EscKey,
 				Type:       "[]string",
@@ -177,6 +206,188 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Desc:       "context mode",
 				Enums:      []string{"only", "disable", "both"},
 			},
+			{
+				ContextKey: ContextPosKey,
+				Type:       "string",
+				Desc:       "context parameter position",
+				Enums:      []string{"first", "last"},
+			},
+			{
+				ContextKey: DBTypeKey,
+				Type:       "string",
+				Desc:       "name of the db interface type",
+				Default:    "DB",
+			},
+			{
+				ContextKey: NarrowDBKey,
+				Type:       "bool",
+				Desc:       "use narrow db interfaces (Execer, Querier, RowQuerier)",
+			},
+			{
+				ContextKey: ShardKey,
+				Type:       "bool",
+				Desc:       "enables ShardRouter generation for sharded databases",
+			},
+			{
+				ContextKey: RetryKey,
+				Type:       "bool",
+				Desc:       "enables retry policy for transient errors on reads",
+			},
+			{
+				ContextKey: HooksKey,
+				Type:       "bool",
+				Desc:       "enables Before and After hooks called by Insert, Update, Upsert, and Delete",
+			},
+			{
+				ContextKey: QueryHintKey,
+				Type:       "string",
+				Desc:       "leading query hint comment (i.e 'app=checkout op={{ .Name }}')",
+			},
+			{
+				ContextKey: QueryIDKey,
+				Type:       "bool",
+				Desc:       "enables QueryID constants for generated statements",
+			},
+			{
+				ContextKey: CatalogKey,
+				Type:       "bool",
+				Desc:       "enables Queries() catalog of generated statements",
+			},
+			{
+				ContextKey: EncryptKey,
+				Type:       "[]string",
+				Desc:       "encrypted columns (i.e. users.ssn, or ssn for all tables)",
+			},
+			{
+				ContextKey: EncryptBase64Key,
+				Type:       "bool",
+				Desc:       "enables base64 encoding of encrypted columns, allowing text columns",
+			},
+			{
+				ContextKey: SensitiveKey,
+				Type:       "[]string",
+				Desc:       "sensitive columns omitted by Redacted list funcs (i.e. users.ssn)",
+			},
+			{
+				ContextKey: InsertOnlyKey,
+				Type:       "[]string",
+				Desc:       "insert only columns never updated by Update or Upsert (i.e. created_at)",
+			},
+			{
+				ContextKey: UpdateOnlyKey,
+				Type:       "[]string",
+				Desc:       "update only columns left to their default by Insert (i.e. users.updated_at)",
+			},
+			{
+				ContextKey: TenantKey,
+				Type:       "string",
+				Desc:       "tenant column required by generated queries (i.e. tenant_id)",
+			},
+			{
+				ContextKey: SoftDeleteKey,
+				Type:       "string",
+				Desc:       "nullable time column soft deleting rows, enabling Destroy funcs (i.e. deleted_at)",
+			},
+			{
+				ContextKey: OrderByKey,
+				Type:       "[]string",
+				Desc:       "default order of rows returned by list funcs (i.e. books=created_at DESC)",
+			},
+			{
+				ContextKey: EnumValueKey,
+				Type:       "[]string",
+				Desc:       "pin enum const values (i.e. book_type.fiction=1)",
+			},
+			{
+				ContextKey: InsertManyKey,
+				Type:       "bool",
+				Desc:       "enables InsertMany funcs inserting rows with multi-row INSERT statements",
+			},
+			{
+				ContextKey: UpsertIndexKey,
+				Type:       "bool",
+				Desc:       "enables UpsertBy funcs upserting on the conflict of unique indexes (postgres and sqlite only)",
+			},
+			{
+				ContextKey: PagedKey,
+				Type:       "bool",
+				Desc:       "enables Paged and After variants of list funcs paginating by offset and primary key",
+			},
+			{
+				ContextKey: CheckRowsKey,
+				Type:       "bool",
+				Desc:       "return ErrNoRowsAffected when Update or Delete matches no rows",
+			},
+			{
+				ContextKey: ExecResultKey,
+				Type:       "bool",
+				Desc:       "return ExecResult from custom exec queries",
+			},
+			{
+				ContextKey: UnitOfWorkKey,
+				Type:       "bool",
+				Desc:       "enables UnitOfWork generation",
+			},
+			{
+				ContextKey: CascadeKey,
+				Type:       "bool",
+				Desc:       "enables cascading delete funcs following foreign keys",
+			},
+			{
+				ContextKey: PreloadKey,
+				Type:       "int",
+				Desc:       "enables Load graph funcs preloading rows up to the foreign key depth",
+			},
+			{
+				ContextKey: GraphJSONKey,
+				Type:       "bool",
+				Desc:       "enables single query JSON variants of Load graph funcs (postgres only)",
+			},
+			{
+				ContextKey: TemporalKey,
+				Type:       "string",
+				Desc:       "temporal validity columns enabling AsOf and Supersede funcs (i.e. valid_from,valid_to)",
+			},
+			{
+				ContextKey: OutboxKey,
+				Type:       "string",
+				Desc:       "outbox table enabling InsertWithOutbox funcs and an outbox consumer",
+			},
+			{
+				ContextKey: CDCKey,
+				Type:       "bool",
+				Desc:       "enables change data capture decoders for wal2json and pgoutput (postgres only)",
+			},
+			{
+				ContextKey: NotifyKey,
+				Type:       "string",
+				Desc:       "channel enabling NOTIFY trigger SQL with ChangeEvent payloads (postgres only)",
+			},
+			{
+				ContextKey: ReconcileKey,
+				Type:       "bool",
+				Desc:       "enables Reconcile funcs comparing tables between databases",
+			},
+			{
+				ContextKey: TestsKey,
+				Type:       "bool",
+				Desc:       "enables tests asserting generated funcs return the error of a canceled context",
+			},
+			{
+				ContextKey: ExplainKey,
+				Type:       "int",
+				Desc:       "enables a test failing on sequential scans of tables over the row count (postgres only, requires --go-catalog)",
+			},
+			{
+				ContextKey: TestHelpersKey,
+				Type:       "bool",
+				Desc:       "enables test helpers for rolled back transactions and per test schemas (postgres only)",
+			},
+			{
+				ContextKey: GoVersionKey,
+				Type:       "string",
+				Desc:       "minimum go version of generated code (e.g. 1.18, default: latest)",
+			},
 			{
 				ContextKey: InjectKey,
 				Type:       "string",
@@ -188,6 +399,7 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				Type:       "string",
 				Desc:       "insert code into generated file headers from a file",
 				Default:    "",
+				Expand:     true,
 			},
 			{
 				ContextKey: LegacyKey,
@@ -217,12 +429,12 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 			return ctx
 		},
 		Order: func(ctx context.Context, mode string) []string {
-			base := []string{"header", "db"}
+			base := []string{"header", "db", "region_begin"}
 			switch mode {
 			case "query":
-				return append(base, "typedef", "query")
+				return append(base, "typedef", "query", "catalog", "region_end")
 			case "schema":
-				return append(base, "enum", "proc", "typedef", "query", "index", "foreignkey")
+				return append(base, "enum", "bitmask", "proc", "typedef", "insert_many", "constraint", "query", "index", "index_asof", "index_paged", "upsert_index", "foreignkey", "cascade", "graph", "preload", "preload_json", "catalog", "unitofwork", "outbox", "notify", "reconcile", "test_db", "test", "explain", "region_end")
 			}
 			return nil
 		},
@@ -230,6 +442,24 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 			if err := addInitialisms(ctx); err != nil {
 				return err
 			}
+			if err := addIdentMap(ctx); err != nil {
+				return err
+			}
+			if err := checkRename(ctx, set); err != nil {
+				return err
+			}
+			if err := checkOrderBy(ctx, set); err != nil {
+				return err
+			}
+			if err := checkEnumValues(ctx, set); err != nil {
+				return err
+			}
+			if err := checkDBType(ctx); err != nil {
+				return err
+			}
+			if Explain(ctx) > 0 && !Catalog(ctx) {
+				return errors.New("--go-explain requires --go-catalog")
+			}
 			files, err := fileNames(ctx, mode, set)
 			if err != nil {
 				return err
@@ -245,12 +475,48 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 				if xo.Single(ctx) == "" {
 					files["dbtpl.dbtpl.go"] = true
 				}
+				if driver, _, _ := xo.DriverDbSchema(ctx); mode == "schema" && driver == "postgres" && Explain(ctx) > 0 && xo.Single(ctx) == "" {
+					emit(xo.Template{
+						Partial: "explain",
+						Dest:    "explain" + testExt,
+						Data:    Explain(ctx),
+					})
+					files["explain"+testExt] = true
+				}
+				if mode == "schema" && genTests(ctx) {
+					emit(xo.Template{
+						Partial: "test_db",
+						Dest:    "dbtpl" + testExt,
+					})
+					files["dbtpl"+testExt] = true
+				}
+			}
+			// emit in file name order, as map iteration order is random (the
+			// interpreter's slices package has no sort funcs, so use sort)
+			var filenames []string
+			for filename := range files {
+				filenames = append(filenames, filename)
 			}
+			sort.Strings(filenames)
+			// If -a is provided, generate into the file's marked region,
+			// keeping the file's content outside the region.
 			if Append(ctx) {
-				for filename := range files {
+				for _, filename := range filenames {
+					emit(xo.Template{
+						Src:     "{{.Data}}",
+						Partial: "region_begin",
+						Data:    "\n" + regionBegin + "\n\n",
+						Dest:    filename,
+					})
 					f, err := out.Open(filename)
 					switch {
 					case errors.Is(err, os.ErrNotExist):
+						emit(xo.Template{
+							Src:     "{{.Data}}",
+							Partial: "region_end",
+							Data:    "\n" + regionEnd + "\n",
+							Dest:    filename,
+						})
 						continue
 					case err != nil:
 						return err
@@ -260,16 +526,29 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 					if err != nil {
 						return err
 					}
+					before, after, err := splitRegion(data)
+					if err != nil {
+						return fmt.Errorf("%s: %w", filename, err)
+					}
 					emit(xo.Template{
 						Src:     "{{.Data}}",
 						Partial: "header", // ordered first
-						Data:    string(data),
+						Data:    before,
+						Dest:    filename,
+					})
+					emit(xo.Template{
+						Src:     "{{.Data}}",
+						Partial: "region_end",
+						Data:    "\n" + regionEnd + "\n" + after,
 						Dest:    filename,
 					})
 					delete(files, filename)
 				}
 			}
-			for filename := range files {
+			for _, filename := range filenames {
+				if !files[filename] {
+					continue
+				}
 				emit(xo.Template{
 					Partial: "header",
 					Dest:    filename,
@@ -294,16 +573,26 @@ func Init(ctx context.Context, f func(xo.TemplateType)) error {
 			return nil
 		},
 		Post: func(ctx context.Context, mode string, files map[string][]byte, emit func(string, []byte)) error {
-			for file, content := range files {
+			// process in file name order, for consistent errors
+			var filenames []string
+			for file := range files {
+				filenames = append(filenames, file)
+			}
+			sort.Strings(filenames)
+			for _, file := range filenames {
 				// Run goimports.
-				buf, err := imports.Process("", content, nil)
+				buf, err := imports.Process("", files[file], nil)
 				if err != nil {
 					return fmt.Errorf("%s:%w", file, err)
 				}
 				// Run gofumpt.
-				formatted, err := format.Source(buf, format.Options{
+				opts := format.Options{
 					ExtraRules: true,
-				})
+				}
+				if v, _ := goVersion(GoVersion(ctx)); v != 0 {
+					opts.LangVersion = fmt.Sprintf("go1.%d", v)
+				}
+				formatted, err := format.Source(buf, opts)
 				if err != nil {
 					return err
 				}
@@ -348,11 +637,20 @@ func fileNames(ctx context.Context, mode string, set *xo.Set) (map[string]bool,
 			}
 			for _, t := range schema.Tables {
 				addFile(camelExport(singularize(t.Name)))
+				if genTests(ctx) && hasTests(t) {
+					files[strings.ToLower(camelExport(singularize(t.Name)))+testExt] = true
+				}
 			}
 			for _, v := range schema.Views {
 				addFile(camelExport(singularize(v.Name)))
 			}
 		}
+		if UnitOfWork(ctx) {
+			addFile("unitofwork")
+		}
+		if driver, _, _ := xo.DriverDbSchema(ctx); driver == "postgres" && Notify(ctx) != "" {
+			addFile("notify")
+		}
 	case "query":
 		for _, query := range set.Queries {
 			addFile(query.Type)
@@ -392,31 +690,43 @@ func emitQuery(ctx context.Context, query xo.Query, emit func(xo.Template)) erro
 	var params []QueryParam
 	for _, param := range query.Params {
 		params = append(params, QueryParam{
-			Name:        param.Name,
+			Name:        safeName(param.Name, Conflict(ctx)),
 			Type:        param.Type.Type,
 			Interpolate: param.Interpolate,
 			Join:        param.Join,
 		})
 	}
+	q := Query{
+		Name:        buildQueryName(query),
+		Query:       query.Query,
+		Comments:    query.Comments,
+		Params:      params,
+		One:         query.Exec || query.Flat || query.One,
+		Flat:        query.Flat,
+		Exec:        query.Exec,
+		Interpolate: query.Interpolate,
+		Type:        table,
+		Comment:     query.Comment,
+	}
 	// emit query
 	emit(xo.Template{
 		Partial:  "query",
 		Dest:     strings.ToLower(table.GoName) + ext,
 		SortType: query.Type,
 		SortName: query.Name,
-		Data: Query{
-			Name:        buildQueryName(query),
-			Query:       query.Query,
-			Comments:    query.Comments,
-			Params:      params,
-			One:         query.Exec || query.Flat || query.One,
-			Flat:        query.Flat,
-			Exec:        query.Exec,
-			Interpolate: query.Interpolate,
-			Type:        table,
-			Comment:     query.Comment,
-		},
+		Data:     q,
 	})
+	// emit catalog, skipping interpolated queries as their SQL is only known
+	// at runtime
+	if Catalog(ctx) && !q.Interpolate {
+		emit(xo.Template{
+			Partial:  "catalog",
+			Dest:     strings.ToLower(table.GoName) + ext,
+			SortType: query.Type,
+			SortName: query.Name,
+			Data:     []Statement{{"query", q}},
+		})
+	}
 	return nil
 }
 
@@ -427,7 +737,7 @@ func buildQueryType(ctx context.Context, query xo.Query) (Table, error) {
 	}
 	var fields []Field
 	for _, z := range query.Fields {
-		f, err := convertField(ctx, tf, z)
+		f, err := convertField(ctx, tf, "", z)
 		if err != nil {
 			return Table{}, err
 		}
@@ -439,6 +749,10 @@ func buildQueryType(ctx context.Context, query xo.Query) (Table, error) {
 				Type:    z.Type.Type,
 			}
 		}
+		xo.Logf(ctx, "TYPE: %s.%s %s -> %s", query.Type, z.Name, sqlType(z.Type), f.Type)
+		if !query.ManualFields && fallbackType(z.Type, f.Type) {
+			xo.Warnf(ctx, "type", query.Type+"."+z.Name, "unmapped type %s defaulted to %s", sqlType(z.Type), f.Type)
+		}
 		fields = append(fields, f)
 	}
 	sqlName := snake(query.Type)
@@ -476,10 +790,15 @@ func buildQueryName(query xo.Query) string {
 func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) error {
 	// emit enums
 	for _, e := range schema.Enums {
-		enum := convertEnum(e)
+		enum, err := convertEnum(ctx, e)
+		if err != nil {
+			return err
+		}
+		dest := strings.ToLower(enum.GoName) + ext
+		checkRenumber(ctx, enum, dest)
 		emit(xo.Template{
 			Partial:  "enum",
-			Dest:     strings.ToLower(enum.GoName) + ext,
+			Dest:     dest,
 			SortName: enum.GoName,
 			Data:     enum,
 		})
@@ -512,13 +831,49 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 			SortName: prefix + name,
 			Data:     procs,
 		})
+		if Catalog(ctx) {
+			var stmts []Statement
+			for _, p := range procs {
+				// mysql procedures with out parameters are unsupported
+				if driver, _, _ := xo.DriverDbSchema(ctx); driver != "mysql" || p.Type != "procedure" || p.Void {
+					stmts = append(stmts, Statement{"proc", p})
+				}
+			}
+			if len(stmts) != 0 {
+				emit(xo.Template{
+					Dest:     prefix + strings.ToLower(name) + ext,
+					Partial:  "catalog",
+					SortName: prefix + name,
+					Data:     stmts,
+				})
+			}
+		}
+	}
+	// tenant scoped tables
+	tenants := make(map[string]bool)
+	for _, t := range schema.Tables {
+		for _, c := range t.Columns {
+			if s := TenantColumn(ctx); s != "" && s == c.Name && !c.IsPrimary {
+				tenants[t.Name] = true
+			}
+		}
 	}
 	// emit tables
+	enums := make(map[string]bool)
+	for _, e := range schema.Enums {
+		enums[e.Name] = true
+	}
+	goNames, constraintNames := make(map[string]string), make(map[string]string)
 	for _, t := range append(schema.Tables, schema.Views...) {
 		table, err := convertTable(ctx, t)
 		if err != nil {
 			return err
 		}
+		checkTypes(ctx, enums, t, table)
+		if name, ok := goNames[table.GoName]; ok {
+			xo.Warnf(ctx, "collision", t.Name, "type %s collides with %s", table.GoName, name)
+		}
+		goNames[table.GoName] = t.Name
 		emit(xo.Template{
 			Dest:     strings.ToLower(table.GoName) + ext,
 			Partial:  "typedef",
@@ -526,12 +881,60 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 			SortName: table.GoName,
 			Data:     table,
 		})
+		// emit constraint names
+		if constraints := convertConstraints(ctx, constraintNames, t); len(constraints) != 0 {
+			emit(xo.Template{
+				Dest:     strings.ToLower(table.GoName) + ext,
+				Partial:  "constraint",
+				SortType: table.Type,
+				SortName: table.GoName,
+				Data:     Constraints{Table: table, Constraints: constraints},
+			})
+		}
+		// emit bitmasks
+		for _, f := range table.Fields {
+			if f.Bitmask != nil {
+				emit(xo.Template{
+					Dest:     strings.ToLower(table.GoName) + ext,
+					Partial:  "bitmask",
+					SortType: table.Type,
+					SortName: f.Bitmask.GoName,
+					Data:     f.Bitmask,
+				})
+			}
+		}
+		// emit insert many
+		if insertMany(ctx, t, table) {
+			emit(xo.Template{
+				Dest:     strings.ToLower(table.GoName) + ext,
+				Partial:  "insert_many",
+				SortType: table.Type,
+				SortName: table.GoName,
+				Data:     table,
+			})
+		}
+		// emit reconcile
+		if Reconcile(ctx) && reconcilable(table) {
+			emit(xo.Template{
+				Dest:     strings.ToLower(table.GoName) + ext,
+				Partial:  "reconcile",
+				SortType: table.Type,
+				SortName: table.GoName,
+				Data:     table,
+			})
+		}
 		// emit indexes
+		var indexes []Index
 		for _, i := range t.Indexes {
+			if name, ok := encryptedColumn(ctx, t.Name, i.Fields); ok {
+				xo.Warnf(ctx, "skip", t.Name+"."+name, "skipping %s: index %s is on an encrypted column", camelExport(i.Func), i.Name)
+				continue
+			}
 			index, err := convertIndex(ctx, table, i)
 			if err != nil {
 				return err
 			}
+			indexes = append(indexes, index)
 			emit(xo.Template{
 				Dest:     strings.ToLower(table.GoName) + ext,
 				Partial:  "index",
@@ -539,13 +942,84 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 				SortName: index.SQLName,
 				Data:     index,
 			})
+			// emit temporal variant
+			if asof, ok := asOfIndex(index); ok {
+				indexes = append(indexes, asof)
+				emit(xo.Template{
+					Dest:     strings.ToLower(table.GoName) + ext,
+					Partial:  "index_asof",
+					SortType: table.Type,
+					SortName: asof.SQLName,
+					Data:     asof,
+				})
+			}
+			// emit redacted list variant
+			if redacted, ok := redactIndex(ctx, index); ok && !index.IsUnique {
+				indexes = append(indexes, redacted)
+				emit(xo.Template{
+					Dest:     strings.ToLower(table.GoName) + ext,
+					Partial:  "index",
+					SortType: table.Type,
+					SortName: index.SQLName + "_redacted",
+					Data:     redacted,
+				})
+			}
+			// emit unique index upsert
+			if upsertIndex(ctx, index) {
+				emit(xo.Template{
+					Dest:     strings.ToLower(table.GoName) + ext,
+					Partial:  "upsert_index",
+					SortType: table.Type,
+					SortName: index.SQLName,
+					Data:     index,
+				})
+			}
+			// emit paged variants
+			if Paged(ctx) {
+				for _, paged := range pagedIndexes(ctx, index) {
+					indexes = append(indexes, paged)
+					emit(xo.Template{
+						Dest:     strings.ToLower(table.GoName) + ext,
+						Partial:  "index_paged",
+						SortType: table.Type,
+						SortName: index.SQLName + "_" + strings.ToLower(strings.TrimPrefix(paged.Func, index.Func)),
+						Data:     paged,
+					})
+				}
+			}
+		}
+		// emit tests
+		if t.Type == "table" && genTests(ctx) && hasTests(t) {
+			emit(xo.Template{
+				Dest:     strings.ToLower(table.GoName) + testExt,
+				Partial:  "test",
+				SortType: table.Type,
+				SortName: table.GoName,
+				Data:     TableTest{Table: table, Indexes: indexes},
+			})
 		}
 		// emit fkeys
 		for _, fk := range t.ForeignKeys {
+			if name, ok := encryptedColumn(ctx, t.Name, fk.Fields); ok {
+				xo.Warnf(ctx, "skip", t.Name+"."+name, "skipping %s.%s: foreign key %s is on an encrypted column", table.GoName, camelExport(fk.Func), fk.Name)
+				continue
+			}
+			if name, ok := encryptedColumn(ctx, fk.RefTable, fk.RefFields); ok {
+				xo.Warnf(ctx, "skip", fk.RefTable+"."+name, "skipping %s.%s: foreign key %s references an encrypted column", table.GoName, camelExport(fk.Func), fk.Name)
+				continue
+			}
 			fkey, err := convertFKey(ctx, table, fk)
 			if err != nil {
 				return err
 			}
+			// scope to the tenant, skipping when the tenant is unknown
+			if tenants[fk.RefTable] {
+				if table.Tenant == nil {
+					continue
+				}
+				fkey.Fields = append(fkey.Fields, *table.Tenant)
+				fkey.RefFields = append(fkey.RefFields, *table.Tenant)
+			}
 			emit(xo.Template{
 				Dest:     strings.ToLower(table.GoName) + ext,
 				Partial:  "foreignkey",
@@ -554,29 +1028,544 @@ func emitSchema(ctx context.Context, schema xo.Schema, emit func(xo.Template)) e
 				Data:     fkey,
 			})
 		}
+		// emit catalog
+		if Catalog(ctx) {
+			stmts := catalogStatements(table)
+			if insertMany(ctx, t, table) {
+				stmts = append(stmts, Statement{"insert_many", table})
+			}
+			if Reconcile(ctx) && reconcilable(table) {
+				stmts = append(stmts, Statement{"reconcile", table})
+			}
+			for _, i := range t.Indexes {
+				if _, ok := encryptedColumn(ctx, t.Name, i.Fields); ok {
+					continue
+				}
+				index, err := convertIndex(ctx, table, i)
+				if err != nil {
+					return err
+				}
+				stmts = append(stmts, Statement{"index", index})
+				if asof, ok := asOfIndex(index); ok {
+					stmts = append(stmts, Statement{"index", asof})
+				}
+				if redacted, ok := redactIndex(ctx, index); ok && !index.IsUnique {
+					stmts = append(stmts, Statement{"index", redacted})
+				}
+				if upsertIndex(ctx, index) {
+					stmts = append(stmts, Statement{"upsert_index", index})
+				}
+				if Paged(ctx) {
+					for _, paged := range pagedIndexes(ctx, index) {
+						stmts = append(stmts, Statement{"index_paged", paged})
+					}
+				}
+			}
+			if len(stmts) != 0 {
+				emit(xo.Template{
+					Dest:     strings.ToLower(table.GoName) + ext,
+					Partial:  "catalog",
+					SortType: table.Type,
+					SortName: table.GoName,
+					Data:     stmts,
+				})
+			}
+		}
 	}
-	return nil
-}
-
-// convertEnum converts a xo.Enum.
-func convertEnum(e xo.Enum) Enum {
-	var vals []EnumValue
-	goName := camelExport(e.Name)
-	for _, v := range e.Values {
-		name := camelExport(strings.ToLower(v.Name))
-		if strings.HasSuffix(name, goName) && goName != name {
-			name = strings.TrimSuffix(name, goName)
+	// emit cascading deletes
+	if Cascade(ctx) {
+		deletes, err := cascades(ctx, schema)
+		if err != nil {
+			return err
 		}
-		vals = append(vals, EnumValue{
-			GoName:     name,
+		for _, c := range deletes {
+			emit(xo.Template{
+				Dest:     strings.ToLower(c.Table.GoName) + ext,
+				Partial:  "cascade",
+				SortType: c.Table.Type,
+				SortName: c.Func,
+				Data:     c,
+			})
+			if Catalog(ctx) {
+				emit(xo.Template{
+					Dest:     strings.ToLower(c.Table.GoName) + ext,
+					Partial:  "catalog",
+					SortType: c.Table.Type,
+					SortName: c.Func,
+					Data:     []Statement{{"cascade", c}},
+				})
+			}
+		}
+	}
+	// emit preloads
+	if depth := PreloadDepth(ctx); depth > 0 {
+		graphs, preloads, err := buildPreloads(ctx, schema, depth)
+		if err != nil {
+			return err
+		}
+		for _, g := range graphs {
+			emit(xo.Template{
+				Dest:     strings.ToLower(g.Table.GoName) + ext,
+				Partial:  "graph",
+				SortType: g.Table.Type,
+				SortName: g.Table.GoName,
+				Data:     g,
+			})
+		}
+		for _, p := range preloads {
+			emit(xo.Template{
+				Dest:     strings.ToLower(p.Table.GoName) + ext,
+				Partial:  "preload",
+				SortType: p.Table.Type,
+				SortName: p.Func,
+				Data:     p,
+			})
+			name, stmts := p.Func, []Statement{{"preload", p}}
+			if p.JSON {
+				p.Func += "JSON"
+				emit(xo.Template{
+					Dest:     strings.ToLower(p.Table.GoName) + ext,
+					Partial:  "preload_json",
+					SortType: p.Table.Type,
+					SortName: p.Func,
+					Data:     p,
+				})
+				stmts = append(stmts, Statement{"preload_json", p})
+			}
+			if Catalog(ctx) {
+				emit(xo.Template{
+					Dest:     strings.ToLower(p.Table.GoName) + ext,
+					Partial:  "catalog",
+					SortType: p.Table.Type,
+					SortName: name,
+					Data:     stmts,
+				})
+			}
+		}
+	}
+	// emit outbox consumer
+	if name := Outbox(ctx); name != "" {
+		var t *xo.Table
+		for i := range schema.Tables {
+			if schema.Tables[i].Name == name {
+				t = &schema.Tables[i]
+			}
+		}
+		if t == nil {
+			return fmt.Errorf("outbox table %q not found", name)
+		}
+		table, err := convertTable(ctx, *t)
+		switch {
+		case err != nil:
+			return err
+		case len(table.PrimaryKeys) == 0:
+			return fmt.Errorf("outbox table %q has no primary key", name)
+		}
+		emit(xo.Template{
+			Dest:     strings.ToLower(table.GoName) + ext,
+			Partial:  "outbox",
+			SortType: table.Type,
+			SortName: table.GoName,
+			Data:     table,
+		})
+		if Catalog(ctx) {
+			emit(xo.Template{
+				Dest:     strings.ToLower(table.GoName) + ext,
+				Partial:  "catalog",
+				SortType: table.Type,
+				SortName: "Poll" + table.GoName,
+				Data:     []Statement{{"outbox", table}},
+			})
+		}
+	}
+	// emit notify triggers
+	if driver, _, _ := xo.DriverDbSchema(ctx); driver == "postgres" && Notify(ctx) != "" {
+		var tables []Table
+		for _, t := range schema.Tables {
+			table, err := convertTable(ctx, t)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, table)
+		}
+		if len(tables) != 0 {
+			emit(xo.Template{
+				Dest:     "notify" + ext,
+				Partial:  "notify",
+				SortName: "Notify",
+				Data:     tables,
+			})
+		}
+	}
+	// emit unit of work
+	if UnitOfWork(ctx) {
+		tables, err := uowTables(ctx, schema)
+		if err != nil {
+			return err
+		}
+		if len(tables) != 0 {
+			emit(xo.Template{
+				Dest:     "unitofwork" + ext,
+				Partial:  "unitofwork",
+				SortName: "UnitOfWork",
+				Data:     tables,
+			})
+		}
+	}
+	return nil
+}
+
+// uowTables returns the schema's tables having primary keys, ordered so that
+// each table follows the tables it references by foreign key. Foreign key
+// cycles are broken in schema order.
+func uowTables(ctx context.Context, schema xo.Schema) ([]Table, error) {
+	m := make(map[string]xo.Table)
+	for _, t := range schema.Tables {
+		m[t.Name] = t
+	}
+	var tables []Table
+	seen := make(map[string]bool)
+	var visit func(xo.Table) error
+	visit = func(t xo.Table) error {
+		if seen[t.Name] {
+			return nil
+		}
+		seen[t.Name] = true
+		for _, fk := range t.ForeignKeys {
+			if ref, ok := m[fk.RefTable]; ok {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+		table, err := convertTable(ctx, t)
+		if err != nil {
+			return err
+		}
+		if len(table.PrimaryKeys) != 0 {
+			tables = append(tables, table)
+		}
+		return nil
+	}
+	for _, t := range schema.Tables {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// cascades returns the cascading deletes for the schema's tables having
+// primary keys that are referenced by single column foreign keys. Each path of
+// foreign keys from a table to a dependent table is followed once, and self
+// referencing foreign keys are not followed.
+func cascades(ctx context.Context, schema xo.Schema) ([]CascadeDelete, error) {
+	tables := make(map[string]Table)
+	refs := make(map[string][]CascadeStep)
+	for _, t := range schema.Tables {
+		table, err := convertTable(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		tables[t.Name] = table
+		for _, fk := range t.ForeignKeys {
+			if len(fk.Fields) != 1 || len(fk.RefFields) != 1 || fk.RefTable == t.Name {
+				continue
+			}
+			refs[fk.RefTable] = append(refs[fk.RefTable], CascadeStep{
+				Table:      t.Name,
+				Field:      fk.Fields[0].Name,
+				RefTable:   fk.RefTable,
+				RefField:   fk.RefFields[0].Name,
+				Tenant:     tenantName(table),
+				SoftDelete: softDeleteName(table),
+			})
+		}
+	}
+	var v []CascadeDelete
+	for _, t := range schema.Tables {
+		table := tables[t.Name]
+		if len(refs[t.Name]) == 0 || len(table.PrimaryKeys) == 0 {
+			continue
+		}
+		// walk the dependent tables, collecting the paths of dependents before
+		// the paths of the tables they depend on
+		var paths [][]CascadeStep
+		var walk func(string, []CascadeStep)
+		walk = func(name string, path []CascadeStep) {
+			for _, step := range refs[name] {
+				cycle := step.Table == t.Name
+				for _, z := range path {
+					cycle = cycle || z.Table == step.Table
+				}
+				if cycle {
+					continue
+				}
+				p := append(append([]CascadeStep{}, path...), step)
+				walk(step.Table, p)
+				paths = append(paths, p)
+			}
+		}
+		walk(t.Name, nil)
+		v = append(v, CascadeDelete{
+			Func:  "Delete" + table.GoName + "Cascade",
+			Table: table,
+			Paths: paths,
+		})
+	}
+	return v, nil
+}
+
+// buildPreloads builds the graph types and preloads for the schema's tables
+// having primary keys. A relation is a single column foreign key from a table
+// having a primary key, where the column has the same type as the referenced
+// column.
+func buildPreloads(ctx context.Context, schema xo.Schema, depth int) ([]Graph, []Preload, error) {
+	tables := make(map[string]Table)
+	jsonable := make(map[string]bool)
+	for _, t := range schema.Tables {
+		table, err := convertTable(ctx, t)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(table.PrimaryKeys) != 0 {
+			tables[t.Name] = table
+			jsonable[t.Name] = graphJSON(ctx, schema, t, table)
+		}
+	}
+	// collect relations
+	relations := make(map[string][]Relation)
+	for _, t := range schema.Tables {
+		table, ok := tables[t.Name]
+		if !ok {
+			continue
+		}
+		for _, fk := range t.ForeignKeys {
+			ref, ok := tables[fk.RefTable]
+			if !ok || len(fk.Fields) != 1 || len(fk.RefFields) != 1 {
+				continue
+			}
+			field, err := convertField(ctx, camelExport, t.Name, fk.Fields[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			refField, err := convertField(ctx, camelExport, fk.RefTable, fk.RefFields[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			if field.Type != refField.Type || field.Type == "[]byte" {
+				continue
+			}
+			relations[ref.SQLName] = append(relations[ref.SQLName], Relation{
+				Name:     inflector.Pluralize(table.GoName),
+				Table:    table,
+				Field:    field,
+				RefField: refField,
+			})
+		}
+	}
+	// disambiguate relations from the same table
+	for _, v := range relations {
+		count := make(map[string]int)
+		for _, rel := range v {
+			count[rel.Name]++
+		}
+		for i := range v {
+			if count[v[i].Name] > 1 {
+				v[i].Name += "By" + v[i].Field.GoName
+			}
+			v[i].Key = snake(v[i].Name)
+			v[i].JSON = jsonable[v[i].Table.SQLName]
+		}
+	}
+	// build graphs and preloads
+	var graphs []Graph
+	var preloads []Preload
+	for _, t := range schema.Tables {
+		table, ok := tables[t.Name]
+		if !ok {
+			continue
+		}
+		graphs = append(graphs, Graph{
+			Table:     table,
+			Relations: relations[t.Name],
+			JSON:      jsonable[t.Name],
+		})
+		if len(relations[t.Name]) == 0 {
+			continue
+		}
+		nodes := []PreloadNode{{Table: table, Parent: -1}}
+		json := true
+		for i := 0; i < len(nodes); i++ {
+			n := nodes[i]
+			json = json && jsonable[n.Table.SQLName]
+			if len(n.Path) == depth {
+				continue
+			}
+			for _, rel := range relations[n.Table.SQLName] {
+				nodes = append(nodes, PreloadNode{
+					Table:       rel.Table,
+					Parent:      i,
+					ParentTable: n.Table,
+					Relation:    rel,
+					Path: append(append([]CascadeStep{}, n.Path...), CascadeStep{
+						Table:    rel.Table.SQLName,
+						Field:    rel.Field.SQLName,
+						RefTable: n.Table.SQLName,
+						RefField: rel.RefField.SQLName,
+						Tenant:   tenantName(rel.Table),
+					}),
+				})
+			}
+		}
+		preloads = append(preloads, Preload{
+			Func:  "Load" + table.GoName + "Graph",
+			Table: table,
+			Depth: depth,
+			Nodes: nodes,
+			JSON:  json,
+		})
+	}
+	return graphs, preloads, nil
+}
+
+// graphJSON returns true when single query JSON preloads are enabled, and the
+// rows of the table can be unmarshaled from the JSON built by postgres. Each
+// column preventing the JSON preloads of the table is reported.
+func graphJSON(ctx context.Context, schema xo.Schema, t xo.Table, table Table) bool {
+	if driver, _, _ := xo.DriverDbSchema(ctx); driver != "postgres" || !PreloadJSON(ctx) {
+		return false
+	}
+	enums := make(map[string]bool)
+	for _, e := range schema.Enums {
+		enums[camelExport(e.Name)] = true
+	}
+	ok := true
+	for i, z := range table.Fields {
+		var reason string
+		switch z.Type {
+		case "bool", "string", "int", "int16", "int32", "int64", "float32", "float64", "uuid.UUID":
+		case "time.Time":
+			// json timestamps without a time zone are not RFC 3339
+			if typ := t.Columns[i].Type.Type; typ != "timestamp with time zone" {
+				reason = fmt.Sprintf("%s is not RFC 3339 in JSON", typ)
+			}
+		default:
+			if !enums[z.Type] {
+				reason = fmt.Sprintf("type %s cannot be unmarshaled from JSON", z.Type)
+			}
+		}
+		if reason != "" {
+			xo.Warnf(ctx, "skip", t.Name+"."+z.SQLName, "no JSON preloads of the table: %s", reason)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// catalogStatements returns the statements generated for the table's
+// receiver funcs.
+func catalogStatements(table Table) []Statement {
+	if len(table.PrimaryKeys) == 0 {
+		return nil
+	}
+	stmts := []Statement{{"insert", table}}
+	if len(updateFields(table)) != 0 {
+		stmts = append(stmts, Statement{"update", table}, Statement{"upsert", table})
+	}
+	stmts = append(stmts, Statement{"delete", table})
+	if table.SoftDelete != nil {
+		stmts = append(stmts, Statement{"destroy", table})
+	}
+	if table.ValidTo != nil {
+		stmts = append(stmts, Statement{"supersede", table})
+	}
+	return stmts
+}
+
+// insertMany returns true when an InsertMany func is generated for the table.
+func insertMany(ctx context.Context, t xo.Table, table Table) bool {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	return InsertMany(ctx) && driver != "oracle" && t.Type == "table" && len(table.PrimaryKeys) != 0 && len(insertFields(table, table.Manual)) != 0
+}
+
+// convertEnum converts a xo.Enum.
+//
+// Const values are the database's sort order of the values. When the sort
+// order does not give distinct values (such as for a postgres value added
+// with BEFORE/AFTER, which has a fractional sort order), the values are
+// numbered sequentially in sort order instead. Pinned values always take
+// precedence.
+func convertEnum(ctx context.Context, e xo.Enum) (Enum, error) {
+	var vals []EnumValue
+	goName := camelExport(e.Name)
+	seen, dupe := make(map[int]bool), false
+	for _, v := range e.Values {
+		name := camelExport(strings.ToLower(v.Name))
+		if strings.HasSuffix(name, goName) && goName != name {
+			name = strings.TrimSuffix(name, goName)
+		}
+		dupe = dupe || seen[*v.ConstValue]
+		seen[*v.ConstValue] = true
+		vals = append(vals, EnumValue{
+			GoName:     name,
 			SQLName:    v.Name,
 			ConstValue: *v.ConstValue,
 		})
 	}
+	if dupe {
+		xo.Warnf(ctx, "renumber", e.Name, "sort order has duplicate values, numbering sequentially")
+		for i := range vals {
+			vals[i].ConstValue = i + 1
+		}
+	}
+	// apply pinned values
+	pinned := EnumValues(ctx)
+	for i, v := range vals {
+		if n, ok := pinned[e.Name+"."+v.SQLName]; ok {
+			vals[i].ConstValue = n
+		}
+	}
+	values := make(map[int]string)
+	for _, v := range vals {
+		if prev, ok := values[v.ConstValue]; ok {
+			return Enum{}, fmt.Errorf("enum %s: values %q and %q have the same const value %d", e.Name, prev, v.SQLName, v.ConstValue)
+		}
+		values[v.ConstValue] = v.SQLName
+	}
 	return Enum{
 		GoName:  goName,
 		SQLName: e.Name,
 		Values:  vals,
+	}, nil
+}
+
+// checkRenumber warns when the enum's const values differ from the values
+// previously generated to the output, which breaks persisted integer
+// encodings of the enum. Values pinned with --go-enum-value were changed
+// deliberately, and are not reported.
+func checkRenumber(ctx context.Context, enum Enum, dest string) {
+	if s := xo.Single(ctx); s != "" {
+		dest = s
+	}
+	buf, err := os.ReadFile(filepath.Join(xo.Out(ctx), dest))
+	if err != nil {
+		return
+	}
+	re := regexp.MustCompile(`(?m)^\s*` + enum.GoName + `(\w+) ` + enum.GoName + ` = (-?\d+)$`)
+	prev := make(map[string]int)
+	for _, m := range re.FindAllStringSubmatch(string(buf), -1) {
+		n, _ := strconv.Atoi(m[2])
+		prev[m[1]] = n
+	}
+	pinned := EnumValues(ctx)
+	for _, v := range enum.Values {
+		if _, ok := pinned[enum.SQLName+"."+v.SQLName]; ok {
+			continue
+		}
+		if n, ok := prev[v.GoName]; ok && n != v.ConstValue {
+			xo.Warnf(ctx, "renumber", enum.SQLName, "%s renumbered from %d to %d (pin with --go-enum-value %s.%s=%d)", enum.GoName+v.GoName, n, v.ConstValue, enum.SQLName, v.SQLName, n)
+		}
 	}
 }
 
@@ -593,7 +1582,7 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 	// proc params
 	var types []string
 	for _, z := range p.Params {
-		f, err := convertField(ctx, camel, z)
+		f, err := convertField(ctx, camel, "", z)
 		if err != nil {
 			return nil, err
 		}
@@ -606,7 +1595,7 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 	types = nil
 	// proc return
 	for _, z := range p.Returns {
-		f, err := convertField(ctx, camel, z)
+		f, err := convertField(ctx, camel, "", z)
 		if err != nil {
 			return nil, err
 		}
@@ -633,35 +1622,242 @@ func convertProc(ctx context.Context, overloadMap map[string][]Proc, order []str
 // convertTable converts a xo.Table to a Table.
 func convertTable(ctx context.Context, t xo.Table) (Table, error) {
 	var cols, pkCols []Field
+	var tenant *Field
 	for _, z := range t.Columns {
-		f, err := convertField(ctx, camelExport, z)
+		f, err := convertField(ctx, camelExport, t.Name, z)
 		if err != nil {
 			return Table{}, err
 		}
+		if f, err = encryptField(ctx, t.Name, z, f); err != nil {
+			return Table{}, err
+		}
+		f = bitmaskField(ctx, t.Name, f)
+		xo.Logf(ctx, "TYPE: %s.%s %s -> %s", t.Name, z.Name, sqlType(z.Type), f.Type)
 		cols = append(cols, f)
 		if z.IsPrimary {
 			pkCols = append(pkCols, f)
 		}
+		if s := TenantColumn(ctx); s != "" && s == z.Name && !z.IsPrimary {
+			tenant = &f
+		}
+	}
+	validFrom, validTo := temporalFields(ctx, cols)
+	softDelete := softDeleteField(ctx, t.Name, cols)
+	name := singularize(t.Name)
+	if name != t.Name {
+		xo.Infof(ctx, "singularize", t.Name, "singularized to %s", name)
 	}
 	return Table{
-		GoName:      camelExport(singularize(t.Name)),
+		GoName:      camelExport(name),
 		SQLName:     t.Name,
 		Fields:      cols,
 		PrimaryKeys: pkCols,
 		Manual:      t.Manual,
+		Tenant:      tenant,
+		SoftDelete:  softDelete,
+		OrderBy:     OrderBy(ctx)[t.Name],
+		ValidFrom:   validFrom,
+		ValidTo:     validTo,
 		Comment:     t.Definition,
 	}, nil
 }
 
+// convertConstraints converts the names of the table's indexes and foreign
+// keys to constants, skipping names whose constant is already used by another
+// table of the schema.
+func convertConstraints(ctx context.Context, names map[string]string, t xo.Table) []Constraint {
+	var constraints []Constraint
+	add := func(name, kind, prefix string) {
+		// sqlite_ prefixed names are reserved for sqlite's internal indexes
+		if name == "" || strings.HasPrefix(name, "sqlite_") {
+			return
+		}
+		goName := prefix + camelExport(name)
+		if prev, ok := names[goName]; ok {
+			if prev != name {
+				xo.Warnf(ctx, "collision", t.Name, "constraint %s collides with %s", name, prev)
+			}
+			return
+		}
+		names[goName] = name
+		constraints = append(constraints, Constraint{
+			GoName:  goName,
+			SQLName: name,
+			Kind:    kind,
+		})
+	}
+	for _, i := range t.Indexes {
+		switch {
+		case i.IsPrimary:
+			add(i.Name, "primary key", "Constraint")
+		case i.IsUnique:
+			add(i.Name, "unique index", "Constraint")
+		default:
+			add(i.Name, "index", "Index")
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		add(fk.Name, "foreign key", "Constraint")
+	}
+	return constraints
+}
+
+// genTests returns true when tests are generated. Tests require context funcs,
+// and their own files.
+func genTests(ctx context.Context) bool {
+	return Tests(ctx) && Context(ctx) != "disable" && xo.Single(ctx) == "" && !Append(ctx)
+}
+
+// hasTests returns true when the table has funcs to test.
+func hasTests(t xo.Table) bool {
+	if len(t.Indexes) != 0 {
+		return true
+	}
+	for _, z := range t.Columns {
+		if z.IsPrimary {
+			return true
+		}
+	}
+	return false
+}
+
+// temporalFields returns the temporal validity fields of the table's fields,
+// when both are present. The valid to field must be nullable, as a null value
+// marks the current version of a row.
+func temporalFields(ctx context.Context, fields []Field) (*Field, *Field) {
+	from, to, ok := strings.Cut(Temporal(ctx), ",")
+	if !ok {
+		return nil, nil
+	}
+	var validFrom, validTo *Field
+	for i, z := range fields {
+		switch {
+		case z.SQLName == strings.TrimSpace(from) && !z.IsPrimary && (z.Type == "time.Time" || z.Type == "sql.NullTime" || z.Type == "Time"):
+			validFrom = &fields[i]
+		case z.SQLName == strings.TrimSpace(to) && !z.IsPrimary && (z.Type == "sql.NullTime" || z.Type == "*Time"):
+			validTo = &fields[i]
+		}
+	}
+	if validFrom == nil || validTo == nil {
+		return nil, nil
+	}
+	return validFrom, validTo
+}
+
+// softDeleteField returns the soft delete field of the table's fields, if any.
+// The field must be a nullable time, as a null value marks a row that is not
+// deleted.
+func softDeleteField(ctx context.Context, table string, fields []Field) *Field {
+	s := SoftDeleteColumn(ctx)
+	for i, z := range fields {
+		switch {
+		case s == "" || z.SQLName != s:
+		case z.IsPrimary || (z.Type != "sql.NullTime" && z.Type != "*Time"):
+			xo.Warnf(ctx, "skip", table+"."+z.SQLName, "soft delete column of non-nullable time type %s", z.Type)
+		default:
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// asOfIndex returns a copy of the non-primary index of a temporal table
+// retrieving the rows valid at a time, omitting the valid from field from the
+// index fields and func name.
+func asOfIndex(index Index) (Index, bool) {
+	if index.Table.ValidFrom == nil || index.Redacted || index.IsPrimary {
+		return Index{}, false
+	}
+	var fields []Field
+	for _, z := range index.Fields {
+		if z.SQLName != index.Table.ValidFrom.SQLName {
+			fields = append(fields, z)
+		}
+	}
+	if len(fields) == 0 {
+		return Index{}, false
+	}
+	if len(fields) != len(index.Fields) {
+		index.Func = strings.Replace(index.Func, index.Table.ValidFrom.GoName, "", 1)
+	}
+	index.Func += "AsOf"
+	index.Fields, index.AsOf = fields, true
+	return index, true
+}
+
+// upsertIndex returns true when an upsert on the conflict of the index is
+// generated, for a unique index other than the primary key of a table with
+// update fields. As mysql's ON DUPLICATE KEY conflicts on any unique index, it
+// is only generated for postgres and sqlite.
+func upsertIndex(ctx context.Context, index Index) bool {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	return UpsertIndex(ctx) && (driver == "postgres" || driver == "sqlite3") &&
+		index.IsUnique && !index.IsPrimary &&
+		len(index.Table.PrimaryKeys) != 0 && len(updateFields(index.Table)) != 0
+}
+
+// indexFields returns the fields of the index's columns, without the tenant
+// field added to scope the index to the tenant.
+func indexFields(i Index) []Field {
+	if i.Scoped {
+		return i.Fields[:len(i.Fields)-1]
+	}
+	return i.Fields
+}
+
+// pagedIndexes returns copies of the non-unique index of a table with a
+// primary key retrieving a page of rows, by limit and offset, and by limit
+// after a primary key. Keyset pagination on a composite primary key compares
+// row values, and is not supported for sqlserver and oracle.
+func pagedIndexes(ctx context.Context, index Index) []Index {
+	if index.IsUnique || index.Redacted || index.AsOf || len(index.Table.PrimaryKeys) == 0 {
+		return nil
+	}
+	limit := Field{GoName: "Limit", SQLName: "limit", Type: "int", Zero: "0"}
+	paged := index
+	paged.Func += "Paged"
+	paged.Paged = true
+	paged.Page = []Field{limit, {GoName: "Offset", SQLName: "offset", Type: "int", Zero: "0"}}
+	indexes := []Index{paged}
+	if driver, _, _ := xo.DriverDbSchema(ctx); len(index.Table.PrimaryKeys) > 1 && (driver == "sqlserver" || driver == "oracle") {
+		return indexes
+	}
+	keyset := index
+	keyset.Func += "After"
+	keyset.Keyset = true
+	for _, z := range index.Table.PrimaryKeys {
+		keyset.Page = append(keyset.Page, Field{
+			GoName:  "After" + z.GoName,
+			SQLName: z.SQLName,
+			Type:    z.Type,
+			Zero:    z.Zero,
+		})
+	}
+	keyset.Page = append(keyset.Page, limit)
+	return append(indexes, keyset)
+}
+
 func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 	var fields []Field
 	for _, z := range i.Fields {
-		f, err := convertField(ctx, camelExport, z)
+		f, err := convertField(ctx, camelExport, t.SQLName, z)
 		if err != nil {
 			return Index{}, err
 		}
 		fields = append(fields, f)
 	}
+	// scope to the tenant
+	var scoped bool
+	if t.Tenant != nil {
+		var ok bool
+		for _, f := range fields {
+			ok = ok || f.SQLName == t.Tenant.SQLName
+		}
+		if !ok {
+			fields = append(fields, *t.Tenant)
+			scoped = true
+		}
+	}
 	return Index{
 		SQLName:   i.Name,
 		Func:      camelExport(i.Func),
@@ -669,6 +1865,7 @@ func convertIndex(ctx context.Context, t Table, i xo.Index) (Index, error) {
 		Fields:    fields,
 		IsUnique:  i.IsUnique,
 		IsPrimary: i.IsPrimary,
+		Scoped:    scoped,
 	}, nil
 }
 
@@ -676,7 +1873,7 @@ func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, er
 	var fields, refFields []Field
 	// convert fields
 	for _, f := range fk.Fields {
-		field, err := convertField(ctx, camelExport, f)
+		field, err := convertField(ctx, camelExport, t.SQLName, f)
 		if err != nil {
 			return ForeignKey{}, err
 		}
@@ -684,7 +1881,7 @@ func convertFKey(ctx context.Context, t Table, fk xo.ForeignKey) (ForeignKey, er
 	}
 	// convert ref fields
 	for _, f := range fk.RefFields {
-		refField, err := convertField(ctx, camelExport, f)
+		refField, err := convertField(ctx, camelExport, fk.RefTable, f)
 		if err != nil {
 			return ForeignKey{}, err
 		}
@@ -722,22 +1919,186 @@ func overloadedName(sqlTypes []string, proc Proc) string {
 	return fmt.Sprintf("%sBy%sAnd%s", proc.GoName, front, last)
 }
 
-func convertField(ctx context.Context, tf transformFunc, f xo.Field) (Field, error) {
+func convertField(ctx context.Context, tf transformFunc, table string, f xo.Field) (Field, error) {
 	typ, zero, err := goType(ctx, f.Type)
 	if err != nil {
 		return Field{}, err
 	}
+	name := tf(f.Name)
+	if s := fieldRename(ctx, table, f.Name); s != "" {
+		name = s
+	}
+	sqlTyp := f.Type
+	sqlTyp.Nullable = false
 	return Field{
 		Type:       typ,
-		GoName:     tf(f.Name),
+		GoName:     name,
 		SQLName:    f.Name,
+		SQLType:    sqlType(sqlTyp),
 		Zero:       zero,
 		IsPrimary:  f.IsPrimary,
 		IsSequence: f.IsSequence,
+		InsertOnly: table != "" && !f.IsPrimary && columnMatch(InsertOnly(ctx), table, f.Name),
+		UpdateOnly: table != "" && !f.IsPrimary && columnMatch(UpdateOnly(ctx), table, f.Name),
 		Comment:    f.Comment,
 	}, nil
 }
 
+// fieldRename returns the go field name configured for the table's column,
+// preferring the table qualified column name.
+func fieldRename(ctx context.Context, table, column string) string {
+	if table == "" {
+		return ""
+	}
+	m := Rename(ctx)
+	if s, ok := m[table+"."+column]; ok {
+		return s
+	}
+	return m[column]
+}
+
+// encryptField changes the type of the field to EncryptedString (or
+// NullEncryptedString, when nullable) when the column is configured as
+// encrypted, keeping the ciphertext out of the generated struct. The column
+// must be able to store the ciphertext: a binary column, or a text column when
+// the ciphertext is base64 encoded.
+func encryptField(ctx context.Context, table string, z xo.Field, f Field) (Field, error) {
+	if !columnMatch(Encrypt(ctx), table, f.SQLName) {
+		return f, nil
+	}
+	switch typ := strings.ToLower(z.Type.Type); {
+	case z.Type.IsArray:
+		return Field{}, fmt.Errorf("encrypted column %s.%s: array type %s can not store ciphertext", table, f.SQLName, typ)
+	case encryptTextTypes[typ] && !EncryptBase64(ctx):
+		return Field{}, fmt.Errorf("encrypted column %s.%s: text type %s requires --go-encrypt-base64", table, f.SQLName, typ)
+	case !encryptBinaryTypes[typ] && !encryptTextTypes[typ]:
+		return Field{}, fmt.Errorf("encrypted column %s.%s: type %s can not store ciphertext", table, f.SQLName, typ)
+	}
+	if z.Type.Nullable {
+		f.Type, f.Zero = "NullEncryptedString", "NullEncryptedString{}"
+	} else {
+		f.Type, f.Zero = "EncryptedString", `""`
+	}
+	return f, nil
+}
+
+// encryptBinaryTypes are the sql types able to store ciphertext.
+var encryptBinaryTypes = map[string]bool{
+	"binary":     true,
+	"blob":       true,
+	"bytea":      true,
+	"image":      true,
+	"long raw":   true,
+	"longblob":   true,
+	"mediumblob": true,
+	"raw":        true,
+	"tinyblob":   true,
+	"varbinary":  true,
+}
+
+// encryptTextTypes are the sql types able to store base64 encoded
+// ciphertext.
+var encryptTextTypes = map[string]bool{
+	"char":              true,
+	"character":         true,
+	"character varying": true,
+	"clob":              true,
+	"longtext":          true,
+	"mediumtext":        true,
+	"nchar":             true,
+	"nclob":             true,
+	"ntext":             true,
+	"nvarchar":          true,
+	"nvarchar2":         true,
+	"text":              true,
+	"tinytext":          true,
+	"varchar":           true,
+	"varchar2":          true,
+}
+
+// encryptedColumn returns the first encrypted column of the fields. Lookups
+// are not generated for the indexes and foreign keys of encrypted columns, as
+// the ciphertext of a value is usually randomized and does not match the
+// stored ciphertext.
+func encryptedColumn(ctx context.Context, table string, fields []xo.Field) (string, bool) {
+	for _, z := range fields {
+		if columnMatch(Encrypt(ctx), table, z.Name) {
+			return z.Name, true
+		}
+	}
+	return "", false
+}
+
+// bitmaskRE matches the bitmask annotation of a column comment.
+var bitmaskRE = regexp.MustCompile(`\s*@bitmask\(([^)]*)\)`)
+
+// bitmaskField changes the type of an integer field to a generated flags type
+// when the column's comment has a bitmask annotation (i.e.
+// '@bitmask(read,write,admin)'), with each listed flag a successive bit.
+func bitmaskField(ctx context.Context, table string, f Field) Field {
+	m := bitmaskRE.FindStringSubmatch(f.Comment)
+	if m == nil {
+		return f
+	}
+	f.Comment = strings.TrimSpace(bitmaskRE.ReplaceAllString(f.Comment, ""))
+	if !intTypes[f.Type] {
+		xo.Warnf(ctx, "skip", table+"."+f.SQLName, "bitmask annotation on non-integer type %s", f.Type)
+		return f
+	}
+	bitmask := &Bitmask{
+		GoName:  camelExport(singularize(table)) + f.GoName,
+		SQLName: table + "." + f.SQLName,
+		Type:    f.Type,
+	}
+	for i, name := range strings.Split(m[1], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			bitmask.Values = append(bitmask.Values, EnumValue{
+				GoName:     camelExport(strings.ToLower(name)),
+				SQLName:    name,
+				ConstValue: 1 << i,
+			})
+		}
+	}
+	f.Type, f.Bitmask = bitmask.GoName, bitmask
+	return f
+}
+
+// intTypes are the go integer types usable as a bitmask.
+var intTypes = map[string]bool{
+	"int":    true,
+	"int16":  true,
+	"int32":  true,
+	"int64":  true,
+	"uint":   true,
+	"uint16": true,
+	"uint32": true,
+	"uint64": true,
+}
+
+// redactIndex returns a copy of the index with the table's sensitive columns
+// omitted, or false when the table has no sensitive columns.
+func redactIndex(ctx context.Context, index Index) (Index, bool) {
+	var fields []Field
+	for _, z := range index.Table.Fields {
+		if !columnMatch(Sensitive(ctx), index.Table.SQLName, z.SQLName) {
+			fields = append(fields, z)
+		}
+	}
+	if len(fields) == len(index.Table.Fields) {
+		return index, false
+	}
+	index.Table.Fields = fields
+	index.Func += "Redacted"
+	index.Redacted = true
+	return index, true
+}
+
+// columnMatch returns true when the column is in v, either as the column
+// name or as the table qualified column name.
+func columnMatch(v []string, table, column string) bool {
+	return slices.Contains(v, column) || slices.Contains(v, table+"."+column)
+}
+
 func goType(ctx context.Context, typ xo.Type) (string, string, error) {
 	driver, _, schema := xo.DriverDbSchema(ctx)
 	var f func(xo.Type, string, string, string) (string, string, error)
@@ -765,40 +2126,353 @@ func goType(ctx context.Context, typ xo.Type) (string, string, error) {
 	return f(typ, schema, Int32(ctx), Uint32(ctx))
 }
 
+// sqlType returns a description of a sql type for verbose output.
+func sqlType(typ xo.Type) string {
+	s := typ.Type
+	switch {
+	case typ.Prec != 0 && typ.Scale != 0:
+		s += fmt.Sprintf("(%d,%d)", typ.Prec, typ.Scale)
+	case typ.Prec != 0:
+		s += fmt.Sprintf("(%d)", typ.Prec)
+	}
+	if typ.IsArray {
+		s += "[]"
+	}
+	if typ.Nullable {
+		s += " null"
+	}
+	return s
+}
+
+// binaryTypes are the sql types intentionally mapped to []byte, including the
+// json, xml and interval types scanned as their raw text.
+var binaryTypes = map[string]bool{
+	"binary":     true,
+	"blob":       true,
+	"bytea":      true,
+	"image":      true,
+	"interval":   true,
+	"json":       true,
+	"jsonb":      true,
+	"long raw":   true,
+	"longblob":   true,
+	"mediumblob": true,
+	"raw":        true,
+	"tinyblob":   true,
+	"varbinary":  true,
+	"xml":        true,
+}
+
+// checkTypes reports the table's fields having a go type defaulted to []byte,
+// or to a custom type for a sql type that is not an enum of the schema.
+func checkTypes(ctx context.Context, enums map[string]bool, t xo.Table, table Table) {
+	driver, _, _ := xo.DriverDbSchema(ctx)
+	for i, z := range t.Columns {
+		f := table.Fields[i]
+		switch {
+		case columnMatch(Encrypt(ctx), t.Name, z.Name):
+			continue
+		case f.Bitmask != nil:
+			continue
+		case fallbackType(z.Type, f.Type):
+		case driver != "sqlite3" && customType(enums, z.Type, f.Type):
+		default:
+			continue
+		}
+		xo.Warnf(ctx, "type", t.Name+"."+z.Name, "unmapped type %s defaulted to %s", sqlType(z.Type), f.Type)
+	}
+}
+
+// checkEnumValues checks that the pinned enum values are valid, are in range,
+// are pinned once with distinct values per enum, and are for the enum labels
+// of the set.
+func checkEnumValues(ctx context.Context, set *xo.Set) error {
+	labels := make(map[string]bool)
+	for _, schema := range set.Schemas {
+		for _, e := range schema.Enums {
+			labels[e.Name] = true
+			for _, z := range e.Values {
+				labels[e.Name+"."+z.Name] = true
+			}
+		}
+	}
+	pinned, values := make(map[string]bool), make(map[string]string)
+	v, _ := ctx.Value(EnumValueKey).([]string)
+	for _, s := range v {
+		if s == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(s, "=")
+		name = strings.TrimSpace(name)
+		enum, label, _ := strings.Cut(name, ".")
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		switch {
+		case !ok || err != nil || enum == "" || label == "":
+			return fmt.Errorf("invalid enum value %q", s)
+		case n <= 0 || n > math.MaxUint16:
+			return fmt.Errorf("enum value %q: value %d out of range 1-%d", s, n, math.MaxUint16)
+		case pinned[name]:
+			return fmt.Errorf("enum value %q: %s pinned more than once", s, name)
+		case len(set.Schemas) != 0 && !labels[enum]:
+			return fmt.Errorf("enum value %q: unknown enum %s", s, enum)
+		case len(set.Schemas) != 0 && !labels[name]:
+			return fmt.Errorf("enum value %q: enum %s has no label %s", s, enum, label)
+		}
+		key := enum + "=" + strconv.Itoa(n)
+		if prev, ok := values[key]; ok {
+			return fmt.Errorf("enum value %q: value %d already pinned to %s", s, n, prev)
+		}
+		pinned[name] = true
+		values[key] = name
+	}
+	return nil
+}
+
+// checkDBType checks that the db type does not conflict with the name of a
+// narrow db interface.
+func checkDBType(ctx context.Context) error {
+	if s := DBType(ctx); NarrowDB(ctx) && (s == "Execer" || s == "Querier" || s == "RowQuerier") {
+		return fmt.Errorf("--go-db-type %s conflicts with the --go-narrow-db interface of the same name", s)
+	}
+	return nil
+}
+
+// checkOrderBy checks that the default orders are for tables of the set.
+func checkOrderBy(ctx context.Context, set *xo.Set) error {
+	v, _ := ctx.Value(OrderByKey).([]string)
+	for _, s := range v {
+		if s == "" {
+			continue
+		}
+		table, expr, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(table) == "" || strings.TrimSpace(expr) == "" {
+			return fmt.Errorf("invalid order by %q", s)
+		}
+		var found bool
+		for _, schema := range set.Schemas {
+			for _, t := range append(schema.Tables, schema.Views...) {
+				found = found || t.Name == strings.TrimSpace(table)
+			}
+		}
+		if !found && len(set.Schemas) != 0 {
+			xo.Warnf(ctx, "skip", strings.TrimSpace(table), "order by for unknown table")
+		}
+	}
+	return nil
+}
+
+// identRE matches exported go identifiers, such as the custom go type names
+// generated for sql types without a go type mapping.
+var identRE = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+// customType returns true when the go type is the custom type fallback for a
+// sql type that is not an enum of the schema.
+func customType(enums map[string]bool, typ xo.Type, goType string) bool {
+	name := typ.Type
+	if i := strings.LastIndex(name, "."); i != -1 {
+		name = name[i+1:]
+	}
+	return !enums[name] && identRE.MatchString(goType)
+}
+
+// fallbackType returns true when the go type is the []byte fallback for a sql
+// type without a go type mapping (such as bit varying).
+func fallbackType(typ xo.Type, goType string) bool {
+	return goType == "[]byte" && !binaryTypes[strings.ToLower(typ.Type)]
+}
+
 type transformFunc func(...string) string
 
 func snake(names ...string) string {
-	return snaker.CamelToSnake(strings.Join(names, "_"))
+	return snaker.CamelToSnake(transliterate(strings.Join(names, "_")))
 }
 
 func camel(names ...string) string {
-	return snaker.ForceLowerCamelIdentifier(strings.Join(names, "_"))
+	return snaker.ForceLowerCamelIdentifier(transliterate(strings.Join(names, "_")))
 }
 
 func camelExport(names ...string) string {
-	return snaker.ForceCamelIdentifier(strings.Join(names, "_"))
+	return snaker.ForceCamelIdentifier(transliterate(strings.Join(names, "_")))
+}
+
+// identMap is the explicit mapping of database identifiers to Go names.
+var identMap = make(map[string]string)
+
+// transliterate converts s to an ASCII name suitable for use with snaker.
+//
+// Names (or "_" separated parts of names) found in the identifier map are
+// replaced first, and any remaining non-ASCII characters are transliterated
+// using the transliteration table. Characters without a transliteration are
+// converted to their Unicode code point (ie, 'U540d').
+func transliterate(s string) string {
+	if v, ok := identMap[s]; ok {
+		return v
+	}
+	ascii := true
+	for i := 0; i < len(s) && ascii; i++ {
+		ascii = s[i] < utf8.RuneSelf
+	}
+	if ascii {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	for i, part := range parts {
+		if v, ok := identMap[part]; ok {
+			parts[i] = v
+			continue
+		}
+		var sb strings.Builder
+		for _, r := range part {
+			switch v, ok := transliterations[r]; {
+			case r < utf8.RuneSelf:
+				sb.WriteRune(r)
+			case ok:
+				sb.WriteString(v)
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				fmt.Fprintf(&sb, "U%04x", r)
+			}
+		}
+		parts[i] = sb.String()
+	}
+	return strings.Join(parts, "_")
+}
+
+// transliterations is the transliteration table for common non-ASCII
+// characters.
+var transliterations = map[rune]string{
+	// latin
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "Ae", 'Å': "A", 'Æ': "Ae",
+	'Ç': "C", 'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I",
+	'Î': "I", 'Ï': "I", 'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O",
+	'Õ': "O", 'Ö': "Oe", 'Ø': "O", 'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "Ue",
+	'Ý': "Y", 'Þ': "Th", 'ß': "ss", 'à': "a", 'á': "a", 'â': "a", 'ã': "a",
+	'ä': "ae", 'å': "a", 'æ': "ae", 'ç': "c", 'è': "e", 'é': "e", 'ê': "e",
+	'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ð': "d", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "oe", 'ø': "o", 'ù': "u",
+	'ú': "u", 'û': "u", 'ü': "ue", 'ý': "y", 'þ': "th", 'ÿ': "y", 'Ā': "A",
+	'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a", 'Ć': "C", 'ć': "c",
+	'Č': "C", 'č': "c", 'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d", 'Ē': "E",
+	'ē': "e", 'Ė': "E", 'ė': "e", 'Ę': "E", 'ę': "e", 'Ě': "E", 'ě': "e",
+	'Ğ': "G", 'ğ': "g", 'Ī': "I", 'ī': "i", 'Į': "I", 'į': "i", 'İ': "I",
+	'ı': "i", 'Ķ': "K", 'ķ': "k", 'Ļ': "L", 'ļ': "l", 'Ł': "L", 'ł': "l",
+	'Ń': "N", 'ń': "n", 'Ņ': "N", 'ņ': "n", 'Ň': "N", 'ň': "n", 'Ō': "O",
+	'ō': "o", 'Ő': "O", 'ő': "o", 'Œ': "Oe", 'œ': "oe", 'Ř': "R", 'ř': "r",
+	'Ś': "S", 'ś': "s", 'Ş': "S", 'ş': "s", 'Š': "S", 'š': "s", 'Ţ': "T",
+	'ţ': "t", 'Ť': "T", 'ť': "t", 'Ū': "U", 'ū': "u", 'Ů': "U", 'ů': "u",
+	'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u", 'Ź': "Z", 'ź': "z", 'Ż': "Z",
+	'ż': "z", 'Ž': "Z", 'ž': "z",
+	// greek
+	'Α': "A", 'Β': "B", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I",
+	'Θ': "Th", 'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X",
+	'Ο': "O", 'Π': "P", 'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F",
+	'Χ': "Ch", 'Ψ': "Ps", 'Ω': "O", 'α': "a", 'β': "b", 'γ': "g", 'δ': "d",
+	'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l",
+	'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	// cyrillic
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "Yo",
+	'Ж': "Zh", 'З': "Z", 'И': "I", 'Й': "Y", 'К': "K", 'Л': "L", 'М': "M",
+	'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U",
+	'Ф': "F", 'Х': "Kh", 'Ц': "Ts", 'Ч': "Ch", 'Ш': "Sh", 'Щ': "Shch",
+	'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "Yu", 'Я': "Ya", 'а': "a",
+	'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
 }
 
 const ext = ".dbtpl.go"
 
+// testExt is the file extension of generated tests.
+const testExt = ".dbtpl_test.go"
+
+// Region markers delimiting the generated code of a file in append mode.
+const (
+	regionBegin = "// dbtpl:begin"
+	regionEnd   = "// dbtpl:end"
+)
+
+// generatedHeader is the comment of the header of generated files.
+const generatedHeader = "// Code generated by dbtpl. DO NOT EDIT."
+
+// splitRegion splits the content of a file at its marked region, returning the
+// content before the region's begin marker and after its end marker. A file
+// without a marked region is returned as before, so that the region is
+// appended to the file, unless the file was generated without a region, as
+// its generated code would then be duplicated.
+func splitRegion(data []byte) (string, string, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case regionBegin:
+			if begin != -1 {
+				return "", "", errors.New("multiple dbtpl:begin markers")
+			}
+			begin = i
+		case regionEnd:
+			if begin == -1 || end != -1 {
+				return "", "", errors.New("unexpected dbtpl:end marker")
+			}
+			end = i
+		}
+	}
+	switch {
+	case begin == -1 && strings.Contains(string(data), generatedHeader):
+		return "", "", errors.New("generated file without dbtpl:begin and dbtpl:end markers")
+	case begin == -1:
+		return string(data), "", nil
+	case end == -1:
+		return "", "", errors.New("missing dbtpl:end marker")
+	}
+	before := strings.TrimRight(strings.Join(lines[:begin], ""), "\n") + "\n"
+	return before, strings.Join(lines[end+1:], ""), nil
+}
+
 // Funcs is a set of template funcs.
 type Funcs struct {
-	driver     string
-	schema     string
-	nth        func(int) string
-	first      bool
-	pkg        string
-	tags       []string
-	imports    []string
-	conflict   string
-	custom     string
-	escSchema  bool
-	escTable   bool
-	escColumn  bool
-	fieldtag   *template.Template
-	context    string
-	inject     string
-	oracleType string
+	driver      string
+	schema      string
+	nth         func(int) string
+	first       bool
+	pkg         string
+	tags        []string
+	nolint      []string
+	maxLines    int
+	imports     []string
+	conflict    string
+	custom      string
+	escSchema   bool
+	escTable    bool
+	escColumn   bool
+	fieldtag    *template.Template
+	context     string
+	ctxpos      string
+	dbtype      string
+	narrow      bool
+	shard       bool
+	retry       bool
+	hooks       bool
+	hint        *template.Template
+	queryID     bool
+	catalog     bool
+	encrypt     bool
+	encryptB64  bool
+	cascade     bool
+	temporal    bool
+	insertMany  bool
+	checkRows   bool
+	execResult  bool
+	outbox      string
+	cdc         bool
+	notify      string
+	reconcile   bool
+	tenant      bool
+	testHelpers bool
+	goVersion   int
+	inject      string
+	oracleType  string
 	// knownTypes is the collection of known Go types.
 	knownTypes map[string]bool
 	// shorts is the collection of Go style short names for types, mainly
@@ -823,30 +2497,71 @@ func NewFuncs(ctx context.Context) (template.FuncMap, error) {
 		}
 		inject = string(buf)
 	}
-	driver, _, schema := xo.DriverDbSchema(ctx)
-	nth, err := loader.NthParam(ctx)
+	// parse query hint template
+	var hint *template.Template
+	if s := QueryHint(ctx); s != "" {
+		if hint, err = template.New("hint").Parse(s); err != nil {
+			return nil, err
+		}
+	}
+	driver, _, schema := xo.DriverDbSchema(ctx)
+	nth, err := loader.NthParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// check go version
+	version, err := goVersion(GoVersion(ctx))
 	if err != nil {
 		return nil, err
 	}
+	cdc := (CDC(ctx) || Notify(ctx) != "") && driver == "postgres"
+	if cdc && version != 0 && version < 22 {
+		return nil, errors.New("--go-cdc and --go-notify require --go-version 1.22 or later")
+	}
 	funcs := &Funcs{
-		first:      first,
-		driver:     driver,
-		schema:     schema,
-		nth:        nth,
-		pkg:        Pkg(ctx),
-		tags:       Tags(ctx),
-		imports:    Imports(ctx),
-		conflict:   Conflict(ctx),
-		custom:     Custom(ctx),
-		escSchema:  Esc(ctx, "schema"),
-		escTable:   Esc(ctx, "table"),
-		escColumn:  Esc(ctx, "column"),
-		fieldtag:   fieldtag,
-		context:    Context(ctx),
-		inject:     inject,
-		oracleType: OracleType(ctx),
-		knownTypes: KnownTypes(ctx),
-		shorts:     Shorts(ctx),
+		first:       first,
+		driver:      driver,
+		schema:      schema,
+		nth:         nth,
+		pkg:         Pkg(ctx),
+		tags:        Tags(ctx),
+		nolint:      Nolint(ctx),
+		maxLines:    MaxLines(ctx),
+		imports:     Imports(ctx),
+		conflict:    Conflict(ctx),
+		custom:      Custom(ctx),
+		escSchema:   Esc(ctx, "schema"),
+		escTable:    Esc(ctx, "table"),
+		escColumn:   Esc(ctx, "column"),
+		fieldtag:    fieldtag,
+		context:     Context(ctx),
+		ctxpos:      ContextPos(ctx),
+		dbtype:      DBType(ctx),
+		narrow:      NarrowDB(ctx),
+		shard:       Shard(ctx),
+		retry:       Retry(ctx),
+		hooks:       Hooks(ctx),
+		hint:        hint,
+		queryID:     QueryID(ctx),
+		catalog:     Catalog(ctx),
+		encrypt:     len(Encrypt(ctx)) != 0,
+		encryptB64:  EncryptBase64(ctx),
+		cascade:     Cascade(ctx),
+		temporal:    Temporal(ctx) != "",
+		insertMany:  InsertMany(ctx) && driver != "oracle",
+		checkRows:   CheckRows(ctx),
+		execResult:  ExecResult(ctx),
+		outbox:      outboxName(ctx),
+		cdc:         cdc,
+		notify:      Notify(ctx),
+		reconcile:   Reconcile(ctx),
+		tenant:      TenantColumn(ctx) != "",
+		testHelpers: TestHelpers(ctx),
+		goVersion:   version,
+		inject:      inject,
+		oracleType:  OracleType(ctx),
+		knownTypes:  KnownTypes(ctx),
+		shorts:      Shorts(ctx),
 	}
 	return funcs.FuncMap(), nil
 }
@@ -860,6 +2575,7 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"schema":  f.schemafn,
 		"pkg":     f.pkgfn,
 		"tags":    f.tagsfn,
+		"nolint":  f.nolintfn,
 		"imports": f.importsfn,
 		"inject":  f.injectfn,
 		// context
@@ -867,22 +2583,73 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"context_both":    f.context_both,
 		"context_disable": f.context_disable,
 		// func and query
-		"func_name_context":   f.func_name_context,
-		"func_name":           f.func_name_none,
-		"func_context":        f.func_context,
-		"func":                f.func_none,
-		"recv_context":        f.recv_context,
-		"recv":                f.recv_none,
-		"foreign_key_context": f.foreign_key_context,
-		"foreign_key":         f.foreign_key_none,
-		"db":                  f.db,
-		"db_prefix":           f.db_prefix,
-		"db_update":           f.db_update,
-		"db_named":            f.db_named,
-		"named":               f.named,
-		"logf":                f.logf,
-		"logf_pkeys":          f.logf_pkeys,
-		"logf_update":         f.logf_update,
+		"func_name_context":     f.func_name_context,
+		"func_name":             f.func_name_none,
+		"func_context":          f.func_context,
+		"func":                  f.func_none,
+		"recv_context":          f.recv_context,
+		"recv":                  f.recv_none,
+		"foreign_key_context":   f.foreign_key_context,
+		"foreign_key":           f.foreign_key_none,
+		"call_args":             f.call_args,
+		"db_type":               f.db_type,
+		"narrow_db":             f.narrow_db,
+		"shard":                 f.shardfn,
+		"shard_func":            f.shard_func,
+		"retry":                 f.retryfn,
+		"hooks":                 f.hooksfn,
+		"hook":                  f.hook,
+		"catalog":               f.catalogfn,
+		"encrypt":               f.encryptfn,
+		"encrypt_base64":        f.encrypt_base64,
+		"update_fields":         updateFields,
+		"insert_fields":         insertFields,
+		"insert_many_size":      f.insert_many_size,
+		"insert_many_values":    f.insert_many_values,
+		"insert_many_returning": f.insert_many_returning,
+		"colname":               f.colname,
+		"tenant":                f.tenantfn,
+		"tenant_param":          f.tenant_param,
+		"temporal_at":           f.temporal_at,
+		"page_args":             f.page_args,
+		"upsert_name":           f.upsert_name,
+		"index_fields":          indexFields,
+		"cascade":               f.cascadefn,
+		"temporal":              f.temporalfn,
+		"cascade_stmts":         f.cascade_stmts,
+		"preload_sqlstr":        f.preload_sqlstr,
+		"preload_split":         f.preload_split,
+		"preload_block":         preload_block,
+		"preload_parent":        preload_parent,
+		"preload_node":          f.preload_node,
+		"preload_node_call":     f.preload_node_call,
+		"preload_node_name":     preloadNodeName,
+		"preload_json_sqlstr":   f.preload_json_sqlstr,
+		"uow_func":              f.uow_func,
+		"insert_many":           f.insert_manyfn,
+		"outbox":                f.outboxfn,
+		"cdc":                   f.cdcfn,
+		"notify":                f.notifyfn,
+		"notify_triggers":       f.notify_triggers,
+		"reconcile":             f.reconcilefn,
+		"test_helpers":          f.test_helpers,
+		"test_schema_qualifier": f.test_schema_qualifier,
+		"test_call":             f.test_call,
+		"reconcile_equal":       f.reconcile_equal,
+		"reconcile_src":         f.reconcile_src,
+		"reconcile_tenant":      f.reconcile_tenant,
+		"go_version":            f.go_version,
+		"check_rows":            f.check_rows,
+		"exec_result":           f.exec_result,
+		"exec_op":               f.exec_op,
+		"db":                    f.db,
+		"db_prefix":             f.db_prefix,
+		"db_update":             f.db_update,
+		"db_named":              f.db_named,
+		"named":                 f.named,
+		"logf":                  f.logf,
+		"logf_pkeys":            f.logf_pkeys,
+		"logf_update":           f.logf_update,
 		// type
 		"names":        f.names,
 		"names_all":    f.names_all,
@@ -893,10 +2660,13 @@ func (f *Funcs) FuncMap() template.FuncMap {
 		"field":        f.field,
 		"short":        f.short,
 		// sqlstr funcs
-		"querystr": f.querystr,
-		"sqlstr":   f.sqlstr,
+		"querystr":    f.querystr,
+		"query_id":    f.query_id,
+		"query_info":  f.query_info,
+		"query_infos": f.query_infos,
+		"sqlstr":      f.sqlstr,
 		// helpers
-		"check_name": checkName,
+		"check_name": f.checkName,
 		"eval":       eval,
 	}
 }
@@ -955,6 +2725,14 @@ func (f *Funcs) tagsfn() []string {
 	return f.tags
 }
 
+// nolintfn returns the nolint directive.
+func (f *Funcs) nolintfn() string {
+	if len(f.nolint) == 0 {
+		return ""
+	}
+	return "//nolint:" + strings.Join(f.nolint, ",")
+}
+
 // importsfn returns the imports.
 func (f *Funcs) importsfn() []PackageImport {
 	var imports []PackageImport
@@ -986,6 +2764,649 @@ func (f *Funcs) context_disable() bool {
 	return f.context == "disable"
 }
 
+// db_type returns the name of the DB interface.
+func (f *Funcs) db_type() string {
+	return f.dbtype
+}
+
+// narrow_db returns true when narrow db interfaces are enabled.
+func (f *Funcs) narrow_db() bool {
+	return f.narrow
+}
+
+// shardfn returns true when ShardRouter generation is enabled.
+func (f *Funcs) shardfn() bool {
+	return f.shard
+}
+
+// retryfn returns true when retry policy generation is enabled.
+func (f *Funcs) retryfn() bool {
+	return f.retry
+}
+
+// hooksfn returns true when Before and After hook generation is enabled.
+func (f *Funcs) hooksfn() bool {
+	return f.hooks
+}
+
+// hook generates a call to the named hook (BeforeInsert, AfterInsert, ...)
+// when implemented by the table's type, returning the hook's error. The call is
+// indented by depth tabs (default 1). Returns an empty string when hooks are
+// not enabled.
+func (f *Funcs) hook(name string, t Table, depth ...int) string {
+	if !f.hooks {
+		return ""
+	}
+	var ctx string
+	if f.contextfn() {
+		ctx = "ctx"
+	}
+	indent := "\t"
+	if len(depth) != 0 {
+		indent = strings.Repeat("\t", depth[0])
+	}
+	op := strings.TrimPrefix(strings.TrimPrefix(name, "Before"), "After")
+	s := fmt.Sprintf("\n\t// %s hook\n\tif h, ok := any(%s).(%sHook); ok {\n\t\tif err := h.%s(%s); err != nil {\n\t\t\treturn logerror(err)\n\t\t}\n\t}",
+		strings.ToLower(strings.TrimSuffix(name, op)+" "+op), f.short(t), name, name, ctx)
+	return strings.ReplaceAll(s, "\n\t", "\n"+indent)
+}
+
+// encryptfn returns true when encrypted columns are configured.
+func (f *Funcs) encryptfn() bool {
+	return f.encrypt
+}
+
+// encrypt_base64 returns true when encrypted columns are base64 encoded.
+func (f *Funcs) encrypt_base64() bool {
+	return f.encryptB64
+}
+
+// check_rows returns true when Update and Delete should check the number of
+// affected rows.
+func (f *Funcs) check_rows() bool {
+	return f.checkRows
+}
+
+// exec_result returns true when custom exec queries return ExecResult.
+func (f *Funcs) exec_result() bool {
+	return f.execResult
+}
+
+// exec_op returns the statement type (i.e. INSERT, UPDATE, DELETE) of the
+// custom exec query. The statement type of a query with common table
+// expressions (WITH ...) is the type of its main statement.
+func (f *Funcs) exec_op(q Query) string {
+	return stmtOp(strings.Join(q.Query, " "))
+}
+
+// stmtOp returns the upper cased first keyword of the sql statement, skipping
+// the common table expressions of a leading WITH clause.
+func stmtOp(sqlstr string) string {
+	with, depth, start := false, 0, -1
+	for i := 0; i <= len(sqlstr); i++ {
+		var c byte
+		if i < len(sqlstr) {
+			c = sqlstr[i]
+		}
+		if c == '_' || '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 && depth == 0 {
+			word := strings.ToUpper(sqlstr[start:i])
+			switch {
+			case !with && word == "WITH":
+				with = true
+			case !with:
+				return word
+			case word == "SELECT" || word == "INSERT" || word == "UPDATE" || word == "DELETE" || word == "MERGE":
+				return word
+			}
+		}
+		start = -1
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"', '`':
+			// skip quoted strings and identifiers
+			if j := strings.IndexByte(sqlstr[i+1:], c); j != -1 {
+				i += j + 1
+			}
+		}
+	}
+	return ""
+}
+
+// uow_func builds a UnitOfWork func definition buffering the op (Insert,
+// Update, or Delete) for the table.
+func (f *Funcs) uow_func(op string, t Table) string {
+	p := []string{f.short(t) + " *" + t.GoName}
+	if t.Tenant != nil && op != "Insert" {
+		p = append(p, f.param(*t.Tenant, true))
+	}
+	return fmt.Sprintf("func (uow *UnitOfWork) %s%s(%s)", op, t.GoName, strings.Join(p, ", "))
+}
+
+// outboxName returns the Go name of the outbox table from the context.
+func outboxName(ctx context.Context) string {
+	if s := Outbox(ctx); s != "" {
+		return camelExport(singularize(s))
+	}
+	return ""
+}
+
+// outboxfn returns the Go name of the outbox table, or an empty string when
+// outbox generation is disabled.
+func (f *Funcs) outboxfn() string {
+	return f.outbox
+}
+
+// cdcfn returns true when change data capture decoder generation is enabled.
+func (f *Funcs) cdcfn() bool {
+	return f.cdc
+}
+
+// notifyfn returns the NOTIFY channel.
+func (f *Funcs) notifyfn() string {
+	return f.notify
+}
+
+// notify_triggers generates the SQL creating the trigger func notifying the
+// channel with a ChangeEvent payload, and the triggers calling it for the
+// tables.
+func (f *Funcs) notify_triggers(tables []Table) string {
+	fn := f.schemafn(f.notify + "_notify")
+	lines := []string{
+		"CREATE OR REPLACE FUNCTION " + fn + "() RETURNS trigger AS $$",
+		"BEGIN",
+		"  PERFORM pg_notify('" + strings.ReplaceAll(f.notify, "'", "''") + "', json_build_object(",
+		"    'op', lower(TG_OP),",
+		"    'schema', TG_TABLE_SCHEMA,",
+		"    'table', TG_TABLE_NAME,",
+		"    'old', CASE WHEN TG_OP <> 'INSERT' THEN to_jsonb(OLD) END,",
+		"    'new', CASE WHEN TG_OP <> 'DELETE' THEN to_jsonb(NEW) END",
+		"  )::text);",
+		"  RETURN NULL;",
+		"END;",
+		"$$ LANGUAGE plpgsql;",
+	}
+	for _, t := range tables {
+		name, table := t.SQLName+"_"+f.notify, f.schemafn(t.SQLName)
+		lines = append(lines,
+			"",
+			"DROP TRIGGER IF EXISTS "+name+" ON "+table+";",
+			"CREATE TRIGGER "+name+" AFTER INSERT OR UPDATE OR DELETE ON "+table,
+			"  FOR EACH ROW EXECUTE FUNCTION "+fn+"();",
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reconcilable returns true when the table's rows can be reconciled, requiring
+// an upsert, an update and primary keys ordered the same by the database and
+// Go.
+func reconcilable(t Table) bool {
+	if len(t.PrimaryKeys) == 0 || len(updateFields(t)) == 0 {
+		return false
+	}
+	for _, z := range t.PrimaryKeys {
+		switch z.Type {
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+			"float32", "float64", "string":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// reconcilefn returns true when reconcile generation is enabled.
+func (f *Funcs) reconcilefn() bool {
+	return f.reconcile
+}
+
+// reconcile_src returns the interface type of the src param of Reconcile
+// funcs, which only queries rows.
+func (f *Funcs) reconcile_src() string {
+	if f.narrow {
+		return "Querier"
+	}
+	return f.dbtype
+}
+
+// reconcile_tenant returns the declaration of the tenant param of Reconcile
+// funcs, or an empty string when the table is not tenant scoped.
+func (f *Funcs) reconcile_tenant(t Table) string {
+	if t.Tenant == nil {
+		return ""
+	}
+	return f.param(*t.Tenant, true)
+}
+
+// test_helpers returns true when test helper generation is enabled.
+func (f *Funcs) test_helpers() bool {
+	return f.testHelpers
+}
+
+// test_schema_qualifier returns the Go string of the schema qualifier of the
+// names in the generated queries, that the test helpers replace with the test
+// schema.
+func (f *Funcs) test_schema_qualifier() string {
+	s := f.schema
+	if f.escSchema {
+		s = escfn(s)
+	}
+	return strconv.Quote(s + ".")
+}
+
+// test_call generates a call of the index func, or of the table's method, with
+// ctx, db and the zero values of the params.
+func (f *Funcs) test_call(v any, z ...any) string {
+	var name string
+	var zeros []string
+	switch x := v.(type) {
+	case Index:
+		name = f.func_name_context(x)
+		for _, field := range x.Fields {
+			zeros = append(zeros, f.zero(field))
+		}
+		if x.AsOf {
+			zeros = append(zeros, "time.Time{}")
+		}
+		for _, field := range x.Page {
+			zeros = append(zeros, f.zero(field))
+		}
+	case string:
+		name = f.func_name_context(x)
+		if t, ok := z[0].(Table); ok && t.Tenant != nil && (x == "Update" || x == "Upsert" || x == "Delete" || x == "Destroy") {
+			zeros = append(zeros, f.zero(*t.Tenant))
+		}
+	default:
+		return fmt.Sprintf("[[ UNSUPPORTED TYPE 36: %T ]]", v)
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(f.withContext("ctx", append([]string{"db"}, zeros...)), ", "))
+}
+
+// reconcile_equal generates the expression comparing the field of the rows a
+// and b.
+func (f *Funcs) reconcile_equal(z Field, a, b string) string {
+	x, y := a+"."+z.GoName, b+"."+z.GoName
+	switch typ := z.Type; {
+	case typ == "time.Time":
+		return x + ".Equal(" + y + ")"
+	case typ == "Time":
+		return x + ".Time().Equal(" + y + ".Time())"
+	case typ == "sql.NullTime":
+		return x + ".Valid == " + y + ".Valid && " + x + ".Time.Equal(" + y + ".Time)"
+	case typ == "[]byte" || typ == "json.RawMessage":
+		return "bytes.Equal(" + x + ", " + y + ")"
+	case strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "map[") || strings.Contains(typ, "Array"):
+		return "reflect.DeepEqual(" + x + ", " + y + ")"
+	}
+	return x + " == " + y
+}
+
+// go_version returns true when the generated code can use features of go
+// 1.minor.
+func (f *Funcs) go_version(minor int) bool {
+	return f.goVersion == 0 || minor <= f.goVersion
+}
+
+// temporalfn returns true when temporal validity columns are configured.
+func (f *Funcs) temporalfn() bool {
+	return f.temporal
+}
+
+// cascadefn returns true when cascading delete generation is enabled.
+func (f *Funcs) cascadefn() bool {
+	return f.cascade
+}
+
+// cascade_stmts generates the statements of the cascading delete, with
+// dependent rows deleted first, as a list of Go strings.
+func (f *Funcs) cascade_stmts(c CascadeDelete) string {
+	return "`" + strings.Join(f.cascadeSQL(c), "`,\n\t\t`") + "`,"
+}
+
+// cascadeSQL builds the statements of the cascading delete, deleting the
+// dependent rows first. As with the Delete funcs, the rows of a soft delete
+// table are soft deleted with an UPDATE setting the soft delete column of the
+// rows not already deleted.
+func (f *Funcs) cascadeSQL(c CascadeDelete) []string {
+	var stmts []string
+	for _, path := range c.Paths {
+		step := path[len(path)-1]
+		stmts = append(stmts, f.cascadeStmt(step.Table, step.SoftDelete, f.pathCond(c.Table, path)))
+	}
+	return append(stmts, f.cascadeStmt(c.Table.SQLName, softDeleteName(c.Table), f.pathCond(c.Table, nil)))
+}
+
+// cascadeStmt builds the statement deleting the rows of the table matching
+// cond, soft deleting the rows when the table has a soft delete column.
+func (f *Funcs) cascadeStmt(table, softDelete, cond string) string {
+	if softDelete == "" {
+		return "DELETE FROM " + f.schemafn(table) + " WHERE " + cond
+	}
+	col := f.colname(Field{SQLName: softDelete})
+	return "UPDATE " + f.schemafn(table) + " SET " + col + " = CURRENT_TIMESTAMP WHERE " + cond + " AND " + col + " IS NULL"
+}
+
+// pathCond builds the WHERE condition selecting the rows at the end of the
+// path of foreign keys from the row of table t with the primary key params.
+// When t is tenant scoped, the row must also be of the tenant param, so that
+// only the rows depending on a row of the tenant are selected, and the rows of
+// each tenant scoped table along the path must be of the tenant param too.
+func (f *Funcs) pathCond(t Table, path []CascadeStep) string {
+	var pk []string
+	for i, z := range t.PrimaryKeys {
+		pk = append(pk, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
+	}
+	if t.Tenant != nil {
+		pk = append(pk, fmt.Sprintf("%s = %s", f.colname(*t.Tenant), f.nth(len(t.PrimaryKeys))))
+	}
+	cond := strings.Join(pk, " AND ")
+	for i, step := range path {
+		col, ref := f.colname(Field{SQLName: step.Field}), f.colname(Field{SQLName: step.RefField})
+		if i == 0 && len(pk) == 1 && t.PrimaryKeys[0].SQLName == step.RefField {
+			cond = fmt.Sprintf("%s = %s", col, f.nth(0))
+		} else {
+			cond = fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)", col, ref, f.schemafn(step.RefTable), cond)
+		}
+		if t.Tenant != nil && step.Tenant != "" {
+			cond += fmt.Sprintf(" AND %s = %s", f.colname(Field{SQLName: step.Tenant}), f.nth(len(t.PrimaryKeys)))
+		}
+	}
+	return cond
+}
+
+// preload_json_sqlstr builds the single SELECT query of the preload, building
+// the graph as JSON.
+func (f *Funcs) preload_json_sqlstr(p Preload) (string, error) {
+	return f.sqlconst("preload_json", p, f.preloadJSONLines(p), "\t")
+}
+
+// preloadJSONLines builds the lines of the single SELECT query of the preload.
+func (f *Funcs) preloadJSONLines(p Preload) []string {
+	var pk []string
+	for i, z := range p.Table.PrimaryKeys {
+		pk = append(pk, fmt.Sprintf("t0.%s = %s", f.colname(z), f.nth(i)))
+	}
+	if t := p.Table; t.Tenant != nil {
+		pk = append(pk, fmt.Sprintf("t0.%s = %s", f.colname(*t.Tenant), f.nth(len(t.PrimaryKeys))))
+	}
+	if t := p.Table; t.SoftDelete != nil {
+		pk = append(pk, fmt.Sprintf("t0.%s IS NULL", f.colname(*t.SoftDelete)))
+	}
+	return []string{
+		"SELECT " + f.jsonNode(p, 0) + " ",
+		"FROM " + f.schemafn(p.Table.SQLName) + " t0 ",
+		"WHERE " + strings.Join(pk, " AND "),
+	}
+}
+
+// jsonNode builds the JSON object of the row of the preload node i, including
+// the JSON arrays of its relations.
+func (f *Funcs) jsonNode(p Preload, i int) string {
+	var rels []string
+	for j, n := range p.Nodes {
+		if n.Parent != i {
+			continue
+		}
+		var filter, orderBy string
+		if p.Table.Tenant != nil && n.Table.Tenant != nil {
+			filter = fmt.Sprintf(" AND t%d.%s = %s", j, f.colname(*n.Table.Tenant), f.nth(len(p.Table.PrimaryKeys)))
+		}
+		if n.Table.SoftDelete != nil {
+			filter += fmt.Sprintf(" AND t%d.%s IS NULL", j, f.colname(*n.Table.SoftDelete))
+		}
+		if n.Table.OrderBy != "" {
+			orderBy = " ORDER BY " + n.Table.OrderBy
+		}
+		rels = append(rels, fmt.Sprintf(
+			"'%s', COALESCE((SELECT jsonb_agg(%s%s) FROM %s t%d WHERE t%d.%s = t%d.%s%s), '[]')",
+			n.Relation.Key, f.jsonNode(p, j), orderBy, f.schemafn(n.Table.SQLName),
+			j, j, f.colname(n.Relation.Field), i, f.colname(n.Relation.RefField), filter,
+		))
+	}
+	if len(rels) == 0 {
+		return fmt.Sprintf("to_jsonb(t%d)", i)
+	}
+	return fmt.Sprintf("to_jsonb(t%d) || jsonb_build_object(%s)", i, strings.Join(rels, ", "))
+}
+
+// preload_sqlstr builds the SELECT query of the preload node.
+func (f *Funcs) preload_sqlstr(p Preload, n PreloadNode) (string, error) {
+	return f.sqlconst("preload", PreloadBlock{Preload: p, Node: n}, f.preloadLines(p, n), "\t\t")
+}
+
+// preload_split returns true when the Load graph func of the preload exceeds
+// the max lines, and the scan blocks of its nodes are split into funcs.
+func (f *Funcs) preload_split(p Preload) bool {
+	if f.maxLines <= 0 {
+		return false
+	}
+	// lines of the scan blocks in the preload template, and the return
+	n := 1
+	for i, z := range p.Nodes {
+		n += 27 + len(f.preloadLines(p, z))
+		if i != 0 {
+			n += 4
+		}
+	}
+	return n > f.maxLines
+}
+
+// PreloadBlock is the scan block of a preload node.
+type PreloadBlock struct {
+	Preload Preload
+	Index   int
+	Node    PreloadNode
+}
+
+// preload_block returns the scan block of the preload's i-th node.
+func preload_block(p Preload, i int) PreloadBlock {
+	return PreloadBlock{
+		Preload: p,
+		Index:   i,
+		Node:    p.Nodes[i],
+	}
+}
+
+// preload_parent returns true when the preload's i-th node is the parent of
+// other nodes.
+func preload_parent(p Preload, i int) bool {
+	for _, z := range p.Nodes {
+		if z.Parent == i {
+			return true
+		}
+	}
+	return false
+}
+
+// preload_node generates the signature of the func of the preload's i-th node
+// split from the Load graph func.
+func (f *Funcs) preload_node(p Preload, i int) string {
+	n := p.Nodes[i]
+	params := []string{"db " + f.dbIface(Table{}, p)}
+	if i != 0 {
+		params = append(params, fmt.Sprintf("g%d []*%sGraph", n.Parent, n.ParentTable.GoName))
+	}
+	params = append(params, f.params(p.Table.PrimaryKeys, true))
+	if p.Table.Tenant != nil {
+		params = append(params, f.param(*p.Table.Tenant, true))
+	}
+	if f.contextfn() {
+		params = f.withContext("ctx context.Context", params)
+	}
+	return fmt.Sprintf("func %s(%s) ([]*%sGraph, error)", preloadNodeName(p, i), strings.Join(params, ", "), n.Table.GoName)
+}
+
+// preload_node_call generates the call of the func of the preload's i-th
+// node.
+func (f *Funcs) preload_node_call(p Preload, i int) string {
+	args := []string{"db"}
+	if i != 0 {
+		args = append(args, fmt.Sprintf("g%d", p.Nodes[i].Parent))
+	}
+	args = append(args, f.params(p.Table.PrimaryKeys, false))
+	if p.Table.Tenant != nil {
+		args = append(args, f.param(*p.Table.Tenant, false))
+	}
+	if f.contextfn() {
+		args = f.withContext("ctx", args)
+	}
+	return preloadNodeName(p, i) + "(" + strings.Join(args, ", ") + ")"
+}
+
+// preloadNodeName returns the name of the func of the preload's i-th node.
+func preloadNodeName(p Preload, i int) string {
+	return "l" + strings.TrimPrefix(p.Func, "L") + "Node" + strconv.Itoa(i)
+}
+
+// preloadLines builds the lines of the SELECT query of the preload node.
+func (f *Funcs) preloadLines(p Preload, n PreloadNode) []string {
+	var fields []string
+	for _, z := range n.Table.Fields {
+		fields = append(fields, f.colname(z))
+	}
+	cond := f.pathCond(p.Table, n.Path)
+	// omit soft deleted rows
+	if n.Table.SoftDelete != nil {
+		cond += " AND " + f.colname(*n.Table.SoftDelete) + " IS NULL"
+	}
+	lines := []string{
+		"SELECT " + strings.Join(fields, ", ") + " ",
+		"FROM " + f.schemafn(n.Table.SQLName) + " ",
+		"WHERE " + cond,
+	}
+	if n.Table.OrderBy != "" {
+		lines[2] += " "
+		lines = append(lines, "ORDER BY "+n.Table.OrderBy)
+	}
+	return lines
+}
+
+// temporal_at returns the expression converting the time at to the type of
+// the table's valid from field.
+func (f *Funcs) temporal_at(t Table) string {
+	switch t.ValidFrom.Type {
+	case "sql.NullTime":
+		return "sql.NullTime{Time: at, Valid: true}"
+	case "Time":
+		return "NewTime(at)"
+	}
+	return "at"
+}
+
+// page_args returns the names of the params of a paged index func in the order
+// of the statement's bind params. Oracle binds by position, and its offset
+// precedes the limit.
+func (f *Funcs) page_args(i Index) string {
+	page := i.Page
+	if i.Paged && f.driver == "oracle" {
+		page = []Field{page[1], page[0]}
+	}
+	return f.params(append(append([]Field{}, i.Fields...), page...), false)
+}
+
+// tenantfn returns true when a tenant column is configured.
+func (f *Funcs) tenantfn() bool {
+	return f.tenant
+}
+
+// tenant_param returns the name of the tenant param for the table, or an empty
+// string when the table is not tenant scoped.
+func (f *Funcs) tenant_param(t Table) string {
+	if t.Tenant == nil {
+		return ""
+	}
+	return f.param(*t.Tenant, false)
+}
+
+// catalogfn returns true when query catalog generation is enabled.
+func (f *Funcs) catalogfn() bool {
+	return f.catalog
+}
+
+// shard_func generates a ShardRouter method signature for the index.
+func (f *Funcs) shard_func(i Index) string {
+	p := []string{f.params(i.Fields, true)}
+	if f.contextfn() {
+		p = f.withContext("ctx context.Context", p)
+	}
+	rt := "*" + i.Table.GoName
+	if !i.IsUnique {
+		rt = "[]" + rt
+	}
+	return fmt.Sprintf("func (r *ShardRouter) %s(%s) (%s, error)", f.func_name_context(i), strings.Join(p, ", "), rt)
+}
+
+// dbIface returns the interface type used for the db param of the func
+// generated for v. When narrow db interfaces are enabled, this is the
+// narrowest interface providing the methods used by the func.
+func (f *Funcs) dbIface(t Table, v any) string {
+	if !f.narrow {
+		return f.dbtype
+	}
+	switch x := v.(type) {
+	case Query:
+		switch {
+		case x.Exec:
+			return "Execer"
+		case x.Flat || x.One:
+			return "RowQuerier"
+		}
+		return "Querier"
+	case Proc:
+		if x.Void || (x.Type == "procedure" && (f.driver == "sqlserver" || f.driver == "oracle")) {
+			return "Execer"
+		}
+		return "RowQuerier"
+	case Index:
+		if x.IsUnique {
+			return "RowQuerier"
+		}
+		return "Querier"
+	case ForeignKey:
+		return "RowQuerier"
+	case CascadeDelete:
+		return "Execer"
+	case Preload:
+		return "Querier"
+	case string:
+		switch x {
+		case "Insert":
+			switch {
+			case t.Manual:
+				return "Execer"
+			case f.driver == "postgres":
+				return "RowQuerier"
+			case f.driver == "sqlserver":
+				return "Querier"
+			}
+			return "Execer"
+		case "Upsert":
+			// the tenant of an upserted mysql row is checked with a query
+			if f.driver == "mysql" && t.Tenant != nil {
+				return f.dbtype
+			}
+			return "Execer"
+		case "Update", "Delete", "Destroy":
+			return "Execer"
+		}
+		// the upsert by index returns the primary key of the row
+		if strings.HasPrefix(x, "UpsertBy") {
+			return "RowQuerier"
+		}
+	}
+	return f.dbtype
+}
+
 // injectfn returns the injected content provided from args.
 func (f *Funcs) injectfn() string {
 	return f.inject
@@ -1010,6 +3431,10 @@ func (f *Funcs) func_name_none(v any) string {
 		return n
 	case Index:
 		return x.Func
+	case CascadeDelete:
+		return x.Func
+	case Preload:
+		return x.Func
 	}
 	return fmt.Sprintf("[[ UNSUPPORTED TYPE 1: %T ]]", v)
 }
@@ -1033,6 +3458,10 @@ func (f *Funcs) func_name_context(v any) string {
 		return nameContext(f.context_both(), n)
 	case Index:
 		return nameContext(f.context_both(), x.Func)
+	case CascadeDelete:
+		return nameContext(f.context_both(), x.Func)
+	case Preload:
+		return nameContext(f.context_both(), x.Func)
 	}
 	return fmt.Sprintf("[[ UNSUPPORTED TYPE 2: %T ]]", v)
 }
@@ -1040,10 +3469,7 @@ func (f *Funcs) func_name_context(v any) string {
 // funcfn builds a func definition.
 func (f *Funcs) funcfn(name string, context bool, v any) string {
 	var p, r []string
-	if context {
-		p = append(p, "ctx context.Context")
-	}
-	p = append(p, "db DB")
+	p = append(p, "db "+f.dbIface(Table{}, v))
 	switch x := v.(type) {
 	case Query:
 		// params
@@ -1052,6 +3478,8 @@ func (f *Funcs) funcfn(name string, context bool, v any) string {
 		}
 		// returns
 		switch {
+		case x.Exec && f.execResult:
+			r = append(r, "ExecResult")
 		case x.Exec:
 			r = append(r, "sql.Result")
 		case x.Flat:
@@ -1075,16 +3503,39 @@ func (f *Funcs) funcfn(name string, context bool, v any) string {
 	case Index:
 		// params
 		p = append(p, f.params(x.Fields, true))
+		if x.AsOf {
+			p = append(p, "at time.Time")
+		}
+		if len(x.Page) != 0 {
+			p = append(p, f.params(x.Page, true))
+		}
 		// returns
 		rt := "*" + x.Table.GoName
 		if !x.IsUnique {
 			rt = "[]" + rt
 		}
 		r = append(r, rt)
+	case CascadeDelete:
+		// params
+		p = append(p, f.params(x.Table.PrimaryKeys, true))
+		if x.Table.Tenant != nil {
+			p = append(p, f.param(*x.Table.Tenant, true))
+		}
+	case Preload:
+		// params
+		p = append(p, f.params(x.Table.PrimaryKeys, true))
+		if x.Table.Tenant != nil {
+			p = append(p, f.param(*x.Table.Tenant, true))
+		}
+		// returns
+		r = append(r, "*"+x.Table.GoName+"Graph")
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 3: %T ]]", v)
 	}
 	r = append(r, "error")
+	if context {
+		p = f.withContext("ctx context.Context", p)
+	}
 	return fmt.Sprintf("func %s(%s) (%s)", name, strings.Join(p, ", "), strings.Join(r, ", "))
 }
 
@@ -1104,10 +3555,19 @@ func (f *Funcs) recv(name string, context bool, t Table, v any) string {
 	short := f.short(t)
 	var p, r []string
 	// determine params and return type
+	p = append(p, "db "+f.dbIface(t, v))
+	if s, _ := v.(string); t.Tenant != nil && (s == "Update" || s == "Save" || s == "Upsert" || strings.HasPrefix(s, "UpsertBy") || s == "Delete" || s == "Destroy") {
+		p = append(p, f.param(*t.Tenant, true))
+	}
+	if s, _ := v.(string); s == "Supersede" {
+		p = append(p, "at time.Time")
+	}
+	if s, _ := v.(string); s == "InsertWithOutbox" {
+		p = append(p, "event *"+f.outbox)
+	}
 	if context {
-		p = append(p, "ctx context.Context")
+		p = f.withContext("ctx context.Context", p)
 	}
-	p = append(p, "db DB")
 	switch x := v.(type) {
 	case ForeignKey:
 		r = append(r, "*"+x.RefTable)
@@ -1138,9 +3598,6 @@ func (f *Funcs) recv_none(typ any, v any) string {
 func (f *Funcs) foreign_key_context(v any) string {
 	var name string
 	var p []string
-	if f.contextfn() {
-		p = append(p, "ctx")
-	}
 	switch x := v.(type) {
 	case ForeignKey:
 		name = x.RefFunc
@@ -1152,6 +3609,9 @@ func (f *Funcs) foreign_key_context(v any) string {
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 6: %T ]]", v)
 	}
+	if f.contextfn() {
+		p = f.withContext("ctx", p)
+	}
 	return fmt.Sprintf("%s(%s)", name, strings.Join(p, ", "))
 }
 
@@ -1160,14 +3620,39 @@ func (f *Funcs) foreign_key_none(v any) string {
 	var p []string
 	switch x := v.(type) {
 	case ForeignKey:
-		name = x.RefFunc
-		p = append(p, "context.Background()", "db", f.convertTypes(x))
+		name = x.RefFunc + "Context"
+		p = f.withContext("context.Background()", []string{"db", f.convertTypes(x)})
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 7: %T ]]", v)
 	}
 	return fmt.Sprintf("%s(%s)", name, strings.Join(p, ", "))
 }
 
+// call_args generates a list of all names for a call to a generated func,
+// placing ctx first or last as determined by the context position.
+func (f *Funcs) call_args(ctx string, z ...any) string {
+	var p []string
+	if s := f.names_all("", z...); s != "" {
+		p = append(p, s)
+	}
+	return strings.Join(f.withContext(ctx, p), ", ")
+}
+
+// withContext adds ctx to the params, either first or last as determined by
+// the context position.
+func (f *Funcs) withContext(ctx string, p []string) []string {
+	var v []string
+	for _, s := range p {
+		if s != "" {
+			v = append(v, s)
+		}
+	}
+	if f.ctxpos == "last" {
+		return append(v, ctx)
+	}
+	return append([]string{ctx}, v...)
+}
+
 // db generates a db.<name>Context(ctx, sqlstr, ...)
 func (f *Funcs) db(name string, v ...any) string {
 	// params
@@ -1193,12 +3678,10 @@ func (f *Funcs) db_prefix(name string, skip bool, vs ...any) string {
 			params = append(params, x)
 		case Table:
 			prefix = f.short(x.GoName) + "."
-			// skip primary keys
-			if skip {
-				for _, field := range x.Fields {
-					if field.IsSequence {
-						ignore = append(ignore, field.GoName)
-					}
+			for _, field := range x.Fields {
+				// skip primary keys and update only fields
+				if (skip && field.IsSequence) || field.UpdateOnly {
+					ignore = append(ignore, field.GoName)
 				}
 			}
 			p := f.names_ignore(prefix, v, ignore...)
@@ -1217,14 +3700,14 @@ func (f *Funcs) db_prefix(name string, skip bool, vs ...any) string {
 // db_update generates a db.<name>Context(ctx, sqlstr, regularparams,
 // primaryparams)
 func (f *Funcs) db_update(name string, v any) string {
-	var ignore, p []string
+	var p []string
 	switch x := v.(type) {
 	case Table:
 		prefix := f.short(x.GoName) + "."
-		for _, pk := range x.PrimaryKeys {
-			ignore = append(ignore, pk.GoName)
+		p = append(p, f.names(prefix, updateFields(x)), f.names(prefix, x.PrimaryKeys))
+		if x.Tenant != nil {
+			p = append(p, f.tenant_param(x))
 		}
-		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, x.PrimaryKeys))
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 9: %T ]]", v)
 	}
@@ -1237,10 +3720,10 @@ func (f *Funcs) db_named(name string, v any) string {
 	switch x := v.(type) {
 	case Proc:
 		for _, z := range x.Params {
-			p = append(p, f.named(z.SQLName, z.GoName, false))
+			p = append(p, f.named(z.SQLName, f.param(z, false), false))
 		}
 		for _, z := range x.Returns {
-			p = append(p, f.named(z.SQLName, "&"+z.GoName, true))
+			p = append(p, f.named(z.SQLName, "&"+f.checkName(z.GoName), true))
 		}
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 10: %T ]]", v)
@@ -1263,6 +3746,9 @@ func (f *Funcs) logf_pkeys(v any) string {
 	switch x := v.(type) {
 	case Table:
 		p = append(p, f.names(f.short(x.GoName)+".", x.PrimaryKeys))
+		if x.Tenant != nil {
+			p = append(p, f.tenant_param(x))
+		}
 	}
 	return fmt.Sprintf("logf(%s)", strings.Join(p, ", "))
 }
@@ -1288,6 +3774,12 @@ func (f *Funcs) logf(v any, ignore ...any) string {
 	// add fields
 	switch x := v.(type) {
 	case Table:
+		// update only fields are not inserted
+		for _, z := range x.Fields {
+			if z.UpdateOnly {
+				ignoreNames = append(ignoreNames, z.GoName)
+			}
+		}
 		p = append(p, f.names_ignore(f.short(x.GoName)+".", x, ignoreNames...))
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 12: %T ]]", v)
@@ -1296,15 +3788,14 @@ func (f *Funcs) logf(v any, ignore ...any) string {
 }
 
 func (f *Funcs) logf_update(v any) string {
-	var ignore []string
 	p := []string{"sqlstr"}
 	switch x := v.(type) {
 	case Table:
 		prefix := f.short(x.GoName) + "."
-		for _, pk := range x.PrimaryKeys {
-			ignore = append(ignore, pk.GoName)
+		p = append(p, f.names(prefix, updateFields(x)), f.names(prefix, x.PrimaryKeys))
+		if x.Tenant != nil {
+			p = append(p, f.tenant_param(x))
 		}
-		p = append(p, f.names_ignore(prefix, x, ignore...), f.names(prefix, x.PrimaryKeys))
 	default:
 		return fmt.Sprintf("[[ UNSUPPORTED TYPE 13: %T ]]", v)
 	}
@@ -1317,7 +3808,9 @@ func (f *Funcs) namesfn(all bool, prefix string, z ...any) string {
 	for i, v := range z {
 		switch x := v.(type) {
 		case string:
-			names = append(names, x)
+			if x != "" {
+				names = append(names, x)
+			}
 		case Query:
 			for _, p := range x.Params {
 				if !all && p.Interpolate {
@@ -1327,11 +3820,11 @@ func (f *Funcs) namesfn(all bool, prefix string, z ...any) string {
 			}
 		case Table:
 			for _, p := range x.Fields {
-				names = append(names, prefix+checkName(p.GoName))
+				names = append(names, prefix+f.checkName(p.GoName))
 			}
 		case []Field:
 			for _, p := range x {
-				names = append(names, prefix+checkName(p.GoName))
+				names = append(names, prefix+f.checkName(p.GoName))
 			}
 		case Proc:
 			if params := f.params(x.Params, false); params != "" {
@@ -1339,6 +3832,9 @@ func (f *Funcs) namesfn(all bool, prefix string, z ...any) string {
 			}
 		case Index:
 			names = append(names, f.params(x.Fields, false))
+			if len(x.Page) != 0 {
+				names = append(names, f.params(x.Page, false))
+			}
 		default:
 			names = append(names, fmt.Sprintf("/* UNSUPPORTED TYPE 14 (%d): %T */", i, v))
 		}
@@ -1387,20 +3883,27 @@ func (f *Funcs) names_ignore(prefix string, v any, ignore ...string) string {
 
 // querystr generates a querystr for the specified query and any accompanying
 // comments.
-func (f *Funcs) querystr(v any) string {
+func (f *Funcs) querystr(v any) (string, error) {
 	var interpolate bool
 	var query, comments []string
 	switch x := v.(type) {
 	case Query:
 		interpolate, query, comments = x.Interpolate, x.Query, x.Comments
 	default:
-		return fmt.Sprintf("const sqlstr = [[ UNSUPPORTED TYPE 16: %T ]]", v)
+		return fmt.Sprintf("const sqlstr = [[ UNSUPPORTED TYPE 16: %T ]]", v), nil
 	}
 	typ := "const"
 	if interpolate {
 		typ = "var"
 	}
+	hint, err := f.queryHint("query", v)
+	if err != nil {
+		return "", err
+	}
 	var lines []string
+	if hint != "" {
+		lines = append(lines, "`"+hint+"` + ")
+	}
 	for i := 0; i < len(query); i++ {
 		line := "`" + query[i] + "`"
 		if i != len(query)-1 {
@@ -1412,32 +3915,359 @@ func (f *Funcs) querystr(v any) string {
 		lines = append(lines, line)
 	}
 	sqlstr := stripRE.ReplaceAllString(strings.Join(lines, "\n"), " ")
-	return fmt.Sprintf("%s sqlstr = %s", typ, sqlstr)
+	return fmt.Sprintf("%s sqlstr = %s", typ, sqlstr), nil
 }
 
 var stripRE = regexp.MustCompile(`\s+\+\s+` + "``")
 
-func (f *Funcs) sqlstr(typ string, v any) string {
+func (f *Funcs) sqlstr(typ string, v any) (string, error) {
+	lines, ok := f.sqlLines(typ, v)
+	if !ok {
+		return fmt.Sprintf("const sqlstr = `UNKNOWN QUERY TYPE: %s`", typ), nil
+	}
+	return f.sqlconst(typ, v, lines, "\t")
+}
+
+// sqlconst generates the sqlstr const of the lines of the statement of type
+// typ generated for v, leading with the query hint, and with each line after
+// the first indented by indent.
+func (f *Funcs) sqlconst(typ string, v any, lines []string, indent string) (string, error) {
+	hint, err := f.queryHint(typ, v)
+	if err != nil {
+		return "", err
+	}
+	if hint != "" {
+		lines = append([]string{hint}, lines...)
+	}
+	return fmt.Sprintf("const sqlstr = `%s`", strings.Join(lines, "` +\n"+indent+"`")), nil
+}
+
+// sqlLines builds the lines of the SQL statement of type typ for v.
+func (f *Funcs) sqlLines(typ string, v any) ([]string, bool) {
 	var lines []string
 	switch typ {
 	case "insert_manual":
 		lines = f.sqlstr_insert_manual(v)
 	case "insert":
 		lines = f.sqlstr_insert(v)
+	case "insert_many":
+		lines = f.sqlstr_insert_many(v)
 	case "update":
 		lines = f.sqlstr_update(v)
 	case "upsert":
 		lines = f.sqlstr_upsert(v)
+	case "upsert_index":
+		lines = f.sqlstr_upsert_index(v)
+	case "tenant_check":
+		lines = f.sqlstr_tenant_check(v)
 	case "delete":
 		lines = f.sqlstr_delete(v)
+	case "destroy":
+		lines = f.sqlstr_destroy(v)
 	case "proc":
 		lines = f.sqlstr_proc(v)
 	case "index":
 		lines = f.sqlstr_index(v)
+	case "index_paged":
+		lines = f.sqlstr_index_paged(v)
+	case "supersede":
+		lines = f.sqlstr_supersede(v)
+	case "outbox":
+		lines = f.sqlstr_outbox(v)
+	case "reconcile":
+		lines = f.sqlstr_reconcile(v)
+	default:
+		return nil, false
+	}
+	return lines, true
+}
+
+// query_id generates a QueryID constant for the statement of type typ
+// generated for v. The id is a hash of the statement with whitespace
+// normalized, and is stable across regenerations.
+func (f *Funcs) query_id(typ string, v any) string {
+	if !f.queryID {
+		return ""
+	}
+	typ, link, lines := f.statement(typ, v)
+	if lines == nil {
+		return fmt.Sprintf("[[ UNSUPPORTED TYPE 31: %T ]]", v)
+	}
+	name := strings.ReplaceAll(link, ".", "")
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(strings.Fields(strings.Join(lines, " ")), " ")))
+	return fmt.Sprintf("// %sQueryID is the query id for the SQL statement of [%s].\nconst %sQueryID = \"%016x\"\n\n", name, link, name, h.Sum64())
+}
+
+// statement returns the resolved statement type, name, and SQL lines of the
+// statement of type typ generated for v.
+func (f *Funcs) statement(typ string, v any) (string, string, []string) {
+	switch x := v.(type) {
+	case Table:
+		if typ == "insert" && x.Manual {
+			typ = "insert_manual"
+		}
+		lines, _ := f.sqlLines(typ, v)
+		if typ == "insert_many" && lines != nil {
+			// the statement inserting a single row
+			lines = append(lines, f.insertManyRow(x))
+			if seq := f.insert_many_returning(x); seq != nil {
+				lines = append(lines, " RETURNING "+f.colname(*seq))
+			}
+		}
+		return typ, tableStmtName(x, typ), lines
+	case Index:
+		lines, _ := f.sqlLines(typ, v)
+		if typ == "upsert_index" {
+			return typ, x.Table.GoName + "." + f.upsert_name(x), lines
+		}
+		return typ, x.Func, lines
+	case Proc:
+		lines, _ := f.sqlLines(typ, v)
+		return typ, f.func_name_none(x), lines
+	case Query:
+		return typ, x.Name, x.Query
+	}
+	return typ, "", nil
+}
+
+// query_info generates a QueryInfo literal for the statement of type typ
+// generated for v.
+func (f *Funcs) query_info(typ string, v any) (string, error) {
+	typ, name, lines := f.statement(typ, v)
+	if lines == nil {
+		return fmt.Sprintf("[[ UNSUPPORTED TYPE 32: %T ]]", v), nil
+	}
+	hint, err := f.queryHint(typ, v)
+	if err != nil {
+		return "", err
+	}
+	var args []string
+	var result string
+	switch x := v.(type) {
+	case Table:
+		var fields []Field
+		switch typ {
+		case "insert":
+			fields = insertFields(x, false)
+		case "insert_manual", "upsert":
+			fields = insertFields(x, true)
+		case "update":
+			fields = append(updateFields(x), x.PrimaryKeys...)
+		case "insert_many":
+			fields = insertFields(x, x.Manual)
+		case "delete", "destroy":
+			fields = append(fields, x.PrimaryKeys...)
+		case "supersede":
+			args = append(args, "time.Time")
+			fields = append(fields, x.PrimaryKeys...)
+		case "outbox":
+			result = "*" + x.GoName
+		case "reconcile":
+			result = "[]*" + x.GoName
+		}
+		if x.Tenant != nil && (typ == "update" || typ == "delete" || typ == "destroy") {
+			fields = append(fields, *x.Tenant)
+		}
+		for _, z := range fields {
+			args = append(args, f.typefn(z.Type))
+		}
+	case Index:
+		if typ == "upsert_index" {
+			var r []string
+			for _, z := range insertFields(x.Table, false) {
+				args = append(args, f.typefn(z.Type))
+			}
+			for _, z := range x.Table.PrimaryKeys {
+				r = append(r, f.typefn(z.Type))
+			}
+			result = strings.Join(r, ", ")
+			break
+		}
+		for _, z := range append(x.Fields, x.Page...) {
+			args = append(args, f.typefn(z.Type))
+		}
+		if x.AsOf {
+			args = append(args, "time.Time", "time.Time")
+		}
+		result = "*" + x.Table.GoName
+		if !x.IsUnique {
+			result = "[]" + result
+		}
+	case Proc:
+		var r []string
+		for _, z := range x.Params {
+			args = append(args, f.typefn(z.Type))
+		}
+		if !x.Void {
+			for _, z := range x.Returns {
+				r = append(r, f.typefn(z.Type))
+			}
+		}
+		result = strings.Join(r, ", ")
+	case Query:
+		for _, z := range x.Params {
+			args = append(args, z.Type)
+		}
+		switch {
+		case x.Exec && f.execResult:
+			result = "ExecResult"
+		case x.Exec:
+			result = "sql.Result"
+		case x.Flat:
+			var r []string
+			for _, z := range x.Type.Fields {
+				r = append(r, f.typefn(z.Type))
+			}
+			result = strings.Join(r, ", ")
+		case x.One:
+			result = "*" + x.Type.GoName
+		default:
+			result = "[]*" + x.Type.GoName
+		}
+	}
+	return queryInfo(name, hint+strings.Join(lines, ""), args, result, typ == "insert_many"), nil
+}
+
+// query_infos generates the QueryInfo literals for the statements of type typ
+// generated for v. Cascading deletes and preloads run several statements.
+func (f *Funcs) query_infos(typ string, v any) ([]string, error) {
+	var args, infos []string
+	switch x := v.(type) {
+	case CascadeDelete:
+		for _, z := range x.Table.PrimaryKeys {
+			args = append(args, f.typefn(z.Type))
+		}
+		if x.Table.Tenant != nil {
+			args = append(args, f.typefn(x.Table.Tenant.Type))
+		}
+		for _, sqlstr := range f.cascadeSQL(x) {
+			infos = append(infos, queryInfo(x.Func, sqlstr, args, "", false))
+		}
+	case Preload:
+		for _, z := range x.Table.PrimaryKeys {
+			args = append(args, f.typefn(z.Type))
+		}
+		if x.Table.Tenant != nil {
+			args = append(args, f.typefn(x.Table.Tenant.Type))
+		}
+		if typ == "preload_json" {
+			hint, err := f.queryHint(typ, x)
+			if err != nil {
+				return nil, err
+			}
+			return []string{queryInfo(x.Func, hint+strings.Join(f.preloadJSONLines(x), ""), args, "[]byte", false)}, nil
+		}
+		for _, n := range x.Nodes {
+			hint, err := f.queryHint(typ, PreloadBlock{Preload: x, Node: n})
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, queryInfo(x.Func, hint+strings.Join(f.preloadLines(x, n), ""), args, "[]*"+n.Table.GoName, false))
+		}
 	default:
-		return fmt.Sprintf("const sqlstr = `UNKNOWN QUERY TYPE: %s`", typ)
+		s, err := f.query_info(typ, v)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, s)
+	}
+	return infos, nil
+}
+
+// queryInfo generates a QueryInfo literal. When many, the statement is the
+// single row statement of an InsertMany func.
+func queryInfo(name, sqlstr string, args []string, result string, many bool) string {
+	var v []string
+	for _, s := range args {
+		v = append(v, strconv.Quote(s))
+	}
+	s := fmt.Sprintf("Name: %q, SQL: %q, Args: []string{%s}, Result: %q", name, sqlstr, strings.Join(v, ", "), result)
+	if many {
+		s += ", many: true"
+	}
+	return "{" + s + "}"
+}
+
+// tableStmtName returns the name of the func generated for the statement of
+// type typ on the table.
+func tableStmtName(t Table, typ string) string {
+	switch typ {
+	case "insert_many":
+		return "InsertMany" + t.GoName
+	case "outbox":
+		return "Poll" + t.GoName
+	case "reconcile":
+		return "Reconcile" + t.GoName
+	}
+	return t.GoName + "." + camelExport(strings.TrimSuffix(typ, "_manual"))
+}
+
+// queryHint generates the leading query hint comment for the statement of
+// type typ generated for v.
+func (f *Funcs) queryHint(typ string, v any) (string, error) {
+	if f.hint == nil {
+		return "", nil
+	}
+	var name, table string
+	switch x := v.(type) {
+	case Table:
+		name = tableStmtName(x, typ)
+		table = x.SQLName
+	case Index:
+		name = x.Func
+		if typ == "upsert_index" {
+			name = x.Table.GoName + "." + f.upsert_name(x)
+		}
+		table = x.Table.SQLName
+	case Preload:
+		name = x.Func
+		table = x.Table.SQLName
+	case PreloadBlock:
+		name = x.Preload.Func
+		table = x.Node.Table.SQLName
+	case Proc, Query:
+		name = f.func_name_none(x)
+	}
+	buf := new(bytes.Buffer)
+	if err := f.hint.Execute(buf, map[string]string{
+		"Name":  name,
+		"Type":  strings.TrimSuffix(typ, "_manual"),
+		"Table": table,
+	}); err != nil {
+		return "", err
+	}
+	s := strings.ReplaceAll(strings.TrimSpace(buf.String()), "*/", "* /")
+	if s == "" {
+		return "", nil
+	}
+	return "/*+ " + strings.ReplaceAll(s, "`", "'") + " */ ", nil
+}
+
+// insertFields returns the table's fields written by an INSERT, skipping
+// update only fields, and sequence fields when not all.
+func insertFields(t Table, all bool) []Field {
+	var fields []Field
+	for _, z := range t.Fields {
+		if (z.IsSequence && !all) || z.UpdateOnly {
+			continue
+		}
+		fields = append(fields, z)
+	}
+	return fields
+}
+
+// updateFields returns the table's fields set by an UPDATE, skipping primary
+// key, insert only and tenant fields. The tenant of a row is never changed.
+func updateFields(t Table) []Field {
+	var fields []Field
+	for _, z := range t.Fields {
+		if z.IsPrimary || z.InsertOnly || (t.Tenant != nil && z.SQLName == t.Tenant.SQLName) {
+			continue
+		}
+		fields = append(fields, z)
 	}
-	return fmt.Sprintf("const sqlstr = `%s`", strings.Join(lines, "` +\n\t`"))
+	return fields
 }
 
 // sqlstr_insert_base builds an INSERT query
@@ -1446,14 +4276,13 @@ func (f *Funcs) sqlstr_insert_base(all bool, v any) []string {
 	switch x := v.(type) {
 	case Table:
 		// build names and values
-		var n int
 		var fields, vals []string
-		for _, z := range x.Fields {
-			if z.IsSequence && !all {
-				continue
-			}
+		for n, z := range insertFields(x, all) {
 			fields, vals = append(fields, f.colname(z)), append(vals, f.nth(n))
-			n++
+		}
+		// no fields (such as when all fields are update only)
+		if len(fields) == 0 && f.driver != "mysql" && f.driver != "oracle" {
+			return []string{"INSERT INTO " + f.schemafn(x.SQLName) + " DEFAULT VALUES"}
 		}
 		return []string{
 			"INSERT INTO " + f.schemafn(x.SQLName) + " (",
@@ -1477,14 +4306,12 @@ func (f *Funcs) sqlstr_insert(v any) []string {
 	switch x := v.(type) {
 	case Table:
 		var seq Field
-		var count int
 		for _, field := range x.Fields {
 			if field.IsSequence {
 				seq = field
-			} else {
-				count++
 			}
 		}
+		count := len(insertFields(x, false))
 		lines := f.sqlstr_insert_base(false, v)
 		// add return clause
 		switch f.driver {
@@ -1507,6 +4334,91 @@ func (f *Funcs) sqlstr_insert(v any) []string {
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 18: %T ]]", v)}
 }
 
+// sqlstr_insert_many builds the start of a multi-row INSERT query, up to the
+// VALUES that are added for each row.
+func (f *Funcs) sqlstr_insert_many(v any) []string {
+	switch x := v.(type) {
+	case Table:
+		lines := f.sqlstr_insert_base(x.Manual, v)
+		return append(lines[:2], ") VALUES ")
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 37: %T ]]", v)}
+}
+
+// insert_manyfn returns true when InsertMany func generation is enabled.
+func (f *Funcs) insert_manyfn() bool {
+	return f.insertMany
+}
+
+// insert_many_size returns the number of rows inserted by each statement of
+// an InsertMany func, keeping within the driver's limit of bind params.
+func (f *Funcs) insert_many_size(t Table) int {
+	limit := 65535
+	switch f.driver {
+	case "sqlite3":
+		limit = 999
+	case "sqlserver":
+		limit = 2099
+	}
+	if n := limit / len(insertFields(t, t.Manual)); n < 1000 {
+		return n
+	}
+	return 1000
+}
+
+// insert_many_values generates the expression of a row's VALUES for an
+// InsertMany func, numbering the bind params after the n params of the
+// previous rows.
+func (f *Funcs) insert_many_values(t Table, n string) string {
+	var params, args []string
+	for i := range insertFields(t, t.Manual) {
+		switch f.driver {
+		case "postgres":
+			params, args = append(params, "$%d"), append(args, fmt.Sprintf("%s+%d", n, i+1))
+		case "sqlserver":
+			params, args = append(params, "@p%d"), append(args, fmt.Sprintf("%s+%d", n, i+1))
+		default:
+			params = append(params, "?")
+		}
+	}
+	values := "(" + strings.Join(params, ", ") + ")"
+	if len(args) == 0 {
+		return strconv.Quote(values)
+	}
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", values, strings.Join(args, ", "))
+}
+
+// insertManyRow builds the VALUES row of the first row of an InsertMany
+// statement.
+func (f *Funcs) insertManyRow(t Table) string {
+	var params []string
+	for i := range insertFields(t, t.Manual) {
+		switch f.driver {
+		case "postgres":
+			params = append(params, fmt.Sprintf("$%d", i+1))
+		case "sqlserver":
+			params = append(params, fmt.Sprintf("@p%d", i+1))
+		default:
+			params = append(params, "?")
+		}
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// insert_many_returning returns the sequence field set from the RETURNING
+// clause of an InsertMany func, if any.
+func (f *Funcs) insert_many_returning(t Table) *Field {
+	if f.driver != "postgres" || t.Manual {
+		return nil
+	}
+	for _, z := range t.Fields {
+		if z.IsSequence {
+			return &z
+		}
+	}
+	return nil
+}
+
 // sqlstr_update_base builds an UPDATE query, using primary key fields as the WHERE
 // clause, adding prefix.
 //
@@ -1521,8 +4433,9 @@ func (f *Funcs) sqlstr_update_base(prefix string, v any) (int, []string) {
 		// build names and values
 		var n int
 		var list []string
-		for _, z := range x.Fields {
-			if z.IsPrimary {
+		for _, z := range updateFields(x) {
+			// update only fields are not inserted, and are not set by upserts
+			if prefix != "" && z.UpdateOnly {
 				continue
 			}
 			name, param := f.colname(z), f.nth(n)
@@ -1555,6 +4468,9 @@ func (f *Funcs) sqlstr_update(v any) []string {
 		for i, z := range x.PrimaryKeys {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(n+i)))
 		}
+		if x.Tenant != nil {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(*x.Tenant), f.nth(n+len(x.PrimaryKeys))))
+		}
 		return append(lines, "WHERE "+strings.Join(list, " AND "))
 	}
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 20: %T ]]", v)}
@@ -1577,6 +4493,43 @@ func (f *Funcs) sqlstr_upsert(v any) []string {
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 21 %s: %T ]]", f.driver, v)}
 }
 
+// sqlstr_upsert_index builds an upsert query for postgres and sqlite on the
+// conflict of the unique index, returning the primary keys of the inserted or
+// updated row.
+//
+// INSERT (..) VALUES (..) ON CONFLICT (..) DO UPDATE SET ... RETURNING ...
+func (f *Funcs) sqlstr_upsert_index(v any) []string {
+	switch x := v.(type) {
+	case Index:
+		var conflicts, pkeys []string
+		for _, z := range indexFields(x) {
+			conflicts = append(conflicts, f.colname(z))
+		}
+		for _, z := range x.Table.PrimaryKeys {
+			pkeys = append(pkeys, f.colname(z))
+		}
+		lines := append(f.sqlstr_insert_base(false, x.Table), " ON CONFLICT ("+strings.Join(conflicts, ", ")+") DO ")
+		_, update := f.sqlstr_update_base("EXCLUDED.", x.Table)
+		lines = append(lines, update...)
+		// only update the row of the same tenant
+		if t := x.Table; t.Tenant != nil {
+			col := f.colname(*t.Tenant)
+			lines = append(lines, "WHERE "+f.schemafn(t.SQLName)+"."+col+" = EXCLUDED."+col+" ")
+		}
+		return append(lines, "RETURNING "+strings.Join(pkeys, ", "))
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 40: %T ]]", v)}
+}
+
+// upsert_name returns the name of the upsert on the conflict of the unique
+// index, such as UpsertByTagName.
+func (f *Funcs) upsert_name(i Index) string {
+	if s, ok := strings.CutPrefix(i.Func, i.Table.GoName+"By"); ok {
+		return "UpsertBy" + s
+	}
+	return "UpsertBy" + i.Func
+}
+
 // sqlstr_upsert_postgres_sqlite builds an uspert query for postgres and sqlite
 //
 // INSERT (..) VALUES (..) ON CONFLICT DO UPDATE SET ...
@@ -1590,7 +4543,13 @@ func (f *Funcs) sqlstr_upsert_postgres_sqlite(v any) []string {
 		}
 		lines := []string{" ON CONFLICT (" + strings.Join(conflicts, ", ") + ") DO "}
 		_, update := f.sqlstr_update_base("EXCLUDED.", v)
-		return append(lines, update...)
+		lines = append(lines, update...)
+		// only update the row of the same tenant
+		if x.Tenant != nil {
+			col := f.colname(*x.Tenant)
+			lines = append(lines, "WHERE "+f.schemafn(x.SQLName)+"."+col+" = EXCLUDED."+col)
+		}
+		return lines
 	}
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 22: %T ]]", v)}
 }
@@ -1605,11 +4564,18 @@ func (f *Funcs) sqlstr_upsert_mysql(v any) []string {
 		var list []string
 		i := len(x.Fields)
 		for _, z := range x.Fields {
-			if z.IsSequence {
+			if z.IsSequence || z.InsertOnly || z.UpdateOnly {
 				continue
 			}
 			name := f.colname(z)
-			list = append(list, fmt.Sprintf("%s = VALUES(%s)", name, name))
+			switch {
+			case x.Tenant == nil:
+				list = append(list, fmt.Sprintf("%s = VALUES(%s)", name, name))
+			case z.SQLName != x.Tenant.SQLName:
+				// only update the row of the same tenant
+				tenant := f.colname(*x.Tenant)
+				list = append(list, fmt.Sprintf("%s = IF(%s = VALUES(%s), VALUES(%s), %s)", name, tenant, tenant, name, name))
+			}
 			i++
 		}
 		return append(lines, strings.Join(list, ", "))
@@ -1633,7 +4599,7 @@ func (f *Funcs) sqlstr_upsert_sqlserver_oracle(v any) []string {
 		}
 		// using (select ..)
 		var fields, predicate []string
-		for i, field := range x.Fields {
+		for i, field := range insertFields(x, true) {
 			fields = append(fields, fmt.Sprintf("%s %s", f.nth(i), field.SQLName))
 		}
 		for _, field := range x.PrimaryKeys {
@@ -1653,22 +4619,32 @@ func (f *Funcs) sqlstr_upsert_sqlserver_oracle(v any) []string {
 			`ON `+strings.Join(predicate, " AND ")+" ")
 		// build param lists
 		var updateParams, insertParams, insertVals []string
-		for _, field := range x.Fields {
+		for _, field := range insertFields(x, true) {
 			// sequences are always managed by db
 			if field.IsSequence {
 				continue
 			}
-			// primary keys
-			if !field.IsPrimary {
+			// primary keys, insert only and tenant fields
+			if !field.IsPrimary && !field.InsertOnly && (x.Tenant == nil || field.SQLName != x.Tenant.SQLName) {
 				updateParams = append(updateParams, fmt.Sprintf("t.%s = s.%s", field.SQLName, field.SQLName))
 			}
 			insertParams = append(insertParams, field.SQLName)
 			insertVals = append(insertVals, "s."+field.SQLName)
 		}
-		// when matched then update...
+		// when matched then update, only updating the row of the same tenant
+		matched, update := `WHEN MATCHED THEN `, strings.Join(updateParams, ", ")+" "
+		if x.Tenant != nil {
+			tenant := fmt.Sprintf("t.%s = s.%s", x.Tenant.SQLName, x.Tenant.SQLName)
+			switch f.driver {
+			case "sqlserver":
+				matched = `WHEN MATCHED AND ` + tenant + ` THEN `
+			case "oracle":
+				update += `WHERE ` + tenant + ` `
+			}
+		}
 		lines = append(lines,
-			`WHEN MATCHED THEN `, `UPDATE SET `,
-			strings.Join(updateParams, ", ")+" ",
+			matched, `UPDATE SET `,
+			update,
 			`WHEN NOT MATCHED THEN `,
 			`INSERT (`,
 			strings.Join(insertParams, ", "),
@@ -1681,8 +4657,25 @@ func (f *Funcs) sqlstr_upsert_sqlserver_oracle(v any) []string {
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 24: %T ]]", v)}
 }
 
-// sqlstr_delete builds a DELETE query for the primary keys.
+// sqlstr_delete builds a DELETE query for the primary keys, or for a soft
+// delete table, an UPDATE setting the soft delete column of the row when not
+// already deleted.
 func (f *Funcs) sqlstr_delete(v any) []string {
+	switch x := v.(type) {
+	case Table:
+		lines := f.sqlstr_destroy(v)
+		if x.SoftDelete != nil {
+			col := f.colname(*x.SoftDelete)
+			lines[0] = "UPDATE " + f.schemafn(x.SQLName) + " "
+			lines = append(lines[:1], "SET "+col+" = CURRENT_TIMESTAMP ", lines[1]+" AND "+col+" IS NULL")
+		}
+		return lines
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 25: %T ]]", v)}
+}
+
+// sqlstr_destroy builds a DELETE query for the primary keys.
+func (f *Funcs) sqlstr_destroy(v any) []string {
 	switch x := v.(type) {
 	case Table:
 		// names and values
@@ -1690,12 +4683,132 @@ func (f *Funcs) sqlstr_delete(v any) []string {
 		for i, z := range x.PrimaryKeys {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
 		}
-		return []string{
-			"DELETE FROM " + f.schemafn(x.SQLName) + " ",
-			"WHERE " + strings.Join(list, " AND "),
+		if x.Tenant != nil {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(*x.Tenant), f.nth(len(x.PrimaryKeys))))
+		}
+		return []string{
+			"DELETE FROM " + f.schemafn(x.SQLName) + " ",
+			"WHERE " + strings.Join(list, " AND "),
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 39: %T ]]", v)}
+}
+
+// sqlstr_tenant_check builds a query counting the rows of the primary keys
+// of the tenant, used to check the tenant of an upserted row.
+func (f *Funcs) sqlstr_tenant_check(v any) []string {
+	switch x := v.(type) {
+	case Table:
+		lines := f.sqlstr_destroy(v)
+		if x.Tenant == nil {
+			break
+		}
+		lines[0] = "SELECT COUNT(*) FROM " + f.schemafn(x.SQLName) + " "
+		return lines
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 41: %T ]]", v)}
+}
+
+// sqlstr_supersede builds an UPDATE query closing the validity window of the
+// current version of a row.
+func (f *Funcs) sqlstr_supersede(v any) []string {
+	switch x := v.(type) {
+	case Table:
+		if x.ValidTo == nil {
+			break
+		}
+		var list []string
+		for i, z := range x.PrimaryKeys {
+			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i+1)))
+		}
+		return []string{
+			"UPDATE " + f.schemafn(x.SQLName) + " SET ",
+			f.colname(*x.ValidTo) + " = " + f.nth(0) + " ",
+			"WHERE " + strings.Join(list, " AND ") + " AND " + f.colname(*x.ValidTo) + " IS NULL",
+		}
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 33: %T ]]", v)}
+}
+
+// sqlstr_outbox builds a SELECT query locking the next event of the outbox
+// table in primary key order, skipping events locked by other consumers where
+// supported by the driver.
+func (f *Funcs) sqlstr_outbox(v any) []string {
+	t, ok := v.(Table)
+	if !ok {
+		return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 34: %T ]]", v)}
+	}
+	var fields, order []string
+	for _, z := range t.Fields {
+		fields = append(fields, f.colname(z))
+	}
+	for _, z := range t.PrimaryKeys {
+		order = append(order, f.colname(z))
+	}
+	table, orderBy := f.schemafn(t.SQLName), strings.Join(order, ", ")
+	switch f.driver {
+	case "sqlserver":
+		return []string{
+			"SELECT TOP 1 ",
+			strings.Join(fields, ", ") + " ",
+			"FROM " + table + " WITH (UPDLOCK, READPAST, ROWLOCK) ",
+			"ORDER BY " + orderBy,
+		}
+	case "oracle":
+		return []string{
+			"SELECT ",
+			strings.Join(fields, ", ") + " ",
+			"FROM " + table + " ",
+			"ORDER BY " + orderBy + " ",
+			"FETCH FIRST 1 ROWS ONLY",
+		}
+	case "sqlite3":
+		return []string{
+			"SELECT ",
+			strings.Join(fields, ", ") + " ",
+			"FROM " + table + " ",
+			"ORDER BY " + orderBy + " ",
+			"LIMIT 1",
+		}
+	}
+	return []string{
+		"SELECT ",
+		strings.Join(fields, ", ") + " ",
+		"FROM " + table + " ",
+		"ORDER BY " + orderBy + " ",
+		"LIMIT 1 FOR UPDATE SKIP LOCKED",
+	}
+}
+
+// sqlstr_reconcile builds a SELECT query retrieving all rows of the table
+// ordered by primary key, scoped to the tenant and skipping soft deleted rows.
+func (f *Funcs) sqlstr_reconcile(v any) []string {
+	switch x := v.(type) {
+	case Table:
+		var fields, where, order []string
+		for _, z := range x.Fields {
+			fields = append(fields, f.colname(z))
+		}
+		if x.Tenant != nil {
+			where = append(where, f.colname(*x.Tenant)+" = "+f.nth(0))
+		}
+		if x.SoftDelete != nil {
+			where = append(where, f.colname(*x.SoftDelete)+" IS NULL")
+		}
+		for _, z := range x.PrimaryKeys {
+			order = append(order, f.colname(z))
+		}
+		lines := []string{
+			"SELECT ",
+			strings.Join(fields, ", ") + " ",
+			"FROM " + f.schemafn(x.SQLName) + " ",
+		}
+		if len(where) != 0 {
+			lines = append(lines, "WHERE "+strings.Join(where, " AND ")+" ")
 		}
+		return append(lines, "ORDER BY "+strings.Join(order, ", "))
 	}
-	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 25: %T ]]", v)}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 35: %T ]]", v)}
 }
 
 // sqlstr_index builds a index fields.
@@ -1712,16 +4825,106 @@ func (f *Funcs) sqlstr_index(v any) []string {
 		for i, z := range x.Fields {
 			list = append(list, fmt.Sprintf("%s = %s", f.colname(z), f.nth(i)))
 		}
-		return []string{
+		// omit soft deleted rows
+		if x.Table.SoftDelete != nil {
+			list = append(list, f.colname(*x.Table.SoftDelete)+" IS NULL")
+		}
+		lines := []string{
 			"SELECT ",
 			strings.Join(fields, ", ") + " ",
 			"FROM " + f.schemafn(x.Table.SQLName) + " ",
 			"WHERE " + strings.Join(list, " AND "),
 		}
+		// valid at time
+		if x.AsOf {
+			n := len(x.Fields)
+			lines[len(lines)-1] += " "
+			lines = append(lines, fmt.Sprintf(
+				"AND %s <= %s AND (%s IS NULL OR %s > %s)",
+				f.colname(*x.Table.ValidFrom), f.nth(n), f.colname(*x.Table.ValidTo), f.colname(*x.Table.ValidTo), f.nth(n+1),
+			))
+		}
+		// default order of list funcs
+		if !x.IsUnique && x.Table.OrderBy != "" {
+			lines[len(lines)-1] += " "
+			lines = append(lines, "ORDER BY "+x.Table.OrderBy)
+		}
+		return lines
 	}
 	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 26: %T ]]", v)}
 }
 
+// sqlstr_index_paged builds a paginated index query, either by limit and
+// offset, or by limit after a primary key.
+func (f *Funcs) sqlstr_index_paged(v any) []string {
+	switch x := v.(type) {
+	case Index:
+		var order, after []string
+		n := len(x.Fields)
+		for i, z := range x.Table.PrimaryKeys {
+			order, after = append(order, f.colname(z)), append(after, f.nth(n+i))
+		}
+		if x.Keyset {
+			x.Table.OrderBy = ""
+		}
+		lines := f.sqlstr_index(x)
+		lines[len(lines)-1] += " "
+		if x.Keyset {
+			cond := order[0] + " > " + after[0]
+			if len(order) > 1 {
+				cond = "(" + strings.Join(order, ", ") + ") > (" + strings.Join(after, ", ") + ")"
+			}
+			lines = append(lines, "AND "+cond+" ", "ORDER BY "+strings.Join(order, ", ")+" ")
+			limit := f.nth(n + len(order))
+			switch f.driver {
+			case "sqlserver":
+				return append(lines, "OFFSET 0 ROWS FETCH NEXT "+limit+" ROWS ONLY")
+			case "oracle":
+				return append(lines, "FETCH FIRST "+limit+" ROWS ONLY")
+			}
+			return append(lines, "LIMIT "+limit)
+		}
+		switch tiebreak := missingOrder(x.Table.OrderBy, order); {
+		case x.Table.OrderBy == "":
+			lines = append(lines, "ORDER BY "+strings.Join(order, ", ")+" ")
+		case len(tiebreak) != 0:
+			// break ties of the default order by primary key, so that pages
+			// are stable
+			lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], " ") + ", " + strings.Join(tiebreak, ", ") + " "
+		}
+		switch f.driver {
+		case "sqlserver":
+			return append(lines, fmt.Sprintf("OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", f.nth(n+1), f.nth(n)))
+		case "oracle":
+			// oracle binds by position, see page_args
+			return append(lines, fmt.Sprintf("OFFSET %s ROWS FETCH NEXT %s ROWS ONLY", f.nth(n), f.nth(n+1)))
+		}
+		return append(lines, fmt.Sprintf("LIMIT %s OFFSET %s", f.nth(n), f.nth(n+1)))
+	}
+	return []string{fmt.Sprintf("[[ UNSUPPORTED TYPE 38: %T ]]", v)}
+}
+
+// missingOrder returns the (possibly escaped) columns not ordered by the order
+// by expression.
+func missingOrder(orderBy string, cols []string) []string {
+	unquote := func(s string) string {
+		return strings.ToLower(strings.Trim(s, "\"`[]"))
+	}
+	ordered := make(map[string]bool)
+	for _, term := range strings.Split(orderBy, ",") {
+		if v := strings.Fields(term); len(v) != 0 {
+			ordered[unquote(v[0])] = true
+		}
+	}
+	var missing []string
+	for _, col := range cols {
+		if !ordered[unquote(col)] {
+			missing = append(missing, col)
+		}
+	}
+	return missing
+}
+
 // sqlstr_proc builds a stored procedure call.
 func (f *Funcs) sqlstr_proc(v any) []string {
 	switch x := v.(type) {
@@ -1843,6 +5046,10 @@ func (f *Funcs) param(field Field, addType bool) string {
 	if r, ok := goReservedNames[strings.ToLower(s)]; ok {
 		s = r
 	}
+	// check template reserved names
+	if templateReservedNames[s] {
+		s += f.conflict
+	}
 	// add the go type
 	if addType {
 		s += " " + f.typefn(field.Type)
@@ -1975,6 +5182,25 @@ func checkName(name string) string {
 	return name
 }
 
+// checkName checks name against the Go reserved names, and then against the
+// template reserved names, appending the name conflict suffix when name
+// collides with a variable or package used in the generated code.
+func (f *Funcs) checkName(name string) string {
+	return safeName(name, f.conflict)
+}
+
+// safeName returns a safe Go identifier for name that will not collide with a
+// Go reserved name or a name used by the templates.
+func safeName(name, conflict string) string {
+	if n, ok := goReservedNames[name]; ok {
+		return n
+	}
+	if templateReservedNames[name] {
+		return name + conflict
+	}
+	return name
+}
+
 // escfn escapes s.
 func escfn(s string) string {
 	return `"` + s + `"`
@@ -1996,13 +5222,14 @@ func eval(v any, s string) (string, error) {
 // templateReservedNames are the template reserved names.
 var templateReservedNames = map[string]bool{
 	// variables
-	"ctx":  true,
-	"db":   true,
-	"err":  true,
-	"log":  true,
-	"logf": true,
-	"res":  true,
-	"rows": true,
+	"ctx":    true,
+	"db":     true,
+	"err":    true,
+	"log":    true,
+	"logf":   true,
+	"res":    true,
+	"rows":   true,
+	"sqlstr": true,
 
 	// packages
 	"context": true,
@@ -2018,6 +5245,21 @@ var templateReservedNames = map[string]bool{
 	"uuid":    true,
 }
 
+// tableMethodNames are the names of the methods generated for table types,
+// without the context suffix.
+var tableMethodNames = map[string]bool{
+	"Delete":           true,
+	"Deleted":          true,
+	"Destroy":          true,
+	"Exists":           true,
+	"Insert":           true,
+	"InsertWithOutbox": true,
+	"Save":             true,
+	"Supersede":        true,
+	"Update":           true,
+	"Upsert":           true,
+}
+
 // goReservedNames is a map of of go reserved names to "safe" names.
 var goReservedNames = map[string]string{
 	"break":       "brk",
@@ -2068,6 +5310,27 @@ var goReservedNames = map[string]string{
 	"complex128": "c128",
 }
 
+// goVersion parses the minor version of a go version (1.21, go1.21.3),
+// returning 0 when the version is empty. Versions prior to go 1.18 are not
+// supported, as the generated code uses generics.
+func goVersion(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v := strings.Split(strings.TrimPrefix(s, "go"), ".")
+	if len(v) < 2 || len(v) > 3 || v[0] != "1" {
+		return 0, fmt.Errorf("invalid go version %q", s)
+	}
+	minor, err := strconv.Atoi(v[1])
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid go version %q", s)
+	case minor < 18:
+		return 0, fmt.Errorf("go version %q not supported: must be 1.18 or later", s)
+	}
+	return minor, nil
+}
+
 // nameContext adds suffix Context to name.
 func nameContext(context bool, name string) string {
 	if context {
@@ -2078,27 +5341,67 @@ func nameContext(context bool, name string) string {
 
 // Context keys.
 var (
-	AppendKey     xo.ContextKey = "append"
-	KnownTypesKey xo.ContextKey = "known-types"
-	ShortsKey     xo.ContextKey = "shorts"
-	NotFirstKey   xo.ContextKey = "not-first"
-	Int32Key      xo.ContextKey = "int32"
-	Uint32Key     xo.ContextKey = "uint32"
-	ArrayModeKey  xo.ContextKey = "array-mode"
-	PkgKey        xo.ContextKey = "pkg"
-	TagKey        xo.ContextKey = "tag"
-	ImportKey     xo.ContextKey = "import"
-	UUIDKey       xo.ContextKey = "uuid"
-	CustomKey     xo.ContextKey = "custom"
-	ConflictKey   xo.ContextKey = "conflict"
-	InitialismKey xo.ContextKey = "initialism"
-	EscKey        xo.ContextKey = "esc"
-	FieldTagKey   xo.ContextKey = "field-tag"
-	ContextKey    xo.ContextKey = "context"
-	InjectKey     xo.ContextKey = "inject"
-	InjectFileKey xo.ContextKey = "inject-file"
-	LegacyKey     xo.ContextKey = "legacy"
-	OracleTypeKey xo.ContextKey = "oracle-type"
+	AppendKey        xo.ContextKey = "append"
+	KnownTypesKey    xo.ContextKey = "known-types"
+	ShortsKey        xo.ContextKey = "shorts"
+	NotFirstKey      xo.ContextKey = "not-first"
+	Int32Key         xo.ContextKey = "int32"
+	Uint32Key        xo.ContextKey = "uint32"
+	ArrayModeKey     xo.ContextKey = "array-mode"
+	PkgKey           xo.ContextKey = "pkg"
+	TagKey           xo.ContextKey = "tag"
+	NolintKey        xo.ContextKey = "nolint"
+	MaxLinesKey      xo.ContextKey = "max-lines"
+	ImportKey        xo.ContextKey = "import"
+	UUIDKey          xo.ContextKey = "uuid"
+	CustomKey        xo.ContextKey = "custom"
+	ConflictKey      xo.ContextKey = "conflict"
+	InitialismKey    xo.ContextKey = "initialism"
+	IdentMapKey      xo.ContextKey = "ident-map"
+	RenameKey        xo.ContextKey = "rename"
+	EscKey           xo.ContextKey = "esc"
+	FieldTagKey      xo.ContextKey = "field-tag"
+	ContextKey       xo.ContextKey = "context"
+	ContextPosKey    xo.ContextKey = "context-position"
+	DBTypeKey        xo.ContextKey = "db-type"
+	NarrowDBKey      xo.ContextKey = "narrow-db"
+	ShardKey         xo.ContextKey = "shard"
+	RetryKey         xo.ContextKey = "retry"
+	HooksKey         xo.ContextKey = "hooks"
+	QueryHintKey     xo.ContextKey = "query-hint"
+	QueryIDKey       xo.ContextKey = "query-id"
+	CatalogKey       xo.ContextKey = "catalog"
+	EncryptKey       xo.ContextKey = "encrypt"
+	EncryptBase64Key xo.ContextKey = "encrypt-base64"
+	SensitiveKey     xo.ContextKey = "sensitive"
+	InsertOnlyKey    xo.ContextKey = "insert-only"
+	UpdateOnlyKey    xo.ContextKey = "update-only"
+	TenantKey        xo.ContextKey = "tenant-column"
+	SoftDeleteKey    xo.ContextKey = "soft-delete-column"
+	OrderByKey       xo.ContextKey = "order-by"
+	EnumValueKey     xo.ContextKey = "enum-value"
+	InsertManyKey    xo.ContextKey = "insert-many"
+	UpsertIndexKey   xo.ContextKey = "upsert-index"
+	PagedKey         xo.ContextKey = "paged"
+	CheckRowsKey     xo.ContextKey = "check-rows"
+	ExecResultKey    xo.ContextKey = "exec-result"
+	UnitOfWorkKey    xo.ContextKey = "unit-of-work"
+	PreloadKey       xo.ContextKey = "preload-depth"
+	GraphJSONKey     xo.ContextKey = "preload-json"
+	TemporalKey      xo.ContextKey = "temporal"
+	OutboxKey        xo.ContextKey = "outbox"
+	CDCKey           xo.ContextKey = "cdc"
+	NotifyKey        xo.ContextKey = "notify"
+	ReconcileKey     xo.ContextKey = "reconcile"
+	TestsKey         xo.ContextKey = "tests"
+	ExplainKey       xo.ContextKey = "explain"
+	TestHelpersKey   xo.ContextKey = "test-helpers"
+	GoVersionKey     xo.ContextKey = "version"
+	CascadeKey       xo.ContextKey = "cascade"
+	InjectKey        xo.ContextKey = "inject"
+	InjectFileKey    xo.ContextKey = "inject-file"
+	LegacyKey        xo.ContextKey = "legacy"
+	OracleTypeKey    xo.ContextKey = "oracle-type"
 )
 
 // Append returns append from the context.
@@ -2152,6 +5455,24 @@ func Pkg(ctx context.Context) string {
 	return s
 }
 
+// Nolint returns the nolint linters from the context.
+func Nolint(ctx context.Context) []string {
+	v, _ := ctx.Value(NolintKey).([]string)
+	var linters []string
+	for _, s := range v {
+		if s = strings.TrimSpace(s); s != "" {
+			linters = append(linters, s)
+		}
+	}
+	return linters
+}
+
+// MaxLines returns max-lines from the context.
+func MaxLines(ctx context.Context) int {
+	i, _ := ctx.Value(MaxLinesKey).(int)
+	return i
+}
+
 // Tags returns tags from the context.
 func Tags(ctx context.Context) []string {
 	v, _ := ctx.Value(TagKey).([]string)
@@ -2194,6 +5515,19 @@ func Conflict(ctx context.Context) string {
 	return s
 }
 
+// Rename returns rename from the context, as a map of columns (either
+// <table>.<column> or <column>) to go field names.
+func Rename(ctx context.Context) map[string]string {
+	m := make(map[string]string)
+	z, _ := ctx.Value(RenameKey).([]string)
+	for _, s := range z {
+		if column, name, ok := strings.Cut(s, "="); ok {
+			m[strings.TrimSpace(column)] = strings.TrimSpace(name)
+		}
+	}
+	return m
+}
+
 // Esc indicates if esc should be escaped based from the context.
 func Esc(ctx context.Context, esc string) bool {
 	v, _ := ctx.Value(EscKey).([]string)
@@ -2212,6 +5546,282 @@ func Context(ctx context.Context) string {
 	return s
 }
 
+// ContextPos returns context-position from the context.
+func ContextPos(ctx context.Context) string {
+	s, _ := ctx.Value(ContextPosKey).(string)
+	return s
+}
+
+// DBType returns db-type from the context.
+func DBType(ctx context.Context) string {
+	s, _ := ctx.Value(DBTypeKey).(string)
+	if s == "" {
+		s = "DB"
+	}
+	return s
+}
+
+// NarrowDB returns narrow-db from the context.
+func NarrowDB(ctx context.Context) bool {
+	b, _ := ctx.Value(NarrowDBKey).(bool)
+	return b
+}
+
+// Shard returns shard from the context.
+func Shard(ctx context.Context) bool {
+	b, _ := ctx.Value(ShardKey).(bool)
+	return b
+}
+
+// Retry returns retry from the context.
+func Retry(ctx context.Context) bool {
+	b, _ := ctx.Value(RetryKey).(bool)
+	return b
+}
+
+// Hooks returns hooks from the context.
+func Hooks(ctx context.Context) bool {
+	b, _ := ctx.Value(HooksKey).(bool)
+	return b
+}
+
+// QueryHint returns query-hint from the context.
+func QueryHint(ctx context.Context) string {
+	s, _ := ctx.Value(QueryHintKey).(string)
+	return s
+}
+
+// QueryID returns query-id from the context.
+func QueryID(ctx context.Context) bool {
+	b, _ := ctx.Value(QueryIDKey).(bool)
+	return b
+}
+
+// Catalog returns catalog from the context.
+func Catalog(ctx context.Context) bool {
+	b, _ := ctx.Value(CatalogKey).(bool)
+	return b
+}
+
+// EncryptBase64 returns encrypt-base64 from the context.
+func EncryptBase64(ctx context.Context) bool {
+	b, _ := ctx.Value(EncryptBase64Key).(bool)
+	return b
+}
+
+// Encrypt returns encrypt from the context.
+func Encrypt(ctx context.Context) []string {
+	var v []string
+	z, _ := ctx.Value(EncryptKey).([]string)
+	for _, s := range z {
+		if s != "" {
+			v = append(v, s)
+		}
+	}
+	return v
+}
+
+// Sensitive returns sensitive from the context.
+func Sensitive(ctx context.Context) []string {
+	var v []string
+	z, _ := ctx.Value(SensitiveKey).([]string)
+	for _, s := range z {
+		if s != "" {
+			v = append(v, s)
+		}
+	}
+	return v
+}
+
+// InsertOnly returns insert-only from the context.
+func InsertOnly(ctx context.Context) []string {
+	var v []string
+	z, _ := ctx.Value(InsertOnlyKey).([]string)
+	for _, s := range z {
+		if s != "" {
+			v = append(v, s)
+		}
+	}
+	return v
+}
+
+// UpdateOnly returns update-only from the context.
+func UpdateOnly(ctx context.Context) []string {
+	var v []string
+	z, _ := ctx.Value(UpdateOnlyKey).([]string)
+	for _, s := range z {
+		if s != "" {
+			v = append(v, s)
+		}
+	}
+	return v
+}
+
+// tenantName returns the name of the tenant column of the table, or an empty
+// string when the table is not tenant scoped.
+func tenantName(t Table) string {
+	if t.Tenant == nil {
+		return ""
+	}
+	return t.Tenant.SQLName
+}
+
+// softDeleteName returns the name of the soft delete column of the table, or
+// an empty string when the table does not soft delete rows.
+func softDeleteName(t Table) string {
+	if t.SoftDelete == nil {
+		return ""
+	}
+	return t.SoftDelete.SQLName
+}
+
+// TenantColumn returns tenant-column from the context.
+func TenantColumn(ctx context.Context) string {
+	s, _ := ctx.Value(TenantKey).(string)
+	return s
+}
+
+// SoftDeleteColumn returns soft-delete-column from the context.
+func SoftDeleteColumn(ctx context.Context) string {
+	s, _ := ctx.Value(SoftDeleteKey).(string)
+	return s
+}
+
+// OrderBy returns order-by from the context, as a map of tables to their
+// ORDER BY expression.
+func OrderBy(ctx context.Context) map[string]string {
+	m := make(map[string]string)
+	z, _ := ctx.Value(OrderByKey).([]string)
+	for _, s := range z {
+		if table, expr, ok := strings.Cut(s, "="); ok {
+			m[strings.TrimSpace(table)] = strings.TrimSpace(expr)
+		}
+	}
+	return m
+}
+
+// EnumValues returns the pinned enum const values from the context.
+func EnumValues(ctx context.Context) map[string]int {
+	m := make(map[string]int)
+	z, _ := ctx.Value(EnumValueKey).([]string)
+	for _, s := range z {
+		if name, val, ok := strings.Cut(s, "="); ok {
+			if n, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
+				m[strings.TrimSpace(name)] = n
+			}
+		}
+	}
+	return m
+}
+
+// InsertMany returns insert-many from the context.
+func InsertMany(ctx context.Context) bool {
+	b, _ := ctx.Value(InsertManyKey).(bool)
+	return b
+}
+
+// UpsertIndex returns upsert-index from the context.
+func UpsertIndex(ctx context.Context) bool {
+	b, _ := ctx.Value(UpsertIndexKey).(bool)
+	return b
+}
+
+// Paged returns paged from the context.
+func Paged(ctx context.Context) bool {
+	b, _ := ctx.Value(PagedKey).(bool)
+	return b
+}
+
+// CheckRows returns check-rows from the context.
+func CheckRows(ctx context.Context) bool {
+	b, _ := ctx.Value(CheckRowsKey).(bool)
+	return b
+}
+
+// ExecResult returns exec-result from the context.
+func ExecResult(ctx context.Context) bool {
+	b, _ := ctx.Value(ExecResultKey).(bool)
+	return b
+}
+
+// UnitOfWork returns unit-of-work from the context.
+func UnitOfWork(ctx context.Context) bool {
+	b, _ := ctx.Value(UnitOfWorkKey).(bool)
+	return b
+}
+
+// Cascade returns cascade from the context.
+func Cascade(ctx context.Context) bool {
+	b, _ := ctx.Value(CascadeKey).(bool)
+	return b
+}
+
+// PreloadDepth returns preload-depth from the context.
+func PreloadDepth(ctx context.Context) int {
+	i, _ := ctx.Value(PreloadKey).(int)
+	return i
+}
+
+// PreloadJSON returns preload-json from the context.
+func PreloadJSON(ctx context.Context) bool {
+	b, _ := ctx.Value(GraphJSONKey).(bool)
+	return b
+}
+
+// Temporal returns temporal from the context.
+func Temporal(ctx context.Context) string {
+	s, _ := ctx.Value(TemporalKey).(string)
+	return s
+}
+
+// Outbox returns outbox from the context.
+func Outbox(ctx context.Context) string {
+	s, _ := ctx.Value(OutboxKey).(string)
+	return s
+}
+
+// CDC returns cdc from the context.
+func CDC(ctx context.Context) bool {
+	b, _ := ctx.Value(CDCKey).(bool)
+	return b
+}
+
+// Notify returns notify from the context.
+func Notify(ctx context.Context) string {
+	s, _ := ctx.Value(NotifyKey).(string)
+	return s
+}
+
+// Reconcile returns reconcile from the context.
+func Reconcile(ctx context.Context) bool {
+	b, _ := ctx.Value(ReconcileKey).(bool)
+	return b
+}
+
+// Tests returns tests from the context.
+func Tests(ctx context.Context) bool {
+	b, _ := ctx.Value(TestsKey).(bool)
+	return b
+}
+
+// Explain returns explain from the context.
+func Explain(ctx context.Context) int {
+	i, _ := ctx.Value(ExplainKey).(int)
+	return i
+}
+
+// TestHelpers returns test-helpers from the context.
+func TestHelpers(ctx context.Context) bool {
+	b, _ := ctx.Value(TestHelpersKey).(bool)
+	return b
+}
+
+// GoVersion returns version from the context.
+func GoVersion(ctx context.Context) string {
+	s, _ := ctx.Value(GoVersionKey).(string)
+	return s
+}
+
 // Inject returns inject from the context.
 func Inject(ctx context.Context) string {
 	s, _ := ctx.Value(InjectKey).(string)
@@ -2247,8 +5857,75 @@ func addInitialisms(ctx context.Context) error {
 	return snaker.DefaultInitialisms.Add(v...)
 }
 
+// addIdentMap adds the identifier mappings from the context.
+func addIdentMap(ctx context.Context) error {
+	v, _ := ctx.Value(IdentMapKey).([]string)
+	for _, s := range v {
+		if s == "" {
+			continue
+		}
+		i := strings.Index(s, "=")
+		if i == -1 || strings.TrimSpace(s[:i]) == "" || strings.TrimSpace(s[i+1:]) == "" {
+			return fmt.Errorf("invalid identifier mapping %q", s)
+		}
+		identMap[strings.TrimSpace(s[:i])] = strings.TrimSpace(s[i+1:])
+	}
+	return nil
+}
+
+// checkRename checks that the renamed fields are valid go identifiers, are for
+// columns of the set, and do not collide with the names of other fields or of
+// the methods generated for the table.
+func checkRename(ctx context.Context, set *xo.Set) error {
+	v, _ := ctx.Value(RenameKey).([]string)
+	for _, s := range v {
+		if s == "" {
+			continue
+		}
+		column, name, ok := strings.Cut(s, "=")
+		column, name = strings.TrimSpace(column), strings.TrimSpace(name)
+		switch {
+		case !ok || column == "" || !identRE.MatchString(name):
+			return fmt.Errorf("invalid field rename %q", s)
+		case templateReservedNames[name] || tableMethodNames[strings.TrimSuffix(name, "Context")] || strings.HasPrefix(name, "UpsertBy"):
+			return fmt.Errorf("field rename %q: %s is the name of a generated method", s, name)
+		}
+	}
+	if len(set.Schemas) == 0 {
+		return nil
+	}
+	found := make(map[string]bool)
+	for _, schema := range set.Schemas {
+		for _, t := range append(schema.Tables, schema.Views...) {
+			// columns by field name, only reporting collisions of renamed fields
+			names := make(map[string]string)
+			for _, z := range t.Columns {
+				found[t.Name+"."+z.Name] = true
+				found[z.Name] = true
+				name := camelExport(z.Name)
+				if s := fieldRename(ctx, t.Name, z.Name); s != "" {
+					name = s
+				}
+				if prev, ok := names[name]; ok && (name != camelExport(z.Name) || name != camelExport(prev)) {
+					return fmt.Errorf("field rename: columns %s.%s and %s.%s both have the field name %s", t.Name, prev, t.Name, z.Name, name)
+				}
+				names[name] = z.Name
+			}
+		}
+	}
+	for column := range Rename(ctx) {
+		if !found[column] {
+			return fmt.Errorf("field rename: unknown column %s", column)
+		}
+	}
+	return nil
+}
+
 // singularize singularizes s.
 func singularize(s string) string {
+	if v, ok := identMap[s]; ok {
+		s = v
+	}
 	if i := strings.LastIndex(s, "_"); i != -1 {
 		return s[:i+1] + inflector.Singularize(s[i+1:])
 	}
@@ -2270,6 +5947,33 @@ type Enum struct {
 	Comment string
 }
 
+// TableTest is a table test template.
+type TableTest struct {
+	Table   Table
+	Indexes []Index
+}
+
+// Constraint is a constraint or index name template.
+type Constraint struct {
+	GoName  string
+	SQLName string
+	Kind    string
+}
+
+// Constraints is a table's constraint and index names template.
+type Constraints struct {
+	Table       Table
+	Constraints []Constraint
+}
+
+// Bitmask is a bitmask column flags type template.
+type Bitmask struct {
+	GoName  string
+	SQLName string
+	Type    string
+	Values  []EnumValue
+}
+
 // Proc is a stored procedure template.
 type Proc struct {
 	Type           string
@@ -2292,6 +5996,11 @@ type Table struct {
 	PrimaryKeys []Field
 	Fields      []Field
 	Manual      bool
+	Tenant      *Field
+	SoftDelete  *Field
+	OrderBy     string
+	ValidFrom   *Field
+	ValidTo     *Field
 	Comment     string
 }
 
@@ -2307,6 +6016,66 @@ type ForeignKey struct {
 	Comment   string
 }
 
+// Statement is a generated SQL statement of a type (insert, index, query,
+// etc) for a template.
+type Statement struct {
+	Type string
+	Data any
+}
+
+// CascadeDelete is a cascading delete template.
+type CascadeDelete struct {
+	Func  string
+	Table Table
+	Paths [][]CascadeStep
+}
+
+// CascadeStep is a foreign key followed by a cascading delete.
+type CascadeStep struct {
+	Table      string
+	Field      string
+	RefTable   string
+	RefField   string
+	Tenant     string
+	SoftDelete string
+}
+
+// Graph is a graph type template, holding a table's row and the rows of its
+// relations.
+type Graph struct {
+	Table     Table
+	Relations []Relation
+	JSON      bool
+}
+
+// Relation is a foreign key relation from the rows of a table.
+type Relation struct {
+	Name     string
+	Key      string
+	Table    Table
+	Field    Field
+	RefField Field
+	JSON     bool
+}
+
+// Preload is a graph loading template.
+type Preload struct {
+	Func  string
+	Table Table
+	Depth int
+	Nodes []PreloadNode
+	JSON  bool
+}
+
+// PreloadNode is a relation loaded by a preload.
+type PreloadNode struct {
+	Table       Table
+	Parent      int
+	ParentTable Table
+	Relation    Relation
+	Path        []CascadeStep
+}
+
 // Index is an index template.
 type Index struct {
 	SQLName   string
@@ -2315,6 +6084,12 @@ type Index struct {
 	Fields    []Field
 	IsUnique  bool
 	IsPrimary bool
+	Redacted  bool
+	Scoped    bool
+	AsOf      bool
+	Paged     bool
+	Keyset    bool
+	Page      []Field
 	Comment   string
 }
 
@@ -2322,10 +6097,14 @@ type Index struct {
 type Field struct {
 	GoName     string
 	SQLName    string
+	SQLType    string
 	Type       string
 	Zero       string
 	IsPrimary  bool
 	IsSequence bool
+	InsertOnly bool
+	UpdateOnly bool
+	Bitmask    *Bitmask
 	Comment    string
 }
 
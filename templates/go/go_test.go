@@ -0,0 +1,1444 @@
+//go:build dbtpl
+
+package gotpl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	xo "github.com/xo/dbtpl/types"
+)
+
+func TestSafeName(t *testing.T) {
+	tests := []struct {
+		name string
+		exp  string
+	}{
+		{"authorID", "authorID"},
+		{"type", "typ"},
+		{"func", "fn"},
+		{"string", "str"},
+		{"sqlstr", "sqlstrVal"},
+		{"rows", "rowsVal"},
+		{"res", "resVal"},
+		{"err", "errVal"},
+		{"ctx", "ctxVal"},
+		{"db", "dbVal"},
+		{"logf", "logfVal"},
+		{"time", "timeVal"},
+		{"Rows", "Rows"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if s := safeName(test.name, "Val"); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
+
+func TestParamConflict(t *testing.T) {
+	f := &Funcs{
+		conflict:   "Val",
+		knownTypes: map[string]bool{"string": true, "int": true},
+		shorts:     map[string]string{},
+	}
+	tests := []struct {
+		field Field
+		exp   string
+	}{
+		{Field{GoName: "AuthorID", Type: "int"}, "authorID int"},
+		{Field{GoName: "Type", Type: "string"}, "typ string"},
+		{Field{GoName: "Sqlstr", Type: "string"}, "sqlstrVal string"},
+		{Field{GoName: "Rows", Type: "int"}, "rowsVal int"},
+		{Field{GoName: "Err", Type: "string"}, "errVal string"},
+		{Field{GoName: "Ctx", Type: "string"}, "ctxVal string"},
+	}
+	for _, test := range tests {
+		t.Run(test.field.GoName, func(t *testing.T) {
+			if s := f.param(test.field, true); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+	// ensure names generated for locals match
+	returns := []Field{{GoName: "sqlstr"}, {GoName: "rows"}, {GoName: "count"}}
+	if s, exp := f.names("&", returns), "&sqlstrVal, &rowsVal, &count"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	identMap["名前"] = "name"
+	defer delete(identMap, "名前")
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{"author_id", "AuthorID"},
+		{"café", "Cafe"},
+		{"straße", "Strasse"},
+		{"größe_total", "GroesseTotal"},
+		{"données_clients", "DonneesClients"},
+		{"über_id", "UeberID"},
+		{"имя", "Imya"},
+		{"αβγ", "Abg"},
+		{"名前", "Name"},
+		{"名前_id", "NameID"},
+		{"顧客", "U9867u5ba2"},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			if s := camelExport(test.s); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
+
+func TestDBIface(t *testing.T) {
+	f := &Funcs{driver: "postgres", dbtype: "DB", narrow: true}
+	tests := []struct {
+		name string
+		t    Table
+		v    any
+		exp  string
+	}{
+		{"insert", Table{}, "Insert", "RowQuerier"},
+		{"insert manual", Table{Manual: true}, "Insert", "Execer"},
+		{"update", Table{}, "Update", "Execer"},
+		{"delete", Table{}, "Delete", "Execer"},
+		{"destroy", Table{}, "Destroy", "Execer"},
+		{"upsert", Table{}, "Upsert", "Execer"},
+		{"upsert tenant", Table{Tenant: &Field{}}, "Upsert", "Execer"},
+		{"upsert by index", Table{Tenant: &Field{}}, "UpsertByName", "RowQuerier"},
+		{"save", Table{}, "Save", "DB"},
+		{"index unique", Table{}, Index{IsUnique: true}, "RowQuerier"},
+		{"index", Table{}, Index{}, "Querier"},
+		{"foreign key", Table{}, ForeignKey{}, "RowQuerier"},
+		{"proc void", Table{}, Proc{Void: true}, "Execer"},
+		{"query exec", Table{}, Query{Exec: true}, "Execer"},
+		{"query one", Table{}, Query{One: true}, "RowQuerier"},
+		{"query", Table{}, Query{}, "Querier"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if s := f.dbIface(test.t, test.v); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+	f.narrow = false
+	if s := f.dbIface(Table{}, "Insert"); s != "DB" {
+		t.Errorf("expected %q, got: %q", "DB", s)
+	}
+}
+
+func TestShardFunc(t *testing.T) {
+	f := &Funcs{context: "only", knownTypes: map[string]bool{"int": true}, shorts: map[string]string{}}
+	i := Index{
+		Func:     "AuthorByAuthorID",
+		Table:    Table{GoName: "Author"},
+		Fields:   []Field{{GoName: "AuthorID", Type: "int"}},
+		IsUnique: true,
+	}
+	if s, exp := f.shard_func(i), "func (r *ShardRouter) AuthorByAuthorID(ctx context.Context, authorID int) (*Author, error)"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	f.context, f.ctxpos, i.IsUnique = "both", "last", false
+	if s, exp := f.shard_func(i), "func (r *ShardRouter) AuthorByAuthorIDContext(authorID int, ctx context.Context) ([]*Author, error)"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestQueryHint(t *testing.T) {
+	f := &Funcs{hint: template.Must(template.New("hint").Parse("app=checkout op={{ .Name }} table={{ .Table }} */"))}
+	tests := []struct {
+		typ string
+		v   any
+		exp string
+	}{
+		{"insert_manual", Table{GoName: "Author", SQLName: "authors"}, "/*+ app=checkout op=Author.Insert table=authors * / */ "},
+		{"index", Index{Func: "AuthorByAuthorID", Table: Table{SQLName: "authors"}}, "/*+ app=checkout op=AuthorByAuthorID table=authors * / */ "},
+		{"query", Query{Name: "AuthorCount"}, "/*+ app=checkout op=AuthorCount table= * / */ "},
+		{"preload_json", Preload{Func: "LoadAuthorGraph", Table: Table{SQLName: "authors"}}, "/*+ app=checkout op=LoadAuthorGraph table=authors * / */ "},
+		{"preload", PreloadBlock{Preload: Preload{Func: "LoadAuthorGraph"}, Node: PreloadNode{Table: Table{SQLName: "books"}}}, "/*+ app=checkout op=LoadAuthorGraph table=books * / */ "},
+	}
+	for _, test := range tests {
+		t.Run(test.typ, func(t *testing.T) {
+			s, err := f.queryHint(test.typ, test.v)
+			switch {
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case s != test.exp:
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
+
+func TestQueryID(t *testing.T) {
+	f := &Funcs{queryID: true}
+	a := f.query_id("query", Query{Name: "AuthorCount", Query: []string{"SELECT count(*)", "  FROM authors"}})
+	b := f.query_id("query", Query{Name: "AuthorCount", Query: []string{"SELECT count(*) FROM", "authors"}})
+	if a != b {
+		t.Errorf("expected query ids to match, got: %q and %q", a, b)
+	}
+	if !strings.Contains(a, "const AuthorCountQueryID = ") {
+		t.Errorf("expected AuthorCountQueryID const, got: %q", a)
+	}
+	f.queryID = false
+	if s := f.query_id("query", Query{Name: "AuthorCount"}); s != "" {
+		t.Errorf("expected empty string, got: %q", s)
+	}
+}
+
+func TestQueryInfo(t *testing.T) {
+	f := &Funcs{knownTypes: map[string]bool{"int": true, "string": true}}
+	q := Query{
+		Name:   "AuthorCount",
+		Query:  []string{"SELECT count(*) ", "FROM authors WHERE name = $1"},
+		Params: []QueryParam{{Name: "name", Type: "string"}},
+		Flat:   true,
+		Type:   Table{Fields: []Field{{GoName: "Count", Type: "int"}}},
+	}
+	s, err := f.query_info("query", q)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := `{Name: "AuthorCount", SQL: "SELECT count(*) FROM authors WHERE name = $1", Args: []string{"string"}, Result: "int"}`
+	if s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestEncryptField(t *testing.T) {
+	ctx := context.WithValue(context.Background(), EncryptKey, []string{"", "users.ssn", "secret"})
+	if v := Encrypt(ctx); len(v) != 2 {
+		t.Fatalf("expected 2 encrypted columns, got: %d", len(v))
+	}
+	tests := []struct {
+		table    string
+		name     string
+		typ      string
+		nullable bool
+		base64   bool
+		exp      string
+		err      bool
+	}{
+		{"users", "ssn", "bytea", false, false, "EncryptedString", false},
+		{"users", "ssn", "blob", true, false, "NullEncryptedString", false},
+		{"accounts", "ssn", "integer", false, false, "[]byte", false},
+		{"accounts", "secret", "varbinary", false, false, "EncryptedString", false},
+		{"users", "name", "bytea", true, false, "[]byte", false},
+		{"users", "ssn", "text", false, false, "", true},
+		{"users", "ssn", "text", false, true, "EncryptedString", false},
+		{"users", "ssn", "character varying", true, true, "NullEncryptedString", false},
+		{"users", "ssn", "integer", false, true, "", true},
+		{"users", "ssn", "uuid", false, true, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.table+"."+test.name+"."+test.typ, func(t *testing.T) {
+			ctx := context.WithValue(ctx, EncryptBase64Key, test.base64)
+			z := xo.Field{Name: test.name, Type: xo.Type{Type: test.typ, Nullable: test.nullable}}
+			f, err := encryptField(ctx, test.table, z, Field{SQLName: test.name, Type: "[]byte"})
+			switch {
+			case test.err && err == nil:
+				t.Fatalf("expected error")
+			case test.err:
+				return
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if f.Type != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, f.Type)
+			}
+		})
+	}
+	fields := []xo.Field{{Name: "name"}, {Name: "ssn"}}
+	if name, ok := encryptedColumn(ctx, "users", fields); !ok || name != "ssn" {
+		t.Errorf("expected encrypted column ssn, got: %q", name)
+	}
+	if name, ok := encryptedColumn(ctx, "accounts", fields); ok {
+		t.Errorf("expected no encrypted column, got: %q", name)
+	}
+}
+
+func TestFallbackType(t *testing.T) {
+	tests := []struct {
+		typ    string
+		goType string
+		exp    bool
+	}{
+		{"bytea", "[]byte", false},
+		{"json", "[]byte", false},
+		{"jsonb", "[]byte", false},
+		{"XML", "[]byte", false},
+		{"interval", "[]byte", false},
+		{"bit varying", "[]byte", true},
+		{"any", "[]byte", true},
+		{"geometry", "Geometry", false},
+	}
+	for _, test := range tests {
+		t.Run(test.typ, func(t *testing.T) {
+			if b := fallbackType(xo.Type{Type: test.typ}, test.goType); b != test.exp {
+				t.Errorf("expected %t, got: %t", test.exp, b)
+			}
+		})
+	}
+}
+
+func TestRedactIndex(t *testing.T) {
+	ctx := context.WithValue(context.Background(), SensitiveKey, []string{"users.ssn", "dob"})
+	index := Index{
+		Func: "UsersByName",
+		Table: Table{
+			SQLName: "users",
+			Fields:  []Field{{SQLName: "user_id"}, {SQLName: "name"}, {SQLName: "ssn"}, {SQLName: "dob"}},
+		},
+	}
+	redacted, ok := redactIndex(ctx, index)
+	switch {
+	case !ok:
+		t.Fatalf("expected redacted index")
+	case redacted.Func != "UsersByNameRedacted" || !redacted.Redacted:
+		t.Errorf("expected UsersByNameRedacted, got: %q", redacted.Func)
+	case len(redacted.Table.Fields) != 2:
+		t.Errorf("expected 2 fields, got: %d", len(redacted.Table.Fields))
+	case len(index.Table.Fields) != 4:
+		t.Errorf("expected original index to be unchanged")
+	}
+	index.Table.SQLName, index.Table.Fields = "books", []Field{{SQLName: "ssn"}}
+	if _, ok := redactIndex(ctx, index); ok {
+		t.Errorf("expected no redacted index")
+	}
+}
+
+func TestTenantDelete(t *testing.T) {
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	tenant := Field{GoName: "TenantID", SQLName: "tenant_id", Type: "int"}
+	table := Table{
+		SQLName:     "books",
+		PrimaryKeys: []Field{{GoName: "BookID", SQLName: "book_id", Type: "int"}},
+		Tenant:      &tenant,
+	}
+	exp := "DELETE FROM books WHERE book_id = $1 AND tenant_id = $2"
+	if s := strings.Join(f.sqlstr_delete(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	table.Tenant = nil
+	exp = "DELETE FROM books WHERE book_id = $1"
+	if s := strings.Join(f.sqlstr_delete(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestTenantUpsert(t *testing.T) {
+	tenant := Field{GoName: "TenantID", SQLName: "tenant_id", Type: "int"}
+	pk := Field{GoName: "BookID", SQLName: "book_id", Type: "int", IsPrimary: true}
+	table := Table{
+		SQLName:     "books",
+		PrimaryKeys: []Field{pk},
+		Fields:      []Field{pk, tenant, {GoName: "Title", SQLName: "title", Type: "string"}},
+		Tenant:      &tenant,
+	}
+	tests := []struct {
+		driver string
+		exp    string
+	}{
+		{"postgres", "INSERT INTO books (book_id, tenant_id, title) VALUES ($1, $2, $3) ON CONFLICT (book_id) DO UPDATE SET title = EXCLUDED.title WHERE books.tenant_id = EXCLUDED.tenant_id"},
+		{"mysql", "INSERT INTO books (book_id, tenant_id, title) VALUES ($1, $2, $3) ON DUPLICATE KEY UPDATE book_id = IF(tenant_id = VALUES(tenant_id), VALUES(book_id), book_id), title = IF(tenant_id = VALUES(tenant_id), VALUES(title), title)"},
+		{"sqlserver", "MERGE books AS t USING (SELECT $1 book_id, $2 tenant_id, $3 title ) AS s ON s.book_id = t.book_id WHEN MATCHED AND t.tenant_id = s.tenant_id THEN UPDATE SET t.title = s.title WHEN NOT MATCHED THEN INSERT (book_id, tenant_id, title) VALUES (s.book_id, s.tenant_id, s.title);"},
+		{"oracle", "MERGE bookst USING (SELECT $1 book_id, $2 tenant_id, $3 title FROM DUAL ) s ON s.book_id = t.book_id WHEN MATCHED THEN UPDATE SET t.title = s.title WHERE t.tenant_id = s.tenant_id WHEN NOT MATCHED THEN INSERT (book_id, tenant_id, title) VALUES (s.book_id, s.tenant_id, s.title);"},
+	}
+	for _, test := range tests {
+		f := &Funcs{driver: test.driver, nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+		if s := strings.Join(f.sqlstr_upsert(table), ""); s != test.exp {
+			t.Errorf("%s: expected %q, got: %q", test.driver, test.exp, s)
+		}
+	}
+	// the tenant is only bound in the WHERE clause
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	exp := "UPDATE books SET title = $1 WHERE book_id = $2 AND tenant_id = $3"
+	if s := strings.Join(f.sqlstr_update(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	exp = "SELECT COUNT(*) FROM books WHERE book_id = $1 AND tenant_id = $2"
+	if s := strings.Join(f.sqlstr_tenant_check(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.WithValue(context.Background(), SoftDeleteKey, "deleted_at")
+	fields := []Field{
+		{GoName: "BookID", SQLName: "book_id", Type: "int", IsPrimary: true},
+		{GoName: "AuthorID", SQLName: "author_id", Type: "int"},
+		{GoName: "DeletedAt", SQLName: "deleted_at", Type: "sql.NullTime"},
+	}
+	table := Table{SQLName: "books", Fields: fields, PrimaryKeys: fields[:1]}
+	if table.SoftDelete = softDeleteField(ctx, "books", fields); table.SoftDelete == nil {
+		t.Fatalf("expected soft delete field")
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	exp := "UPDATE books SET deleted_at = CURRENT_TIMESTAMP WHERE book_id = $1 AND deleted_at IS NULL"
+	if s := strings.Join(f.sqlstr_delete(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	exp = "DELETE FROM books WHERE book_id = $1"
+	if s := strings.Join(f.sqlstr_destroy(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	index := Index{Table: table, Fields: fields[1:2]}
+	exp = "WHERE author_id = $1 AND deleted_at IS NULL"
+	if s := f.sqlstr_index(index)[3]; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	fields[2].Type = "time.Time"
+	if z := softDeleteField(ctx, "books", fields); z != nil {
+		t.Errorf("expected no soft delete field for non-nullable column")
+	}
+}
+
+func TestSplitRegion(t *testing.T) {
+	tests := []struct {
+		data   string
+		before string
+		after  string
+		err    bool
+	}{
+		{"package a\n\nfunc A() {}\n", "package a\n\nfunc A() {}\n", "", false},
+		{"package a\n\nfunc A() {}\n\n// dbtpl:begin\n\nfunc B() {}\n\n// dbtpl:end\n\nfunc C() {}\n", "package a\n\nfunc A() {}\n", "\nfunc C() {}\n", false},
+		{"package a\n// dbtpl:begin\n", "", "", true},
+		{"package a\n// dbtpl:end\n// dbtpl:begin\n", "", "", true},
+		{"package a\n// dbtpl:begin\n// dbtpl:end\n// dbtpl:begin\n// dbtpl:end\n", "", "", true},
+		{"// Code generated by dbtpl. DO NOT EDIT.\n\npackage a\n\nfunc A() {}\n", "", "", true},
+		{"// Code generated by dbtpl. DO NOT EDIT.\n\npackage a\n\n// dbtpl:begin\n\nfunc A() {}\n\n// dbtpl:end\n", "// Code generated by dbtpl. DO NOT EDIT.\n\npackage a\n", "", false},
+	}
+	for i, test := range tests {
+		before, after, err := splitRegion([]byte(test.data))
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d: expected error", i)
+		case !test.err && err != nil:
+			t.Errorf("test %d: expected no error, got: %v", i, err)
+		case before != test.before || after != test.after:
+			t.Errorf("test %d: expected %q, %q, got: %q, %q", i, test.before, test.after, before, after)
+		}
+	}
+}
+
+func TestExecOp(t *testing.T) {
+	f := new(Funcs)
+	tests := []struct {
+		query []string
+		exp   string
+	}{
+		{[]string{"DELETE FROM authors ", "WHERE author_id = $1"}, "DELETE"},
+		{[]string{"\n  update authors SET name = $1"}, "UPDATE"},
+		{[]string{"WITH old AS (SELECT author_id FROM authors WHERE name = ')') ", "DELETE FROM books USING old WHERE books.author_id = old.author_id"}, "DELETE"},
+		{[]string{"with recursive \"update\"(id) as materialized (select 1 union select id + 1 from \"update\") insert into ids select id from \"update\""}, "INSERT"},
+		{[]string{"WITH a AS (UPDATE authors SET name = $1 RETURNING author_id), b AS (SELECT 1)", " SELECT * FROM a"}, "SELECT"},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		if s := f.exec_op(Query{Query: test.query}); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+}
+
+func TestUowTables(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "sqlite3")
+	id := func(name string) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: "integer"}, IsPrimary: true}
+	}
+	fk := func(ref string) xo.ForeignKey {
+		return xo.ForeignKey{RefTable: ref}
+	}
+	schema := xo.Schema{
+		Tables: []xo.Table{
+			{Name: "book_tags", Columns: []xo.Field{id("book_tag_id")}, ForeignKeys: []xo.ForeignKey{fk("books"), fk("tags")}},
+			{Name: "books", Columns: []xo.Field{id("book_id")}, ForeignKeys: []xo.ForeignKey{fk("authors")}},
+			{Name: "authors", Columns: []xo.Field{id("author_id")}},
+			{Name: "logs", Columns: []xo.Field{{Name: "message", Type: xo.Type{Type: "text"}}}},
+			{Name: "tags", Columns: []xo.Field{id("tag_id")}},
+		},
+	}
+	tables, err := uowTables(ctx, schema)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.SQLName)
+	}
+	if s, exp := strings.Join(names, " "), "authors books tags book_tags"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestCascadeStmts(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "sqlite3")
+	ctx = context.WithValue(ctx, Int32Key, "int")
+	field := func(name string, pk bool) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: "integer"}, IsPrimary: pk}
+	}
+	fk := func(name, ref, refName string) xo.ForeignKey {
+		return xo.ForeignKey{Fields: []xo.Field{field(name, false)}, RefTable: ref, RefFields: []xo.Field{field(refName, true)}}
+	}
+	schema := xo.Schema{
+		Tables: []xo.Table{
+			{Name: "authors", Columns: []xo.Field{field("author_id", true)}},
+			{Name: "books", Columns: []xo.Field{field("book_id", true), field("author_id", false)}, ForeignKeys: []xo.ForeignKey{fk("author_id", "authors", "author_id")}},
+			{Name: "book_tags", Columns: []xo.Field{field("book_id", true), field("tag", true)}, ForeignKeys: []xo.ForeignKey{fk("book_id", "books", "book_id")}},
+		},
+	}
+	deletes, err := cascades(ctx, schema)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(deletes) != 2 {
+		t.Fatalf("expected 2 cascading deletes, got: %d", len(deletes))
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	exp := "`DELETE FROM book_tags WHERE book_id IN (SELECT book_id FROM books WHERE author_id = $1)`,\n" +
+		"\t\t`DELETE FROM books WHERE author_id = $1`,\n" +
+		"\t\t`DELETE FROM authors WHERE author_id = $1`,"
+	if s := f.cascade_stmts(deletes[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// tenant scoped
+	ctx = context.WithValue(ctx, TenantKey, "tenant_id")
+	schema.Tables[0].Columns = append(schema.Tables[0].Columns, field("tenant_id", false))
+	if deletes, err = cascades(ctx, schema); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = "`DELETE FROM book_tags WHERE book_id IN (SELECT book_id FROM books WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2))`,\n" +
+		"\t\t`DELETE FROM books WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2)`,\n" +
+		"\t\t`DELETE FROM authors WHERE author_id = $1 AND tenant_id = $2`,"
+	if s := f.cascade_stmts(deletes[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// tenant scoped dependents
+	schema.Tables[1].Columns = append(schema.Tables[1].Columns, field("tenant_id", false))
+	if deletes, err = cascades(ctx, schema); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = "`DELETE FROM book_tags WHERE book_id IN (SELECT book_id FROM books WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2) AND tenant_id = $2)`,\n" +
+		"\t\t`DELETE FROM books WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2) AND tenant_id = $2`,\n" +
+		"\t\t`DELETE FROM authors WHERE author_id = $1 AND tenant_id = $2`,"
+	if s := f.cascade_stmts(deletes[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// catalog
+	infos, err := f.query_infos("cascade", deletes[0])
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(infos) != 3:
+		t.Fatalf("expected 3 query infos, got: %d", len(infos))
+	}
+	exp = `{Name: "DeleteAuthorCascade", SQL: "DELETE FROM authors WHERE author_id = $1 AND tenant_id = $2", Args: []string{"int", "int"}, Result: ""}`
+	if infos[2] != exp {
+		t.Errorf("expected %q, got: %q", exp, infos[2])
+	}
+	// soft deleted rows
+	ctx = context.WithValue(ctx, SoftDeleteKey, "deleted_at")
+	for i := range schema.Tables[:2] {
+		schema.Tables[i].Columns = append(schema.Tables[i].Columns, xo.Field{Name: "deleted_at", Type: xo.Type{Type: "timestamp", Nullable: true}})
+	}
+	if deletes, err = cascades(ctx, schema); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = "`DELETE FROM book_tags WHERE book_id IN (SELECT book_id FROM books WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2) AND tenant_id = $2)`,\n" +
+		"\t\t`UPDATE books SET deleted_at = CURRENT_TIMESTAMP WHERE author_id IN (SELECT author_id FROM authors WHERE author_id = $1 AND tenant_id = $2) AND tenant_id = $2 AND deleted_at IS NULL`,\n" +
+		"\t\t`UPDATE authors SET deleted_at = CURRENT_TIMESTAMP WHERE author_id = $1 AND tenant_id = $2 AND deleted_at IS NULL`,"
+	if s := f.cascade_stmts(deletes[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestBuildPreloads(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "sqlite3")
+	field := func(name string, pk bool) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: "integer"}, IsPrimary: pk}
+	}
+	fk := func(name, ref, refName string) xo.ForeignKey {
+		return xo.ForeignKey{Fields: []xo.Field{field(name, false)}, RefTable: ref, RefFields: []xo.Field{field(refName, true)}}
+	}
+	schema := xo.Schema{
+		Tables: []xo.Table{
+			{Name: "authors", Columns: []xo.Field{field("author_id", true)}},
+			{Name: "books", Columns: []xo.Field{field("book_id", true), field("author_id", false), field("editor_id", false)}, ForeignKeys: []xo.ForeignKey{fk("author_id", "authors", "author_id"), fk("editor_id", "authors", "author_id")}},
+			{Name: "reviews", Columns: []xo.Field{field("review_id", true), field("book_id", false)}, ForeignKeys: []xo.ForeignKey{fk("book_id", "books", "book_id")}},
+		},
+	}
+	graphs, preloads, err := buildPreloads(ctx, schema, 1)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(graphs) != 3:
+		t.Fatalf("expected 3 graphs, got: %d", len(graphs))
+	case len(preloads) != 2:
+		t.Fatalf("expected 2 preloads, got: %d", len(preloads))
+	}
+	var names []string
+	for _, rel := range graphs[0].Relations {
+		names = append(names, rel.Name)
+	}
+	if s, exp := strings.Join(names, " "), "BooksByAuthorID BooksByEditorID"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if n := len(preloads[0].Nodes); n != 3 {
+		t.Errorf("expected 3 nodes, got: %d", n)
+	}
+}
+
+func TestPreloadSplit(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "sqlite3")
+	ctx = context.WithValue(ctx, Int32Key, "int")
+	field := func(name string, pk bool) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: "integer"}, IsPrimary: pk}
+	}
+	fk := func(name, ref, refName string) xo.ForeignKey {
+		return xo.ForeignKey{Fields: []xo.Field{field(name, false)}, RefTable: ref, RefFields: []xo.Field{field(refName, true)}}
+	}
+	schema := xo.Schema{
+		Tables: []xo.Table{
+			{Name: "authors", Columns: []xo.Field{field("author_id", true)}},
+			{Name: "books", Columns: []xo.Field{field("book_id", true), field("author_id", false)}, ForeignKeys: []xo.ForeignKey{fk("author_id", "authors", "author_id")}},
+			{Name: "reviews", Columns: []xo.Field{field("review_id", true), field("book_id", false)}, ForeignKeys: []xo.ForeignKey{fk("book_id", "books", "book_id")}},
+		},
+	}
+	_, preloads, err := buildPreloads(ctx, schema, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	p := preloads[0]
+	if n := len(p.Nodes); n != 3 {
+		t.Fatalf("expected 3 nodes, got: %d", n)
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }, context: "only", dbtype: "DB"}
+	// 3 scan blocks of 30, 34 and 34 lines, and the return
+	for _, test := range []struct {
+		maxLines int
+		exp      bool
+	}{
+		{0, false},
+		{99, false},
+		{98, true},
+	} {
+		if f.maxLines = test.maxLines; f.preload_split(p) != test.exp {
+			t.Errorf("max lines %d: expected %t", test.maxLines, test.exp)
+		}
+	}
+	if !preload_parent(p, 1) || preload_parent(p, 2) {
+		t.Errorf("expected books to be the only parent node")
+	}
+	exp := "func loadAuthorGraphNode2(ctx context.Context, db DB, g1 []*BookGraph, authorID int) ([]*ReviewGraph, error)"
+	if s := f.preload_node(p, 2); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	exp = "loadAuthorGraphNode2(ctx, db, g1, authorID)"
+	if s := f.preload_node_call(p, 2); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestPreloadJSONSqlstr(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	ctx = context.WithValue(ctx, GraphJSONKey, true)
+	ctx = context.WithValue(ctx, Int32Key, "int")
+	field := func(name string, pk bool) xo.Field {
+		return xo.Field{Name: name, Type: xo.Type{Type: "integer"}, IsPrimary: pk}
+	}
+	schema := xo.Schema{
+		Tables: []xo.Table{
+			{Name: "authors", Columns: []xo.Field{field("author_id", true)}},
+			{Name: "books", Columns: []xo.Field{field("book_id", true), field("author_id", false)}, ForeignKeys: []xo.ForeignKey{
+				{Fields: []xo.Field{field("author_id", false)}, RefTable: "authors", RefFields: []xo.Field{field("author_id", true)}},
+			}},
+		},
+	}
+	_, preloads, err := buildPreloads(ctx, schema, 1)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case len(preloads) != 1 || !preloads[0].JSON:
+		t.Fatalf("expected 1 JSON preload")
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	exp := "const sqlstr = `SELECT to_jsonb(t0) || jsonb_build_object('books', COALESCE((SELECT jsonb_agg(to_jsonb(t1)) FROM books t1 WHERE t1.author_id = t0.author_id), '[]')) ` +\n" +
+		"\t`FROM authors t0 ` +\n" +
+		"\t`WHERE t0.author_id = $1`"
+	if s, _ := f.preload_json_sqlstr(preloads[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// default order
+	ctx = context.WithValue(ctx, OrderByKey, []string{"books=book_id DESC"})
+	if _, preloads, err = buildPreloads(ctx, schema, 1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = strings.Replace(exp, "jsonb_agg(to_jsonb(t1))", "jsonb_agg(to_jsonb(t1) ORDER BY book_id DESC)", 1)
+	if s, _ := f.preload_json_sqlstr(preloads[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// tenant scoped
+	ctx = context.WithValue(ctx, TenantKey, "tenant_id")
+	schema.Tables[0].Columns = append(schema.Tables[0].Columns, field("tenant_id", false))
+	if _, preloads, err = buildPreloads(ctx, schema, 1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = strings.Replace(exp, "t0.author_id = $1", "t0.author_id = $1 AND t0.tenant_id = $2", 1)
+	if s, _ := f.preload_json_sqlstr(preloads[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// soft deleted rows omitted
+	ctx = context.WithValue(ctx, SoftDeleteKey, "deleted_at")
+	for i := range schema.Tables {
+		schema.Tables[i].Columns = append(schema.Tables[i].Columns, xo.Field{Name: "deleted_at", Type: xo.Type{Type: "timestamp with time zone", Nullable: true}})
+	}
+	if _, preloads, err = buildPreloads(ctx, schema, 1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = strings.Replace(exp, "t1.author_id = t0.author_id)", "t1.author_id = t0.author_id AND t1.deleted_at IS NULL)", 1)
+	exp = strings.Replace(exp, "t0.tenant_id = $2", "t0.tenant_id = $2 AND t0.deleted_at IS NULL", 1)
+	if s, _ := f.preload_json_sqlstr(preloads[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// query hint
+	f.hint = template.Must(template.New("hint").Parse("op={{ .Name }}"))
+	exp = strings.Replace(exp, "`SELECT", "`/*+ op=LoadAuthorGraph */ ` +\n\t`SELECT", 1)
+	if s, _ := f.preload_json_sqlstr(preloads[0]); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestCheckDBType(t *testing.T) {
+	tests := []struct {
+		typ    string
+		narrow bool
+		err    bool
+	}{
+		{"DB", true, false},
+		{"Querier", false, false},
+		{"Querier", true, true},
+		{"Execer", true, true},
+		{"RowQuerier", true, true},
+	}
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), DBTypeKey, test.typ)
+		ctx = context.WithValue(ctx, NarrowDBKey, test.narrow)
+		if err := checkDBType(ctx); (err != nil) != test.err {
+			t.Errorf("%s narrow %t: expected error %t, got: %v", test.typ, test.narrow, test.err, err)
+		}
+	}
+}
+
+func TestGraphJSON(t *testing.T) {
+	var warnings []xo.Warning
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	ctx = context.WithValue(ctx, GraphJSONKey, true)
+	ctx = context.WithValue(ctx, Int32Key, "int")
+	ctx = context.WithValue(ctx, xo.WarnKey, func(w xo.Warning) {
+		if w.Kind == "skip" {
+			warnings = append(warnings, w)
+		}
+	})
+	tbl := xo.Table{Name: "authors", Columns: []xo.Field{
+		{Name: "author_id", Type: xo.Type{Type: "integer"}, IsPrimary: true},
+		{Name: "name", Type: xo.Type{Type: "text"}},
+	}}
+	table, err := convertTable(ctx, tbl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !graphJSON(ctx, xo.Schema{}, tbl, table) || len(warnings) != 0 {
+		t.Errorf("expected JSON preloads without warnings, got: %v", warnings)
+	}
+	// each skipped column is reported
+	tbl.Columns = append(tbl.Columns,
+		xo.Field{Name: "bio", Type: xo.Type{Type: "text", Nullable: true}},
+		xo.Field{Name: "born_at", Type: xo.Type{Type: "timestamp without time zone"}},
+	)
+	if table, err = convertTable(ctx, tbl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if graphJSON(ctx, xo.Schema{}, tbl, table) {
+		t.Errorf("expected no JSON preloads")
+	}
+	if len(warnings) != 2 || warnings[0].Name != "authors.bio" || warnings[1].Name != "authors.born_at" {
+		t.Errorf("expected warnings for bio and born_at, got: %v", warnings)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	ctx := context.WithValue(context.Background(), OrderByKey, []string{"", "books = created_at DESC"})
+	if m := OrderBy(ctx); len(m) != 1 || m["books"] != "created_at DESC" {
+		t.Fatalf("expected books order, got: %v", m)
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	fields := []Field{
+		{GoName: "BookID", SQLName: "book_id", Type: "int"},
+		{GoName: "AuthorID", SQLName: "author_id", Type: "int"},
+	}
+	table := Table{SQLName: "books", Fields: fields, OrderBy: OrderBy(ctx)["books"]}
+	tests := []struct {
+		index Index
+		exp   string
+	}{
+		{Index{Table: table, Fields: fields[1:]}, "SELECT book_id, author_id FROM books WHERE author_id = $1 ORDER BY created_at DESC"},
+		{Index{Table: table, Fields: fields[:1], IsUnique: true}, "SELECT book_id, author_id FROM books WHERE book_id = $1"},
+	}
+	for _, test := range tests {
+		if s := strings.Join(f.sqlstr_index(test.index), ""); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+	// paged by offset, ordered by primary key to break ties
+	table.PrimaryKeys = fields[:1]
+	for _, test := range []struct {
+		orderBy string
+		exp     string
+	}{
+		{"created_at DESC", "SELECT book_id, author_id FROM books WHERE author_id = $1 ORDER BY created_at DESC, book_id LIMIT $2 OFFSET $3"},
+		{"created_at DESC, Book_ID DESC", "SELECT book_id, author_id FROM books WHERE author_id = $1 ORDER BY created_at DESC, Book_ID DESC LIMIT $2 OFFSET $3"},
+		{"", "SELECT book_id, author_id FROM books WHERE author_id = $1 ORDER BY book_id LIMIT $2 OFFSET $3"},
+	} {
+		table.OrderBy = test.orderBy
+		if s := strings.Join(f.sqlstr_index_paged(Index{Table: table, Fields: fields[1:]}), ""); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+	set := &xo.Set{Schemas: []xo.Schema{{Tables: []xo.Table{{Name: "books"}}}}}
+	if err := checkOrderBy(ctx, set); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if err := checkOrderBy(context.WithValue(ctx, OrderByKey, []string{"books="}), set); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	one, two, also := 1, 2, 2
+	e := xo.Enum{Name: "book_type", Values: []xo.Field{
+		{Name: "fiction", ConstValue: &one},
+		{Name: "poetry", ConstValue: &two},
+		{Name: "nonfiction", ConstValue: &also},
+	}}
+	var warnings []xo.Warning
+	ctx := context.WithValue(context.Background(), xo.WarnKey, func(w xo.Warning) {
+		warnings = append(warnings, w)
+	})
+	enum, err := convertEnum(ctx, e)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := enum.Values; v[0].ConstValue != 1 || v[1].ConstValue != 2 || v[2].ConstValue != 3 {
+		t.Errorf("expected sequential values, got: %v", v)
+	}
+	if len(warnings) != 1 || warnings[0].Kind != "renumber" {
+		t.Errorf("expected renumber warning, got: %v", warnings)
+	}
+	// regenerate over previous output
+	dir := t.TempDir()
+	prev := "const (\n\tBookTypeFiction BookType = 1\n\tBookTypePoetry BookType = 2\n\tBookTypeNonfiction BookType = 2\n)\n"
+	if err := os.WriteFile(filepath.Join(dir, "booktype.dbtpl.go"), []byte(prev), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnings = nil
+	checkRenumber(context.WithValue(ctx, xo.OutKey, dir), enum, "booktype.dbtpl.go")
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "book_type.nonfiction=2") {
+		t.Errorf("expected renumber warning, got: %v", warnings)
+	}
+	// pin values
+	ctx = context.WithValue(ctx, EnumValueKey, []string{"book_type.nonfiction=2", "book_type.poetry=4"})
+	set := &xo.Set{Schemas: []xo.Schema{{Enums: []xo.Enum{e}}}}
+	if err := checkEnumValues(ctx, set); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if enum, err = convertEnum(ctx, e); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := enum.Values; v[1].ConstValue != 4 || v[2].ConstValue != 2 {
+		t.Errorf("expected pinned values, got: %v", v)
+	}
+	// pinned values are not reported as renumbered
+	warnings = nil
+	checkRenumber(context.WithValue(ctx, xo.OutKey, dir), enum, "booktype.dbtpl.go")
+	if len(warnings) != 0 {
+		t.Errorf("expected no renumber warnings, got: %v", warnings)
+	}
+	if _, err := convertEnum(context.WithValue(ctx, EnumValueKey, []string{"book_type.poetry=1"}), e); err == nil {
+		t.Errorf("expected duplicate value error")
+	}
+	for _, v := range [][]string{
+		{"fiction=x"},
+		{"book_type.poetry=0"},
+		{"book_type.poetry=65536"},
+		{"book_type.poetry=4", "book_type.poetry=5"},
+		{"book_type.poetry=4", "book_type.fiction=4"},
+		{"film_type.poetry=4"},
+		{"book_type.drama=4"},
+	} {
+		if err := checkEnumValues(context.WithValue(ctx, EnumValueKey, v), set); err == nil {
+			t.Errorf("%v: expected error", v)
+		}
+	}
+}
+
+func TestBitmaskField(t *testing.T) {
+	var warnings []xo.Warning
+	ctx := context.WithValue(context.Background(), xo.WarnKey, func(w xo.Warning) {
+		warnings = append(warnings, w)
+	})
+	f := bitmaskField(ctx, "users", Field{GoName: "Permissions", SQLName: "permissions", Type: "int", Comment: "access rights @bitmask(read, write, admin)"})
+	switch b := f.Bitmask; {
+	case b == nil:
+		t.Fatalf("expected bitmask")
+	case f.Type != "UserPermissions" || b.GoName != "UserPermissions" || b.Type != "int":
+		t.Errorf("expected UserPermissions int, got: %q %q", f.Type, b.Type)
+	case f.Comment != "access rights":
+		t.Errorf("expected annotation removed, got: %q", f.Comment)
+	case len(b.Values) != 3 || b.Values[0].GoName != "Read" || b.Values[2].ConstValue != 4:
+		t.Errorf("expected read, write, admin flags, got: %v", b.Values)
+	}
+	f = bitmaskField(ctx, "users", Field{GoName: "Flags", SQLName: "flags", Type: "sql.NullInt64", Comment: "@bitmask(a)"})
+	if f.Bitmask != nil || f.Type != "sql.NullInt64" || f.Comment != "" {
+		t.Errorf("expected no bitmask, got: %v", f)
+	}
+	if len(warnings) != 1 || warnings[0].Kind != "skip" {
+		t.Errorf("expected skip warning, got: %v", warnings)
+	}
+}
+
+func TestConvertConstraints(t *testing.T) {
+	var warnings []xo.Warning
+	ctx := context.WithValue(context.Background(), xo.WarnKey, func(w xo.Warning) {
+		warnings = append(warnings, w)
+	})
+	names := make(map[string]string)
+	constraints := convertConstraints(ctx, names, xo.Table{
+		Name: "books",
+		Indexes: []xo.Index{
+			{Name: "books_pkey", IsPrimary: true, IsUnique: true},
+			{Name: "books_isbn_key", IsUnique: true},
+			{Name: "books_title_idx"},
+		},
+		ForeignKeys: []xo.ForeignKey{{Name: "books_author_id_fkey"}},
+	})
+	exp := []string{"ConstraintBooksPkey", "ConstraintBooksIsbnKey", "IndexBooksTitleIdx", "ConstraintBooksAuthorIDFkey"}
+	if len(constraints) != len(exp) {
+		t.Fatalf("expected %d constraints, got: %v", len(exp), constraints)
+	}
+	for i, s := range exp {
+		if constraints[i].GoName != s {
+			t.Errorf("expected %q, got: %q", s, constraints[i].GoName)
+		}
+	}
+	constraints = convertConstraints(ctx, names, xo.Table{
+		Name:    "books_isbn",
+		Indexes: []xo.Index{{Name: "books_isbn_key", IsUnique: true}, {Name: "books__isbn_key", IsUnique: true}},
+	})
+	if len(constraints) != 0 || len(warnings) != 1 || warnings[0].Kind != "collision" {
+		t.Errorf("expected collision warning, got: %v %v", constraints, warnings)
+	}
+}
+
+func TestAsOfIndex(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TemporalKey, "valid_from,valid_to")
+	fields := []Field{
+		{GoName: "ItemID", SQLName: "item_id", Type: "int"},
+		{GoName: "ValidFrom", SQLName: "valid_from", Type: "time.Time"},
+		{GoName: "ValidTo", SQLName: "valid_to", Type: "sql.NullTime"},
+	}
+	table := Table{SQLName: "prices", Fields: fields}
+	table.ValidFrom, table.ValidTo = temporalFields(ctx, table.Fields)
+	if table.ValidFrom == nil || table.ValidTo == nil {
+		t.Fatalf("expected temporal fields")
+	}
+	index := Index{Func: "PriceByItemIDValidFrom", Table: table, Fields: fields[:2], IsUnique: true}
+	asof, ok := asOfIndex(index)
+	switch {
+	case !ok:
+		t.Fatalf("expected AsOf index")
+	case asof.Func != "PriceByItemIDAsOf" || !asof.AsOf:
+		t.Errorf("expected PriceByItemIDAsOf, got: %q", asof.Func)
+	case len(asof.Fields) != 1:
+		t.Errorf("expected 1 field, got: %d", len(asof.Fields))
+	}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	exp := "WHERE item_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to > $3)"
+	if s := strings.Join(f.sqlstr_index(asof)[3:], ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	index.IsPrimary = true
+	if _, ok := asOfIndex(index); ok {
+		t.Errorf("expected no AsOf index for primary key")
+	}
+}
+
+func TestPagedIndexes(t *testing.T) {
+	fields := []Field{
+		{GoName: "BookID", SQLName: "book_id", Type: "int", IsPrimary: true},
+		{GoName: "Tag", SQLName: "tag", Type: "string", IsPrimary: true},
+		{GoName: "Kind", SQLName: "kind", Type: "int"},
+	}
+	table := Table{GoName: "Tag", SQLName: "tags", Fields: fields, PrimaryKeys: fields[:2]}
+	index := Index{Func: "TagsByKind", Table: table, Fields: fields[2:]}
+	tests := []struct {
+		driver string
+		nth    string
+		exp    []string
+	}{
+		{"postgres", "$%d", []string{
+			"WHERE kind = $1 ORDER BY book_id, tag LIMIT $2 OFFSET $3",
+			"WHERE kind = $1 AND (book_id, tag) > ($2, $3) ORDER BY book_id, tag LIMIT $4",
+		}},
+		{"sqlserver", "@p%d", []string{
+			"WHERE kind = @p1 ORDER BY book_id, tag OFFSET @p3 ROWS FETCH NEXT @p2 ROWS ONLY",
+		}},
+		{"oracle", ":%d", []string{
+			"WHERE kind = :1 ORDER BY book_id, tag OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY",
+		}},
+	}
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), xo.DriverKey, test.driver)
+		indexes := pagedIndexes(ctx, index)
+		if len(indexes) != len(test.exp) {
+			t.Fatalf("%s: expected %d indexes, got: %d", test.driver, len(test.exp), len(indexes))
+		}
+		f := &Funcs{driver: test.driver, nth: func(i int) string { return fmt.Sprintf(test.nth, i+1) }}
+		for i, paged := range indexes {
+			if s := strings.Join(f.sqlstr_index_paged(paged)[3:], ""); s != test.exp[i] {
+				t.Errorf("%s %s: expected %q, got: %q", test.driver, paged.Func, test.exp[i], s)
+			}
+		}
+	}
+	f := &Funcs{driver: "oracle"}
+	if s, exp := f.page_args(pagedIndexes(context.Background(), index)[0]), "kind, offset, limit"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if s, exp := f.page_args(pagedIndexes(context.Background(), index)[1]), "kind, afterBookID, afterTag, limit"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	index.IsUnique = true
+	if indexes := pagedIndexes(context.Background(), index); len(indexes) != 0 {
+		t.Errorf("expected no paged indexes for unique index, got: %d", len(indexes))
+	}
+}
+
+func TestHook(t *testing.T) {
+	table := Table{GoName: "Book"}
+	tests := []struct {
+		hooks   bool
+		context string
+		exp     string
+	}{
+		{false, "only", ""},
+		{true, "only", "\n\t// before insert hook\n\tif h, ok := any(b).(BeforeInsertHook); ok {\n\t\tif err := h.BeforeInsert(ctx); err != nil {\n\t\t\treturn logerror(err)\n\t\t}\n\t}"},
+		{true, "disable", "\n\t// before insert hook\n\tif h, ok := any(b).(BeforeInsertHook); ok {\n\t\tif err := h.BeforeInsert(); err != nil {\n\t\t\treturn logerror(err)\n\t\t}\n\t}"},
+	}
+	for i, test := range tests {
+		f := &Funcs{hooks: test.hooks, context: test.context, shorts: map[string]string{}}
+		if s := f.hook("BeforeInsert", table); s != test.exp {
+			t.Errorf("test %d: expected %q, got: %q", i, test.exp, s)
+		}
+	}
+	// indented in a loop
+	f := &Funcs{hooks: true, context: "only", shorts: map[string]string{}}
+	exp := "\n\t\t// after insert hook\n\t\tif h, ok := any(b).(AfterInsertHook); ok {\n\t\t\tif err := h.AfterInsert(ctx); err != nil {\n\t\t\t\treturn logerror(err)\n\t\t\t}\n\t\t}"
+	if s := f.hook("AfterInsert", table, 2); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestUpsertIndex(t *testing.T) {
+	fields := []Field{
+		{GoName: "TagID", SQLName: "tag_id", Type: "int", IsPrimary: true, IsSequence: true},
+		{GoName: "TagName", SQLName: "tag_name", Type: "string"},
+		{GoName: "Color", SQLName: "color", Type: "string"},
+	}
+	table := Table{GoName: "Tag", SQLName: "tags", Fields: fields, PrimaryKeys: fields[:1]}
+	index := Index{Func: "TagByTagName", Table: table, Fields: fields[1:2], IsUnique: true}
+	f := &Funcs{nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	if s, exp := f.upsert_name(index), "UpsertByTagName"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	exp := "INSERT INTO tags (tag_name, color) VALUES ($1, $2) ON CONFLICT (tag_name) DO " +
+		"UPDATE SET tag_name = EXCLUDED.tag_name, color = EXCLUDED.color RETURNING tag_id"
+	if s := strings.Join(f.sqlstr_upsert_index(index), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// tenant scoped
+	tenant := Field{GoName: "TenantID", SQLName: "tenant_id", Type: "int"}
+	scoped := Table{GoName: "Tag", SQLName: "tags", Fields: append(append([]Field{}, fields...), tenant), PrimaryKeys: fields[:1], Tenant: &tenant}
+	exp = "INSERT INTO tags (tag_name, color, tenant_id) VALUES ($1, $2, $3) ON CONFLICT (tag_name) DO " +
+		"UPDATE SET tag_name = EXCLUDED.tag_name, color = EXCLUDED.color " +
+		"WHERE tags.tenant_id = EXCLUDED.tenant_id RETURNING tag_id"
+	if s := strings.Join(f.sqlstr_upsert_index(Index{Table: scoped, Fields: []Field{fields[1], tenant}, Scoped: true}), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	tests := []struct {
+		driver string
+		index  Index
+		exp    bool
+	}{
+		{"postgres", index, true},
+		{"sqlite3", index, true},
+		{"mysql", index, false},
+		{"postgres", Index{Table: table, Fields: fields[:1], IsUnique: true, IsPrimary: true}, false},
+		{"postgres", Index{Table: table, Fields: fields[2:]}, false},
+	}
+	for i, test := range tests {
+		ctx := context.WithValue(context.Background(), xo.DriverKey, test.driver)
+		ctx = context.WithValue(ctx, UpsertIndexKey, true)
+		if b := upsertIndex(ctx, test.index); b != test.exp {
+			t.Errorf("test %d: expected %t, got: %t", i, test.exp, b)
+		}
+	}
+}
+
+func TestOutboxSqlstr(t *testing.T) {
+	table := Table{
+		SQLName:     "outbox",
+		Fields:      []Field{{SQLName: "outbox_id"}, {SQLName: "topic"}},
+		PrimaryKeys: []Field{{SQLName: "outbox_id"}},
+	}
+	tests := []struct {
+		driver string
+		exp    string
+	}{
+		{"postgres", "SELECT outbox_id, topic FROM outbox ORDER BY outbox_id LIMIT 1 FOR UPDATE SKIP LOCKED"},
+		{"sqlite3", "SELECT outbox_id, topic FROM outbox ORDER BY outbox_id LIMIT 1"},
+		{"sqlserver", "SELECT TOP 1 outbox_id, topic FROM outbox WITH (UPDLOCK, READPAST, ROWLOCK) ORDER BY outbox_id"},
+	}
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			f := &Funcs{driver: test.driver}
+			if s := strings.Join(f.sqlstr_outbox(table), ""); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
+
+func TestNotifyTriggers(t *testing.T) {
+	f := &Funcs{driver: "postgres", notify: "row_changes"}
+	s := f.notify_triggers([]Table{{SQLName: "authors"}, {SQLName: "books"}})
+	for _, exp := range []string{
+		"CREATE OR REPLACE FUNCTION row_changes_notify() RETURNS trigger AS $$",
+		"  PERFORM pg_notify('row_changes', json_build_object(",
+		"CREATE TRIGGER authors_row_changes AFTER INSERT OR UPDATE OR DELETE ON authors",
+		"DROP TRIGGER IF EXISTS books_row_changes ON books;",
+	} {
+		if !strings.Contains(s, exp+"\n") {
+			t.Errorf("expected %q in:\n%s", exp, s)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	pk := Field{GoName: "TagID", SQLName: "tag_id", Type: "int64", IsPrimary: true}
+	table := Table{
+		PrimaryKeys: []Field{pk},
+		Fields:      []Field{pk, {GoName: "Name", Type: "string"}},
+	}
+	if !reconcilable(table) {
+		t.Errorf("expected table to be reconcilable")
+	}
+	table.PrimaryKeys[0].Type = "uuid.UUID"
+	if reconcilable(table) {
+		t.Errorf("expected table with uuid primary key to not be reconcilable")
+	}
+	f := &Funcs{}
+	tests := []struct {
+		typ string
+		exp string
+	}{
+		{"string", "a.Z == b.Z"},
+		{"time.Time", "a.Z.Equal(b.Z)"},
+		{"sql.NullTime", "a.Z.Valid == b.Z.Valid && a.Z.Time.Equal(b.Z.Time)"},
+		{"[]byte", "bytes.Equal(a.Z, b.Z)"},
+		{"pq.StringArray", "reflect.DeepEqual(a.Z, b.Z)"},
+	}
+	for _, test := range tests {
+		if s := f.reconcile_equal(Field{GoName: "Z", Type: test.typ}, "a", "b"); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+	// tenant is a param, and scopes the query
+	f = &Funcs{ctxpos: "last", dbtype: "DB", narrow: true, shorts: map[string]string{}, nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	tenant := Field{GoName: "TenantID", SQLName: "tenant_id", Type: "int64"}
+	deleted := Field{GoName: "DeletedAt", SQLName: "deleted_at", Type: "sql.NullTime"}
+	table = Table{GoName: "Book", SQLName: "books", PrimaryKeys: []Field{pk}, Fields: []Field{pk, tenant, deleted}, Tenant: &tenant, SoftDelete: &deleted}
+	if s, exp := f.reconcile_tenant(table), "tenantID int64"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if s, exp := f.call_args("ctx", "dst", f.tenant_param(table)), "dst, tenantID, ctx"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	exp := "SELECT tag_id, tenant_id, deleted_at FROM books WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY tag_id"
+	if s := strings.Join(f.sqlstr_reconcile(table), ""); s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	// only the tenant and primary key are not reconcilable
+	table.Fields = []Field{pk, tenant}
+	if reconcilable(table) {
+		t.Errorf("expected table without update fields to not be reconcilable")
+	}
+	if s := f.reconcile_src(); s != "Querier" {
+		t.Errorf("expected Querier, got: %q", s)
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp int
+		err bool
+	}{
+		{"", 0, false},
+		{"1.18", 18, false},
+		{"go1.21", 21, false},
+		{"1.22.3", 22, false},
+		{"1.17", 0, true},
+		{"2.1", 0, true},
+		{"1.x", 0, true},
+	}
+	for _, test := range tests {
+		v, err := goVersion(test.s)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%q: expected error", test.s)
+		case !test.err && err != nil:
+			t.Errorf("%q: expected no error, got: %v", test.s, err)
+		case v != test.exp:
+			t.Errorf("%q: expected %d, got: %d", test.s, test.exp, v)
+		}
+	}
+	f := &Funcs{goVersion: 18}
+	if f.go_version(21) || !f.go_version(18) {
+		t.Errorf("expected go 1.18 features only")
+	}
+}
+
+func TestFieldTagSQLType(t *testing.T) {
+	ctx := context.WithValue(context.Background(), xo.DriverKey, "postgres")
+	tpl := template.Must(template.New("fieldtag").Parse(`db:"{{ .SQLName }}" pgtype:"{{ .SQLType }}"`))
+	tests := []struct {
+		typ xo.Type
+		exp string
+	}{
+		{xo.Type{Type: "timestamp with time zone", Nullable: true}, `db:"created_at" pgtype:"timestamp with time zone"`},
+		{xo.Type{Type: "numeric", Prec: 10, Scale: 2}, `db:"created_at" pgtype:"numeric(10,2)"`},
+		{xo.Type{Type: "integer", IsArray: true}, `db:"created_at" pgtype:"integer[]"`},
+	}
+	for _, test := range tests {
+		f, err := convertField(ctx, camelExport, "books", xo.Field{Name: "created_at", Type: test.typ})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		buf := new(strings.Builder)
+		if err := tpl.Execute(buf, f); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s := buf.String(); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+}
+
+func TestNolint(t *testing.T) {
+	ctx := context.WithValue(context.Background(), NolintKey, []string{"", "funlen", " gocyclo "})
+	f := &Funcs{nolint: Nolint(ctx)}
+	if s, exp := f.nolintfn(), "//nolint:funlen,gocyclo"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if s := (&Funcs{}).nolintfn(); s != "" {
+		t.Errorf("expected no directive, got: %q", s)
+	}
+}
+
+func TestTestCall(t *testing.T) {
+	tenant := Field{GoName: "TenantID", SQLName: "tenant_id", Type: "int", Zero: "0"}
+	fields := []Field{
+		{GoName: "BookID", SQLName: "book_id", Type: "int", Zero: "0"},
+		{GoName: "Title", SQLName: "title", Type: "string", Zero: `""`},
+	}
+	table := Table{GoName: "Book", SQLName: "books", Fields: fields, Tenant: &tenant}
+	index := Index{Func: "BooksByTitle", Table: table, Fields: fields[1:]}
+	tests := []struct {
+		ctxpos string
+		v      any
+		exp    string
+	}{
+		{"first", "Insert", "Insert(ctx, db)"},
+		{"first", "Update", "Update(ctx, db, 0)"},
+		{"last", "Delete", "Delete(db, 0, ctx)"},
+		{"first", index, `BooksByTitle(ctx, db, "")`},
+		{"last", Index{Func: "BookByBookIDAsOf", Table: table, Fields: fields[:1], AsOf: true}, "BookByBookIDAsOf(db, 0, time.Time{}, ctx)"},
+	}
+	for _, test := range tests {
+		f := &Funcs{ctxpos: test.ctxpos, knownTypes: map[string]bool{"int": true, "string": true}}
+		if s := f.test_call(test.v, table); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+}
+
+func TestTestSchemaNames(t *testing.T) {
+	table := Table{
+		SQLName:     "books",
+		PrimaryKeys: []Field{{GoName: "BookID", SQLName: "book_id", Type: "int"}},
+	}
+	tests := []struct {
+		testHelpers bool
+		escSchema   bool
+		exp         string
+		qualifier   string
+	}{
+		{false, false, "DELETE FROM public.books WHERE book_id = $1", `"public."`},
+		{true, false, "DELETE FROM public.books WHERE book_id = $1", `"public."`},
+		{true, true, `DELETE FROM "public".books WHERE book_id = $1`, `"\"public\"."`},
+	}
+	for _, test := range tests {
+		f := &Funcs{
+			driver:      "postgres",
+			schema:      "public",
+			testHelpers: test.testHelpers,
+			escSchema:   test.escSchema,
+			nth:         func(i int) string { return fmt.Sprintf("$%d", i+1) },
+		}
+		if s := strings.Join(f.sqlstr_delete(table), ""); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+		if s := f.test_schema_qualifier(); s != test.qualifier {
+			t.Errorf("expected %s, got: %s", test.qualifier, s)
+		}
+	}
+}
+
+func TestInsertMany(t *testing.T) {
+	fields := []Field{
+		{GoName: "BookID", SQLName: "book_id", Type: "int", IsPrimary: true, IsSequence: true},
+		{GoName: "Title", SQLName: "title", Type: "string"},
+		{GoName: "Year", SQLName: "year", Type: "int"},
+	}
+	table := Table{GoName: "Book", SQLName: "books", Fields: fields, PrimaryKeys: fields[:1]}
+	tests := []struct {
+		driver string
+		size   int
+		values string
+	}{
+		{"postgres", 1000, `fmt.Sprintf("($%d, $%d)", n+1, n+2)`},
+		{"sqlserver", 1000, `fmt.Sprintf("(@p%d, @p%d)", n+1, n+2)`},
+		{"sqlite3", 499, `"(?, ?)"`},
+	}
+	for _, test := range tests {
+		f := &Funcs{driver: test.driver}
+		if n := f.insert_many_size(table); n != test.size {
+			t.Errorf("%s: expected size %d, got: %d", test.driver, test.size, n)
+		}
+		if s := f.insert_many_values(table, "n"); s != test.values {
+			t.Errorf("%s: expected %q, got: %q", test.driver, test.values, s)
+		}
+		if z := f.insert_many_returning(table); (z != nil) != (test.driver == "postgres") {
+			t.Errorf("%s: unexpected returning %v", test.driver, z)
+		}
+	}
+	f := &Funcs{driver: "postgres", nth: func(i int) string { return fmt.Sprintf("$%d", i+1) }}
+	if s, exp := strings.Join(f.sqlstr_insert_many(table), ""), "INSERT INTO books (title, year) VALUES "; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestFieldRename(t *testing.T) {
+	ctx := context.WithValue(context.Background(), RenameKey, []string{"", "authors.first_name=GivenName", "last_name = Surname"})
+	text := xo.Type{Type: "text"}
+	set := &xo.Set{Schemas: []xo.Schema{{Tables: []xo.Table{
+		{Name: "authors", Columns: []xo.Field{{Name: "author_id", Type: text}, {Name: "first_name", Type: text}, {Name: "last_name", Type: text}}},
+	}}}}
+	if err := checkRename(ctx, set); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	tests := []struct {
+		table  string
+		column string
+		exp    string
+	}{
+		{"authors", "first_name", "GivenName"},
+		{"books", "first_name", "FirstName"},
+		{"authors", "last_name", "Surname"},
+		{"", "last_name", "LastName"},
+	}
+	for _, test := range tests {
+		t.Run(test.table+"."+test.column, func(t *testing.T) {
+			f, err := convertField(context.WithValue(ctx, xo.DriverKey, "postgres"), camelExport, test.table, xo.Field{
+				Name: test.column,
+				Type: xo.Type{Type: "text"},
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if f.GoName != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, f.GoName)
+			}
+		})
+	}
+	for _, s := range []string{
+		"first_name", "first_name=givenName", "=GivenName", "first_name=Given Name",
+		"first_name=Insert", "first_name=DeleteContext", "first_name=UpsertByEmail",
+		"first_name=AuthorID", "last_name=FirstName",
+		"books.first_name=GivenName", "middle_name=MiddleName",
+	} {
+		if err := checkRename(context.WithValue(ctx, RenameKey, []string{s}), set); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestInsertUpdateOnly(t *testing.T) {
+	f := &Funcs{
+		driver: "postgres",
+		nth:    func(i int) string { return fmt.Sprintf("$%d", i+1) },
+		shorts: make(map[string]string),
+	}
+	pk := Field{GoName: "BookID", SQLName: "book_id", Type: "int", IsPrimary: true, IsSequence: true}
+	table := Table{
+		GoName:  "Book",
+		SQLName: "books",
+		Fields: []Field{
+			pk,
+			{GoName: "Title", SQLName: "title", Type: "string"},
+			{GoName: "CreatedAt", SQLName: "created_at", Type: "time.Time", InsertOnly: true},
+			{GoName: "UpdatedAt", SQLName: "updated_at", Type: "time.Time", UpdateOnly: true},
+		},
+		PrimaryKeys: []Field{pk},
+	}
+	tests := []struct {
+		name string
+		s    string
+		exp  string
+	}{
+		{"insert", strings.Join(f.sqlstr_insert(table), ""), "INSERT INTO books (title, created_at) VALUES ($1, $2) RETURNING book_id"},
+		{"update", strings.Join(f.sqlstr_update(table), ""), "UPDATE books SET title = $1, updated_at = $2 WHERE book_id = $3"},
+		{"upsert", strings.Join(f.sqlstr_upsert(table), ""), "INSERT INTO books (book_id, title, created_at) VALUES ($1, $2, $3) ON CONFLICT (book_id) DO UPDATE SET title = EXCLUDED.title "},
+		{"upsert_mysql", strings.Join((&Funcs{driver: "mysql", nth: f.nth}).sqlstr_upsert(table), ""), "INSERT INTO books (book_id, title, created_at) VALUES ($1, $2, $3) ON DUPLICATE KEY UPDATE title = VALUES(title)"},
+		{"db_prefix", f.db_prefix("QueryRow", true, table), "db.QueryRow(sqlstr, b.Title, b.CreatedAt)"},
+		{"db_update", f.db_update("Exec", table), "db.Exec(sqlstr, b.Title, b.UpdatedAt, b.BookID)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, test.s)
+			}
+		})
+	}
+}
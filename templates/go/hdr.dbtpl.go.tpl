@@ -8,6 +8,9 @@
 {{- if first -}}
 // Package {{ pkg }} contains generated code for schema '{{ schema }}'.
 {{ end -}}
+{{- with nolint -}}
+{{ . }}
+{{ end -}}
 package {{ pkg }}
 
 // Code generated by dbtpl. DO NOT EDIT.
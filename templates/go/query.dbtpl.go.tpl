@@ -1,31 +1,73 @@
 {{ define "query" }}
 {{- $q := .Data -}}
+{{ query_id "query" $q -}}
 {{- if $q.Comment -}}
 // {{ $q.Comment | eval (func_name_context $q) }}
 {{- else -}}
-// {{ func_name_context $q }} runs a custom query{{ if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
+// {{ func_name_context $q }} runs a custom query{{ if and $q.Exec exec_result }} as an [ExecResult]{{ else if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
 {{- end }}
 {{ func_context $q }} {
 	// query
 	{{ querystr $q }}
 	// run
 	logf({{ names "" "sqlstr" $q }})
-{{ if $q.Exec -}}
+{{ if and $q.Exec exec_result -}}
+	res, err := {{ db "Exec" $q }}
+	if err != nil {
+		return ExecResult{}, logerror(err)
+	}
+	return ExecResult{Result: res, Op: "{{ exec_op $q }}"}, nil
+{{- else if $q.Exec -}}
 	return {{ db "Exec" $q }}
 {{- else if $q.Flat -}}
 {{- range $q.Type.Fields -}}
-	var {{ .GoName }} {{ type .Type }}
+	var {{ check_name .GoName }} {{ type .Type }}
 {{ end -}}
+{{ if retry -}}
+	if err := retry({{ if context }}ctx, {{ end }}OpQuery, func() error {
+		return {{ db "QueryRow" $q }}.Scan({{ names "&" $q.Type.Fields }})
+	}); err != nil {
+{{- else -}}
 	if err := {{ db "QueryRow" $q }}.Scan({{ names "&" $q.Type.Fields }}); err != nil {
+{{- end }}
 		return {{ zero $q.Type.Fields "logerror(err)" }}
 	}
 	return {{ names "" $q.Type "nil" }}
 {{- else if $q.One -}}
 	var {{ short $q.Type }} {{ type $q.Type.GoName }}
+{{- if retry }}
+	if err := retry({{ if context }}ctx, {{ end }}OpQuery, func() error {
+		return {{ db "QueryRow" $q }}.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }})
+	}); err != nil {
+{{- else }}
 	if err := {{ db "QueryRow" $q }}.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
+{{- end }}
 		return nil, logerror(err)
 	}
 	return &{{ short $q.Type }}, nil
+{{- else if retry -}}
+	var res []*{{ type $q.Type.GoName }}
+	if err := retry({{ if context }}ctx, {{ end }}OpQuery, func() error {
+		res = nil
+		rows, err := {{ db "Query" $q }}
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		// load results
+		for rows.Next() {
+			var {{ short $q.Type}} {{ type $q.Type.GoName }}
+			// scan
+			if err := rows.Scan({{ names (print "&" (short $q.Type) ".") $q.Type.Fields }}); err != nil {
+				return err
+			}
+			res = append(res, &{{ short $q.Type }})
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
 {{- else -}}
 	rows, err := {{ db "Query" $q }}
 	if err != nil {
@@ -53,10 +95,10 @@
 {{- if $q.Comment -}}
 // {{ $q.Comment | eval (func_name $q) }}
 {{- else -}}
-// {{ func_name $q }} runs a custom query{{ if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
+// {{ func_name $q }} runs a custom query{{ if and $q.Exec exec_result }} as an [ExecResult]{{ else if $q.Exec }} as a [sql.Result]{{ else if not $q.Flat }}, returning results as [{{ $q.Type.GoName }}]{{ end }}.
 {{- end }}
 {{ func $q }} {
-	return {{ func_name_context $q }}({{ names_all "" "context.Background()" "db" $q }})
+	return {{ func_name_context $q }}({{ call_args "context.Background()" "db" $q }})
 }
 {{- end }}
 {{ end }}
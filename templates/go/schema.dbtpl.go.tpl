@@ -11,6 +11,40 @@ const (
 {{ end -}}
 )
 
+// {{ $e.GoName }}Values returns all [{{ $e.GoName }}] values, in schema order.
+func {{ $e.GoName }}Values() []{{ $e.GoName }} {
+	return []{{ $e.GoName }}{
+{{ range $e.Values -}}
+		{{ $e.GoName }}{{ .GoName }},
+{{ end -}}
+	}
+}
+
+// Parse{{ $e.GoName }} parses a [{{ $e.GoName }}] from its '{{ $e.SQLName }}' value.
+func Parse{{ $e.GoName }}(str string) ({{ $e.GoName }}, error) {
+	var {{ short $e.GoName }} {{ $e.GoName }}
+	if err := {{ short $e.GoName }}.UnmarshalText([]byte(str)); err != nil {
+		return 0, err
+	}
+	return {{ short $e.GoName }}, nil
+}
+
+// IsValid returns true when [{{ $e.GoName }}] is a defined value.
+func ({{ short $e.GoName }} {{ $e.GoName }}) IsValid() bool {
+	switch {{ short $e.GoName }} {
+{{ range $e.Values -}}
+	case {{ $e.GoName }}{{ .GoName }}:
+		return true
+{{ end -}}
+	}
+	return false
+}
+
+// Ptr returns a pointer to [{{ $e.GoName }}].
+func ({{ short $e.GoName }} {{ $e.GoName }}) Ptr() *{{ $e.GoName }} {
+	return &{{ short $e.GoName }}
+}
+
 // String satisfies the [fmt.Stringer] interface.
 func ({{ short $e.GoName }} {{ $e.GoName }}) String() string {
 	switch {{ short $e.GoName }} {
@@ -22,12 +56,14 @@ func ({{ short $e.GoName }} {{ $e.GoName }}) String() string {
 	return fmt.Sprintf("{{ $e.GoName }}(%d)", {{ short $e.GoName }})
 }
 
-// MarshalText marshals [{{ $e.GoName }}] into text.
+// MarshalText marshals [{{ $e.GoName }}] into text. Also used by
+// [encoding/json].
 func ({{ short $e.GoName }} {{ $e.GoName }}) MarshalText() ([]byte, error) {
 	return []byte({{ short $e.GoName }}.String()), nil
 }
 
-// UnmarshalText unmarshals [{{ $e.GoName }}] from text.
+// UnmarshalText unmarshals [{{ $e.GoName }}] from text. Also used by
+// [encoding/json].
 func ({{ short $e.GoName }} *{{ $e.GoName }}) UnmarshalText(buf []byte) error {
 	switch str := string(buf); str {
 {{ range $e.Values -}}
@@ -93,6 +129,52 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 }
 {{ end }}
 
+{{ define "bitmask" }}
+{{- $b := .Data -}}
+{{- $s := short $b.GoName -}}
+// {{ $b.GoName }} is the '{{ $b.SQLName }}' bitmask column flags type.
+type {{ $b.GoName }} {{ $b.Type }}
+
+// {{ $b.GoName }} flags.
+const (
+{{ range $b.Values -}}
+	// {{ $b.GoName }}{{ .GoName }} is the '{{ .SQLName }}' {{ $b.SQLName }} flag.
+	{{ $b.GoName }}{{ .GoName }} {{ $b.GoName }} = {{ .ConstValue }}
+{{ end -}}
+)
+
+// Has returns true when all of flag are set.
+func ({{ $s }} {{ $b.GoName }}) Has(flag {{ $b.GoName }}) bool {
+	return {{ $s }}&flag == flag
+}
+
+// Set returns [{{ $b.GoName }}] with flag set.
+func ({{ $s }} {{ $b.GoName }}) Set(flag {{ $b.GoName }}) {{ $b.GoName }} {
+	return {{ $s }} | flag
+}
+
+// Clear returns [{{ $b.GoName }}] with flag cleared.
+func ({{ $s }} {{ $b.GoName }}) Clear(flag {{ $b.GoName }}) {{ $b.GoName }} {
+	return {{ $s }} &^ flag
+}
+
+// String satisfies the [fmt.Stringer] interface, joining the set flags with
+// '|'.
+func ({{ $s }} {{ $b.GoName }}) String() string {
+	var names []string
+{{ range $b.Values -}}
+	if {{ $s }}.Has({{ $b.GoName }}{{ .GoName }}) {
+		names = append(names, "{{ .SQLName }}")
+		{{ $s }} = {{ $s }}.Clear({{ $b.GoName }}{{ .GoName }})
+	}
+{{ end -}}
+	if {{ $s }} != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("%#x", {{ $b.Type }}({{ $s }})))
+	}
+	return strings.Join(names, "|")
+}
+{{ end }}
+
 {{ define "foreignkey" }}
 {{- $k := .Data -}}
 // {{ func_name_context $k }} returns the {{ $k.RefTable }} associated with the [{{ $k.Table.GoName }}]'s ({{ names "" $k.Fields }}).
@@ -112,9 +194,195 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 {{- end }}
 {{ end }}
 
+{{ define "cascade" }}
+{{- $c := .Data -}}
+// {{ func_name_context $c }} deletes the [{{ $c.Table.GoName }}] with the primary key from
+// '{{ schema $c.Table.SQLName }}', first deleting the rows that depend on it by foreign key.
+{{- if $c.Table.Tenant }}
+// Nothing is deleted when the row is not of the tenant.
+{{- end }}
+{{- if hooks }}
+// The rows are deleted by key without being loaded, so no Delete hooks are
+// called.
+{{- end }}
+{{ func_context $c }} {
+	// dependent rows first
+	stmts := []string{
+		{{ cascade_stmts $c }}
+	}
+	// run
+	return cascade({{ if context }}ctx, {{ end }}{{ names "" "db" "stmts" (params $c.Table.PrimaryKeys false) (tenant_param $c.Table) }})
+}
+{{ if context_both }}
+// {{ func_name $c }} deletes the [{{ $c.Table.GoName }}] with the primary key from
+// '{{ schema $c.Table.SQLName }}', first deleting the rows that depend on it by foreign key.
+{{- if $c.Table.Tenant }}
+// Nothing is deleted when the row is not of the tenant.
+{{- end }}
+{{ func $c }} {
+	return {{ func_name_context $c }}({{ call_args "context.Background()" "db" (params $c.Table.PrimaryKeys false) (tenant_param $c.Table) }})
+}
+{{ end }}
+{{ end }}
+
+{{ define "graph" }}
+{{- $g := .Data -}}
+// {{ $g.Table.GoName }}Graph is a [{{ $g.Table.GoName }}] with the rows referencing it by foreign key.
+type {{ $g.Table.GoName }}Graph struct {
+	*{{ $g.Table.GoName }}
+{{- range $g.Relations }}
+	// {{ .Name }} are the [{{ .Table.GoName }}] rows referencing the [{{ $g.Table.GoName }}] by {{ .Field.SQLName }}.
+	{{ .Name }} []*{{ .Table.GoName }}Graph `json:"{{ .Key }}"`
+{{- end }}
+}
+{{ if $g.JSON }}
+// exists marks the rows of the [{{ $g.Table.GoName }}Graph] as existing in the database.
+func (g *{{ $g.Table.GoName }}Graph) exists() {
+	g._exists = true
+{{- range $g.Relations }}{{ if .JSON }}
+	for _, z := range g.{{ .Name }} {
+		z.exists()
+	}
+{{- end }}{{ end }}
+}
+{{ end }}
+{{- end }}
+
+{{ define "preload_json" }}
+{{- $p := .Data -}}
+// {{ func_name_context $p }} loads the [{{ $p.Table.GoName }}Graph] with the primary key from
+// '{{ schema $p.Table.SQLName }}', preloading the rows referencing it up to {{ $p.Depth }} foreign keys deep
+// with a single query building the graph as JSON.
+{{- if $p.Table.Tenant }} The rows are loaded for the tenant.{{ end }}
+{{ func_context $p }} {
+	// query
+	{{ preload_json_sqlstr $p }}
+	// run
+	logf({{ names "" "sqlstr" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }})
+	var buf []byte
+	if err := {{ db "QueryRow" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }}.Scan(&buf); err != nil {
+		return nil, logerror(err)
+	}
+	var g {{ $p.Table.GoName }}Graph
+	if err := json.Unmarshal(buf, &g); err != nil {
+		return nil, logerror(err)
+	}
+	g.exists()
+	return &g, nil
+}
+{{ if context_both }}
+// {{ func_name $p }} loads the [{{ $p.Table.GoName }}Graph] with the primary key from
+// '{{ schema $p.Table.SQLName }}', preloading the rows referencing it up to {{ $p.Depth }} foreign keys deep
+// with a single query building the graph as JSON.
+{{- if $p.Table.Tenant }} The rows are loaded for the tenant.{{ end }}
+{{ func $p }} {
+	return {{ func_name_context $p }}({{ call_args "context.Background()" "db" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }})
+}
+{{ end }}
+{{ end }}
+
+{{ define "preload" }}
+{{- $p := .Data -}}
+// {{ func_name_context $p }} loads the [{{ $p.Table.GoName }}Graph] with the primary key from
+// '{{ schema $p.Table.SQLName }}', preloading the rows referencing it up to {{ $p.Depth }} foreign keys deep
+// with one query per relation.
+{{- if $p.Table.Tenant }} The rows are loaded for the tenant.{{ end }}
+{{ func_context $p }} {
+{{- if preload_split $p }}
+{{- range $i, $n := $p.Nodes }}
+{{- if or (not $i) (preload_parent $p $i) }}
+	g{{ $i }}, err := {{ preload_node_call $p $i }}
+	if err != nil {
+		return nil, err
+	}
+{{- else }}
+	if _, err = {{ preload_node_call $p $i }}; err != nil {
+		return nil, err
+	}
+{{- end }}
+{{- end }}
+{{- else }}
+{{- range $i, $n := $p.Nodes }}{{ template "preload_block" (preload_block $p $i) }}{{ end }}
+{{- end }}
+	return g0[0], nil
+}
+{{- if preload_split $p }}
+{{- range $i, $n := $p.Nodes }}
+
+// {{ preload_node_name $p $i }} loads the rows of '{{ schema $n.Table.SQLName }}' for [{{ func_name_context $p }}].
+{{ preload_node $p $i }} {
+{{- template "preload_block" (preload_block $p $i) }}
+	return g{{ $i }}, nil
+}
+{{- end }}
+{{- end }}
+{{ if context_both }}
+// {{ func_name $p }} loads the [{{ $p.Table.GoName }}Graph] with the primary key from
+// '{{ schema $p.Table.SQLName }}', preloading the rows referencing it up to {{ $p.Depth }} foreign keys deep
+// with one query per relation.
+{{- if $p.Table.Tenant }} The rows are loaded for the tenant.{{ end }}
+{{ func $p }} {
+	return {{ func_name_context $p }}({{ call_args "context.Background()" "db" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }})
+}
+{{ end }}
+{{ end }}
+
+{{ define "preload_block" }}
+{{- $p := .Preload }}{{ $i := .Index }}{{ $n := .Node }}
+{{- if $i }}
+	// load {{ $n.Table.SQLName }} referencing {{ $n.ParentTable.SQLName }} by {{ $n.Relation.Field.SQLName }}
+{{- else }}
+	// load {{ $n.Table.SQLName }}
+{{- end }}
+	var g{{ $i }} []*{{ $n.Table.GoName }}Graph
+	{
+{{- if $i }}
+		parents := make(map[{{ type $n.Relation.RefField.Type }}]*{{ $n.ParentTable.GoName }}Graph)
+		for _, parent := range g{{ $n.Parent }} {
+			parents[parent.{{ $n.Relation.RefField.GoName }}] = parent
+		}
+{{- end }}
+		{{ preload_sqlstr $p $n }}
+		// run
+		logf({{ names "" "sqlstr" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }})
+		rows, err := {{ db "Query" (params $p.Table.PrimaryKeys false) (tenant_param $p.Table) }}
+		if err != nil {
+			return nil, logerror(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			row := {{ $n.Table.GoName }}{
+				_exists: true,
+			}
+			if err := rows.Scan({{ names "&row." $n.Table.Fields }}); err != nil {
+				return nil, logerror(err)
+			}
+			node := &{{ $n.Table.GoName }}Graph{ {{- $n.Table.GoName }}: &row}
+{{- if $i }}
+			if parent, ok := parents[row.{{ $n.Relation.Field.GoName }}]; ok {
+				parent.{{ $n.Relation.Name }} = append(parent.{{ $n.Relation.Name }}, node)
+			}
+{{- end }}
+			g{{ $i }} = append(g{{ $i }}, node)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, logerror(err)
+		}
+	}
+{{- if not $i }}
+	if len(g0) == 0 {
+		return nil, logerror(sql.ErrNoRows)
+	}
+{{- end }}
+{{- end }}
+
 {{ define "index" }}
 {{- $i := .Data -}}
-// {{ func_name_context $i }} retrieves a row from '{{ schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
+{{ query_id "index" $i }}// {{ func_name_context $i }} retrieves a row from '{{ schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}].
+{{- if $i.Redacted }}
+// Sensitive columns are omitted from the query and left as zero values, and
+// the rows are not marked as existing so they cannot be updated.
+{{- end }}
 //
 // Generated from index '{{ $i.SQLName }}'.
 {{ func_context $i }} {
@@ -128,10 +396,43 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 		_exists: true,
 	{{ end -}}
 	}
+{{- if retry }}
+	if err := retry({{ if context }}ctx, {{ end }}OpIndex, func() error {
+		return {{ db "QueryRow"  $i }}.Scan({{ names (print "&" (short $i.Table) ".") $i.Table }})
+	}); err != nil {
+{{- else }}
 	if err := {{ db "QueryRow"  $i }}.Scan({{ names (print "&" (short $i.Table) ".") $i.Table }}); err != nil {
+{{- end }}
 		return nil, logerror(err)
 	}
 	return &{{ short $i.Table }}, nil
+{{- else if retry }}
+	var res []*{{ $i.Table.GoName }}
+	if err := retry({{ if context }}ctx, {{ end }}OpIndex, func() error {
+		res = nil
+		rows, err := {{ db "Query" $i }}
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		// process
+		for rows.Next() {
+			{{ short $i.Table }} := {{ $i.Table.GoName }}{
+			{{- if and $i.Table.PrimaryKeys (not $i.Redacted) }}
+				_exists: true,
+			{{ end -}}
+			}
+			// scan
+			if err := rows.Scan({{ names_ignore (print "&" (short $i.Table) ".")  $i.Table }}); err != nil {
+				return err
+			}
+			res = append(res, &{{ short $i.Table }})
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
 {{- else }}
 	rows, err := {{ db "Query" $i }}
 	if err != nil {
@@ -142,7 +443,7 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 	var res []*{{ $i.Table.GoName }}
 	for rows.Next() {
 		{{ short $i.Table }} := {{ $i.Table.GoName }}{
-		{{- if $i.Table.PrimaryKeys }}
+		{{- if and $i.Table.PrimaryKeys (not $i.Redacted) }}
 			_exists: true,
 		{{ end -}}
 		}
@@ -164,16 +465,226 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 //
 // Generated from index '{{ $i.SQLName }}'.
 {{ func $i }} {
-	return {{ func_name_context $i }}({{ names "" "context.Background()" "db" $i }})
+	return {{ func_name_context $i }}({{ call_args "context.Background()" "db" $i }})
+}
+{{- end }}
+
+{{ if shard -}}
+{{ if $i.IsPrimary -}}
+// {{ func_name_context $i }} retrieves a row from '{{ schema $i.Table.SQLName }}' as a [{{ $i.Table.GoName }}]
+// from the shard for the primary key.
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ shard_func $i }} {
+	return {{ func_name_context $i }}({{ if context }}{{ call_args "ctx" (print "r.Shard(" (params $i.Table.PrimaryKeys false) ")") $i }}{{ else }}r.Shard({{ params $i.Table.PrimaryKeys false }}), {{ names "" $i }}{{ end }})
+}
+{{- else -}}
+// {{ func_name_context $i }} retrieves {{ if $i.IsUnique }}a row{{ else }}rows{{ end }} from '{{ schema $i.Table.SQLName }}' as {{ if $i.IsUnique }}a{{ else }}a list of{{ end }} [{{ $i.Table.GoName }}]
+// from all shards.
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ shard_func $i }} {
+{{- if $i.IsUnique }}
+	for _, db := range r.Shards {
+		res, err := {{ func_name_context $i }}({{ if context }}{{ call_args "ctx" "db" $i }}{{ else }}{{ names "" "db" $i }}{{ end }})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		return res, nil
+	}
+	return nil, sql.ErrNoRows
+{{- else }}
+	var rows []*{{ $i.Table.GoName }}
+	for _, db := range r.Shards {
+		res, err := {{ func_name_context $i }}({{ if context }}{{ call_args "ctx" "db" $i }}{{ else }}{{ names "" "db" $i }}{{ end }})
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, res...)
+	}
+	return rows, nil
+{{- end }}
+}
+{{- end }}
+{{- end }}
+
+{{end}}
+
+{{ define "index_asof" }}
+{{- $i := .Data -}}
+{{ query_id "index" $i }}// {{ func_name_context $i }} retrieves {{ if $i.IsUnique }}the row{{ else }}the rows{{ end }} from '{{ schema $i.Table.SQLName }}' valid at the time as {{ if $i.IsUnique }}a{{ else }}a list of{{ end }} [{{ $i.Table.GoName }}].
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ func_context $i }} {
+	// query
+	{{ sqlstr "index" $i }}
+	// run
+	logf(sqlstr, {{ params $i.Fields false }}, at)
+{{- if $i.IsUnique }}
+	{{ short $i.Table }} := {{ $i.Table.GoName }}{
+	{{- if $i.Table.PrimaryKeys }}
+		_exists: true,
+	{{ end -}}
+	}
+	if err := {{ db "QueryRow" $i "at" "at" }}.Scan({{ names (print "&" (short $i.Table) ".") $i.Table }}); err != nil {
+		return nil, logerror(err)
+	}
+	return &{{ short $i.Table }}, nil
+{{- else }}
+	rows, err := {{ db "Query" $i "at" "at" }}
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*{{ $i.Table.GoName }}
+	for rows.Next() {
+		{{ short $i.Table }} := {{ $i.Table.GoName }}{
+		{{- if $i.Table.PrimaryKeys }}
+			_exists: true,
+		{{ end -}}
+		}
+		// scan
+		if err := rows.Scan({{ names_ignore (print "&" (short $i.Table) ".")  $i.Table }}); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &{{ short $i.Table }})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+{{- end }}
+}
+
+{{ if context_both -}}
+// {{ func_name $i }} retrieves {{ if $i.IsUnique }}the row{{ else }}the rows{{ end }} from '{{ schema $i.Table.SQLName }}' valid at the time as {{ if $i.IsUnique }}a{{ else }}a list of{{ end }} [{{ $i.Table.GoName }}].
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ func $i }} {
+	return {{ func_name_context $i }}({{ call_args "context.Background()" "db" $i "at" }})
+}
+{{- end }}
+{{end}}
+
+{{ define "index_paged" }}
+{{- $i := .Data -}}
+{{ query_id "index_paged" $i }}
+{{- if $i.Keyset -}}
+// {{ func_name_context $i }} retrieves up to limit rows from '{{ schema $i.Table.SQLName }}' after the primary key as a list of [{{ $i.Table.GoName }}], ordered by primary key.
+// Pass the zero value of the key to retrieve the first page, and the key of the
+// last row to retrieve the next page.
+{{- else -}}
+// {{ func_name_context $i }} retrieves up to limit rows from '{{ schema $i.Table.SQLName }}', skipping offset rows, as a list of [{{ $i.Table.GoName }}].
+{{- end }}
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ func_context $i }} {
+	// query
+	{{ sqlstr "index_paged" $i }}
+	// run
+	logf(sqlstr, {{ page_args $i }})
+	rows, err := {{ db "Query" (page_args $i) }}
+	if err != nil {
+		return nil, logerror(err)
+	}
+	defer rows.Close()
+	// process
+	var res []*{{ $i.Table.GoName }}
+	for rows.Next() {
+		{{ short $i.Table }} := {{ $i.Table.GoName }}{
+			_exists: true,
+		}
+		// scan
+		if err := rows.Scan({{ names_ignore (print "&" (short $i.Table) ".")  $i.Table }}); err != nil {
+			return nil, logerror(err)
+		}
+		res = append(res, &{{ short $i.Table }})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logerror(err)
+	}
+	return res, nil
+}
+
+{{ if context_both -}}
+// {{ func_name $i }} retrieves up to limit rows from '{{ schema $i.Table.SQLName }}' as a list of [{{ $i.Table.GoName }}].
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ func $i }} {
+	return {{ func_name_context $i }}({{ call_args "context.Background()" "db" $i }})
 }
 {{- end }}
+{{end}}
+
+{{ define "upsert_index" }}
+{{- $i := .Data -}}
+{{- $t := $i.Table -}}
+{{- $name := upsert_name $i -}}
+{{ query_id "upsert_index" $i }}// {{ func_name_context $name }} performs an upsert for [{{ $t.GoName }}] on the conflict of
+// {{ range $k, $f := index_fields $i }}{{ if $k }}, {{ end }}'{{ $f.SQLName }}'{{ end }}, setting the primary key of the inserted or updated row.
+{{- if $t.Tenant }}
+//
+// The row is upserted for the tenant. A conflicting row of a different tenant
+// is left unchanged, returning [ErrTenantMismatch].
+{{- end }}
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ recv_context $t $name }} {
+	switch {
+	case {{ short $t }}._deleted: // deleted
+		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
+	}
+{{- if $t.Tenant }}
+	// scope to the tenant
+	{{ short $t }}.{{ $t.Tenant.GoName }} = {{ tenant_param $t }}
+{{- end }}
+{{- hook "BeforeUpsert" $t }}
+	// upsert
+	{{ sqlstr "upsert_index" $i }}
+	// run
+{{- if $t.Manual }}
+	{{ logf $t }}
+{{- else }}
+	{{ logf $t $t.PrimaryKeys }}
+{{- end }}
+{{- if $t.Tenant }}
+	// a conflicting row of a different tenant returns no row
+	switch err := {{ db_prefix "QueryRow" true $t }}.Scan({{ names (print "&" (short $t) ".") $t.PrimaryKeys }}); {
+	case errors.Is(err, sql.ErrNoRows):
+		return logerror(&ErrUpsertFailed{ErrTenantMismatch})
+	case err != nil:
+		return logerror(err)
+	}
+{{- else }}
+	if err := {{ db_prefix "QueryRow" true $t }}.Scan({{ names (print "&" (short $t) ".") $t.PrimaryKeys }}); err != nil {
+		return logerror(err)
+	}
+{{- end }}
+	// set exists
+	{{ short $t }}._exists = true
+{{- hook "AfterUpsert" $t }}
+	return nil
+}
 
+{{ if context_both -}}
+// {{ $name }} performs an upsert for [{{ $t.GoName }}] on the conflict of
+// {{ range $k, $f := index_fields $i }}{{ if $k }}, {{ end }}'{{ $f.SQLName }}'{{ end }}.
+//
+// Generated from index '{{ $i.SQLName }}'.
+{{ recv $t $name }} {
+	return {{ short $t }}.{{ func_name_context $name }}({{ call_args "context.Background()" "db" (tenant_param $t) }})
+}
+{{- end }}
 {{end}}
 
 {{ define "procs" }}
 {{- $ps := .Data -}}
 {{- range $p := $ps -}}
-// {{ func_name_context $p }} calls the stored {{ $p.Type }} '{{ $p.Signature }}' on db.
+{{ query_id "proc" $p }}// {{ func_name_context $p }} calls the stored {{ $p.Type }} '{{ $p.Signature }}' on db.
 {{ func_context $p }} {
 {{- if and (driver "mysql") (eq $p.Type "procedure") (not $p.Void) }}
 	// At the moment, the Go MySQL driver does not support stored procedures
@@ -213,12 +724,24 @@ func (err ErrInvalid{{ $e.GoName }}) Error() string {
 {{ if context_both -}}
 // {{ func_name $p }} calls the {{ $p.Type }} '{{ $p.Signature }}' on db.
 {{ func $p }} {
-	return {{ func_name_context $p }}({{ names_all "" "context.Background()" "db" $p.Params }})
+	return {{ func_name_context $p }}({{ call_args "context.Background()" "db" $p.Params }})
 }
 {{- end -}}
 {{- end }}
 {{ end }}
 
+{{ define "constraint" }}
+{{- $c := .Data -}}
+// {{ $c.Table.GoName }} constraint and index names, such as for matching the
+// constraint name of a database error.
+const (
+{{ range $c.Constraints -}}
+	// {{ .GoName }} is the '{{ .SQLName }}' {{ .Kind }}.
+	{{ .GoName }} = "{{ .SQLName }}"
+{{ end -}}
+)
+{{ end }}
+
 {{ define "typedef" }}
 {{- $t := .Data -}}
 {{- if $t.Comment -}}
@@ -236,10 +759,86 @@ type {{ $t.GoName }} struct {
 {{ end -}}
 }
 
-{{ if $t.PrimaryKeys -}}
-// Exists returns true when the [{{ $t.GoName }}] exists in the database.
-func ({{ short $t }} *{{ $t.GoName }}) Exists() bool {
-	return {{ short $t }}._exists
+{{ if cdc -}}
+// decode{{ $t.GoName }} decodes the text format column values into a [{{ $t.GoName }}].
+func decode{{ $t.GoName }}(values map[string]any) (*{{ $t.GoName }}, error) {
+	var {{ short $t }} {{ $t.GoName }}
+	for name, v := range values {
+		var err error
+		switch name {
+{{- range $f := $t.Fields }}
+		case "{{ $f.SQLName }}":
+			err = changeAssign(&{{ short $t }}.{{ $f.GoName }}, v)
+{{- end }}
+		}
+		if err != nil {
+			return nil, logerror(fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return &{{ short $t }}, nil
+}
+
+// changeValues returns the column values of the [{{ $t.GoName }}].
+func ({{ short $t }} *{{ $t.GoName }}) changeValues() (map[string]any, error) {
+	return changeValues(map[string]any{
+{{- range $f := $t.Fields }}
+		"{{ $f.SQLName }}": {{ short $t }}.{{ $f.GoName }},
+{{- end }}
+	})
+}
+
+// {{ $t.GoName }}FromChange decodes the column values of the change into a [{{ $t.GoName }}].
+// The old column values are decoded for deletes.
+func {{ $t.GoName }}FromChange(c Change) (*{{ $t.GoName }}, error) {
+	if c.Table != "{{ $t.SQLName }}" {
+		return nil, logerror(ErrChangeTable)
+	}
+	if c.Kind == ChangeDelete {
+		return decode{{ $t.GoName }}(c.Identity)
+	}
+	{{ short $t }}, err := decode{{ $t.GoName }}(c.Columns)
+	if err != nil {
+		return nil, err
+	}
+{{- if $t.PrimaryKeys }}
+	{{ short $t }}._exists = true
+{{- end }}
+	return {{ short $t }}, nil
+}
+
+// {{ $t.GoName }}ChangeEvent decodes the old and new column values of the change into a
+// [ChangeEvent] for [{{ $t.GoName }}].
+func {{ $t.GoName }}ChangeEvent(c Change) (ChangeEvent[{{ $t.GoName }}], error) {
+	if c.Table != "{{ $t.SQLName }}" {
+		return ChangeEvent[{{ $t.GoName }}]{}, logerror(ErrChangeTable)
+	}
+	ev := ChangeEvent[{{ $t.GoName }}]{
+		Op:     c.Kind,
+		Schema: c.Schema,
+		Table:  c.Table,
+	}
+	var err error
+	if c.Identity != nil {
+		if ev.Old, err = decode{{ $t.GoName }}(c.Identity); err != nil {
+			return ChangeEvent[{{ $t.GoName }}]{}, err
+		}
+	}
+	if c.Columns != nil {
+		if ev.New, err = decode{{ $t.GoName }}(c.Columns); err != nil {
+			return ChangeEvent[{{ $t.GoName }}]{}, err
+		}
+{{- if $t.PrimaryKeys }}
+		ev.New._exists = true
+{{- end }}
+	}
+	return ev, nil
+}
+
+{{ end -}}
+{{ if $t.PrimaryKeys -}}
+// Exists returns true when the [{{ $t.GoName }}] exists in the database.
+func ({{ short $t }} *{{ $t.GoName }}) Exists() bool {
+	return {{ short $t }}._exists
 }
 
 // Deleted returns true when the [{{ $t.GoName }}] has been marked for deletion
@@ -248,7 +847,7 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	return {{ short $t }}._deleted
 }
 
-// {{ func_name_context "Insert" }} inserts the [{{ $t.GoName }}] to the database.
+{{ query_id "insert" $t }}// {{ func_name_context "Insert" }} inserts the [{{ $t.GoName }}] to the database.
 {{ recv_context $t "Insert" }} {
 	switch {
 	case {{ short $t }}._exists: // already exists
@@ -256,6 +855,7 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	case {{ short $t }}._deleted: // deleted
 		return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
 	}
+{{- hook "BeforeInsert" $t }}
 {{ if $t.Manual -}}
 	// insert (manual)
 	{{ sqlstr "insert_manual" $t }}
@@ -312,21 +912,22 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 {{- end }}
 	// set exists
 	{{ short $t }}._exists = true
+{{- hook "AfterInsert" $t }}
 	return nil
 }
 
 {{ if context_both -}}
 // Insert inserts the [{{ $t.GoName }}] to the database.
 {{ recv $t "Insert" }} {
-	return {{ short $t }}.InsertContext(context.Background(), db)
+	return {{ short $t }}.InsertContext({{ call_args "context.Background()" "db" }})
 }
 {{- end }}
 
 
-{{ if eq (len $t.Fields) (len $t.PrimaryKeys) -}}
+{{ if not (update_fields $t) -}}
 // ------ NOTE: Update statements omitted due to lack of fields other than primary key ------
 {{- else -}}
-// {{ func_name_context "Update" }} updates a [{{ $t.GoName }}] in the database.
+{{ query_id "update" $t }}// {{ func_name_context "Update" }} updates a [{{ $t.GoName }}] in the database.
 {{ recv_context $t "Update" }} {
 	switch {
 	case !{{ short $t }}._exists: // doesn't exist
@@ -334,68 +935,126 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	case {{ short $t }}._deleted: // deleted
 		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
 	}
+{{- hook "BeforeUpdate" $t }}
 	// update with {{ if driver "postgres" }}composite {{ end }}primary key
 	{{ sqlstr "update" $t }}
 	// run
 	{{ logf_update $t }}
+{{- if check_rows }}
+	res, err := {{ db_update "Exec" $t }}
+	if err != nil {
+		return logerror(err)
+	}
+	if err := checkRows(res); err != nil {
+		return logerror(&ErrUpdateFailed{err})
+	}
+{{- else }}
 	if _, err := {{ db_update "Exec" $t }}; err != nil {
 		return logerror(err)
 	}
+{{- end }}
+{{- hook "AfterUpdate" $t }}
 	return nil
 }
 
 {{ if context_both -}}
 // Update updates a [{{ $t.GoName }}] in the database.
 {{ recv $t "Update" }} {
-	return {{ short $t }}.UpdateContext(context.Background(), db)
+	return {{ short $t }}.UpdateContext({{ call_args "context.Background()" "db" (tenant_param $t) }})
 }
 {{- end }}
 
 // {{ func_name_context "Save" }} saves the [{{ $t.GoName }}] to the database.
 {{ recv_context $t "Save" }} {
 	if {{ short $t }}.Exists() {
-		return {{ short $t }}.{{ func_name_context "Update" }}({{ if context }}ctx, {{ end }}db)
+		return {{ short $t }}.{{ func_name_context "Update" }}({{ if context }}{{ call_args "ctx" "db" (tenant_param $t) }}{{ else }}{{ names "" "db" (tenant_param $t) }}{{ end }})
 	}
-	return {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}ctx, {{ end }}db)
+	return {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}{{ call_args "ctx" "db" }}{{ else }}db{{ end }})
 }
 
 {{ if context_both -}}
 // Save saves the [{{ $t.GoName }}] to the database.
 {{ recv $t "Save" }} {
 	if {{ short $t }}._exists {
-		return {{ short $t }}.UpdateContext(context.Background(), db)
+		return {{ short $t }}.UpdateContext({{ call_args "context.Background()" "db" (tenant_param $t) }})
 	}
-	return {{ short $t }}.InsertContext(context.Background(), db)
+	return {{ short $t }}.InsertContext({{ call_args "context.Background()" "db" }})
 }
 {{- end }}
 
-// {{ func_name_context "Upsert" }} performs an upsert for [{{ $t.GoName }}].
+{{ query_id "upsert" $t }}// {{ func_name_context "Upsert" }} performs an upsert for [{{ $t.GoName }}].
+{{- if $t.Tenant }}
+//
+// The row is upserted for the tenant. An existing row of a different tenant
+// with the same primary key is left unchanged, returning [ErrTenantMismatch].
+{{- end }}
 {{ recv_context $t "Upsert" }} {
 	switch {
 	case {{ short $t }}._deleted: // deleted
 		return logerror(&ErrUpsertFailed{ErrMarkedForDeletion})
 	}
+{{- if $t.Tenant }}
+	// scope to the tenant
+	{{ short $t }}.{{ $t.Tenant.GoName }} = {{ tenant_param $t }}
+{{- end }}
+{{- hook "BeforeUpsert" $t }}
 	// upsert
 	{{ sqlstr "upsert" $t }}
 	// run
 	{{ logf $t }}
+{{- if and $t.Tenant (not (driver "mysql")) }}
+	res, err := {{ db_prefix "Exec" false $t }}
+	if err != nil {
+		return logerror(err)
+	}
+	// a conflicting row of a different tenant is not affected
+	switch n, err := res.RowsAffected(); {
+	case err != nil:
+		return logerror(err)
+	case n == 0:
+		return logerror(&ErrUpsertFailed{ErrTenantMismatch})
+	}
+{{- else }}
 	if _, err := {{ db_prefix "Exec" false $t }}; err != nil {
 		return logerror(err)
 	}
+{{- end }}
+{{- if and $t.Tenant (driver "mysql") }}
+	// check the row is of the tenant, as mysql does not report an unchanged
+	// row as affected
+	{
+		{{ sqlstr "tenant_check" $t }}
+		{{ logf_pkeys $t }}
+		var n int
+		if err := {{ db "QueryRow" (names (print (short $t) ".") $t.PrimaryKeys) (tenant_param $t) }}.Scan(&n); err != nil {
+			return logerror(err)
+		}
+		if n == 0 {
+			return logerror(&ErrUpsertFailed{ErrTenantMismatch})
+		}
+	}
+{{- end }}
 	// set exists
 	{{ short $t }}._exists = true
+{{- hook "AfterUpsert" $t }}
 	return nil
 }
 
 {{ if context_both -}}
 // Upsert performs an upsert for [{{ $t.GoName }}].
 {{ recv $t "Upsert" }} {
-	return {{ short $t }}.UpsertContext(context.Background(), db)
+	return {{ short $t }}.UpsertContext({{ call_args "context.Background()" "db" (tenant_param $t) }})
 }
 {{- end -}}
 {{- end }}
 
+{{ query_id "delete" $t }}
+{{- if $t.SoftDelete -}}
+// {{ func_name_context "Delete" }} soft deletes the [{{ $t.GoName }}], setting '{{ $t.SoftDelete.SQLName }}' to the
+// current time. Use [{{ $t.GoName }}.{{ func_name_context "Destroy" }}] to permanently delete the row.
+{{- else -}}
 // {{ func_name_context "Delete" }} deletes the [{{ $t.GoName }}] from the database.
+{{- end }}
 {{ recv_context $t "Delete" }} {
 	switch {
 	case !{{ short $t }}._exists: // doesn't exist
@@ -403,33 +1062,831 @@ func ({{ short $t }} *{{ $t.GoName }}) Deleted() bool {
 	case {{ short $t }}._deleted: // deleted
 		return nil
 	}
+{{- hook "BeforeDelete" $t }}
 {{ if eq (len $t.PrimaryKeys) 1 -}}
 	// delete with single primary key
 	{{ sqlstr "delete" $t }}
 	// run
 	{{ logf_pkeys $t }}
-	if _, err := {{ db "Exec" (print (short $t) "." (index $t.PrimaryKeys 0).GoName) }}; err != nil {
+{{- if check_rows }}
+	res, err := {{ db "Exec" (print (short $t) "." (index $t.PrimaryKeys 0).GoName) (tenant_param $t) }}
+	if err != nil {
+		return logerror(err)
+	}
+	if err := checkRows(res); err != nil {
+		return logerror(&ErrDeleteFailed{err})
+	}
+{{- else }}
+	if _, err := {{ db "Exec" (print (short $t) "." (index $t.PrimaryKeys 0).GoName) (tenant_param $t) }}; err != nil {
 		return logerror(err)
 	}
+{{- end }}
 {{- else -}}
 	// delete with composite primary key
 	{{ sqlstr "delete" $t }}
 	// run
 	{{ logf_pkeys $t }}
-	if _, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) }}; err != nil {
+{{- if check_rows }}
+	res, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) (tenant_param $t) }}
+	if err != nil {
+		return logerror(err)
+	}
+	if err := checkRows(res); err != nil {
+		return logerror(&ErrDeleteFailed{err})
+	}
+{{- else }}
+	if _, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) (tenant_param $t) }}; err != nil {
 		return logerror(err)
 	}
+{{- end }}
 {{- end }}
 	// set deleted
 	{{ short $t }}._deleted = true
+{{- hook "AfterDelete" $t }}
 	return nil
 }
 
 {{ if context_both -}}
-// Delete deletes the [{{ $t.GoName }}] from the database.
+// Delete {{ if $t.SoftDelete }}soft {{ end }}deletes the [{{ $t.GoName }}] from the database.
 {{ recv $t "Delete" }} {
-	return {{ short $t }}.DeleteContext(context.Background(), db)
+	return {{ short $t }}.DeleteContext({{ call_args "context.Background()" "db" (tenant_param $t) }})
 }
 {{- end -}}
+
+{{- if $t.SoftDelete }}
+
+{{ query_id "destroy" $t }}// {{ func_name_context "Destroy" }} permanently deletes the [{{ $t.GoName }}] from the database,
+// including when soft deleted.
+{{ recv_context $t "Destroy" }} {
+	if !{{ short $t }}._exists { // doesn't exist
+		return nil
+	}
+{{- hook "BeforeDelete" $t }}
+	// delete
+	{{ sqlstr "destroy" $t }}
+	// run
+	{{ logf_pkeys $t }}
+{{- if check_rows }}
+	res, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) (tenant_param $t) }}
+	if err != nil {
+		return logerror(err)
+	}
+	if err := checkRows(res); err != nil {
+		return logerror(&ErrDeleteFailed{err})
+	}
+{{- else }}
+	if _, err := {{ db "Exec" (names (print (short $t) ".") $t.PrimaryKeys) (tenant_param $t) }}; err != nil {
+		return logerror(err)
+	}
 {{- end }}
+	// set deleted
+	{{ short $t }}._deleted = true
+{{- hook "AfterDelete" $t }}
+	return nil
+}
+
+{{ if context_both -}}
+// Destroy permanently deletes the [{{ $t.GoName }}] from the database.
+{{ recv $t "Destroy" }} {
+	return {{ short $t }}.DestroyContext({{ call_args "context.Background()" "db" (tenant_param $t) }})
+}
+{{- end -}}
+{{- end -}}
+
+{{- if $t.ValidTo }}
+
+{{ query_id "supersede" $t }}// {{ func_name_context "Supersede" }} closes the validity window of the current version of
+// the [{{ $t.GoName }}] at the time and inserts the [{{ $t.GoName }}] as the new
+// version valid from the time. When db can begin a transaction (i.e. a
+// [*sql.DB]), both are run within a new transaction. Superseding a version
+// that was already closed returns [ErrSuperseded].
+{{ recv_context $t "Supersede" }} {
+	switch {
+	case !{{ short $t }}._exists: // doesn't exist
+		return logerror(&ErrUpdateFailed{ErrDoesNotExist})
+	case {{ short $t }}._deleted: // deleted
+		return logerror(&ErrUpdateFailed{ErrMarkedForDeletion})
+	}
+	return inTx({{ if context }}ctx, {{ end }}db, func(db {{ db_type }}) error {
+		// close current version
+		{{ sqlstr "supersede" $t }}
+		// run
+		logf(sqlstr, at, {{ names (print (short $t) ".") $t.PrimaryKeys }})
+		res, err := {{ db "Exec" "at" (names (print (short $t) ".") $t.PrimaryKeys) }}
+		if err != nil {
+			return logerror(err)
+		}
+		// only the open version is closed
+		switch n, err := res.RowsAffected(); {
+		case err != nil:
+			return logerror(err)
+		case n != 1:
+			return logerror(&ErrUpdateFailed{ErrSuperseded})
+		}
+		// open new version
+		{{ short $t }}.{{ $t.ValidFrom.GoName }} = {{ temporal_at $t }}
+		{{ short $t }}.{{ $t.ValidTo.GoName }} = {{ $t.ValidTo.Zero }}
+		{{ short $t }}._exists = false
+		return {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}{{ call_args "ctx" "db" }}{{ else }}db{{ end }})
+	})
+}
+
+{{ if context_both -}}
+// Supersede closes the validity window of the current version of the
+// [{{ $t.GoName }}] at the time and inserts the [{{ $t.GoName }}] as the new
+// version valid from the time. When db can begin a transaction (i.e. a
+// [*sql.DB]), both are run within a new transaction.
+{{ recv $t "Supersede" }} {
+	return {{ short $t }}.SupersedeContext({{ call_args "context.Background()" "db" "at" }})
+}
+{{- end -}}
+{{- end }}
+{{- if and outbox (ne $t.GoName outbox) }}
+
+// {{ func_name_context "InsertWithOutbox" }} inserts the [{{ $t.GoName }}] and the [{{ outbox }}] event
+// into the database. When db can begin a transaction (i.e. a [*sql.DB]), both
+// are inserted within a new transaction.
+{{ recv_context $t "InsertWithOutbox" }} {
+	return inTx({{ if context }}ctx, {{ end }}db, func(db {{ db_type }}) error {
+		if err := {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}{{ call_args "ctx" "db" }}{{ else }}db{{ end }}); err != nil {
+			return err
+		}
+		return event.{{ func_name_context "Insert" }}({{ if context }}{{ call_args "ctx" "db" }}{{ else }}db{{ end }})
+	})
+}
+
+{{ if context_both -}}
+// InsertWithOutbox inserts the [{{ $t.GoName }}] and the [{{ outbox }}] event into the
+// database. When db can begin a transaction (i.e. a [*sql.DB]), both are
+// inserted within a new transaction.
+{{ recv $t "InsertWithOutbox" }} {
+	return {{ short $t }}.InsertWithOutboxContext({{ call_args "context.Background()" "db" "event" }})
+}
+{{- end -}}
+{{- end }}
+{{- end }}
+{{ end }}
+
+{{ define "outbox" }}
+{{- $t := .Data -}}
+// {{ $t.GoName }}Handler handles an event from '{{ schema $t.SQLName }}'.
+type {{ $t.GoName }}Handler func(context.Context, *{{ $t.GoName }}) error
+
+// poll{{ $t.GoName }}Event handles and deletes the next [{{ $t.GoName }}] event within a
+// new transaction, returning false when there are no events.
+func poll{{ $t.GoName }}Event(ctx context.Context, db *sql.DB, h {{ $t.GoName }}Handler) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, logerror(err)
+	}
+	// query
+	{{ sqlstr "outbox" $t }}
+	// run
+	logf(sqlstr)
+	{{ short $t }} := {{ $t.GoName }}{
+		_exists: true,
+	}
+	switch err := tx.QueryRowContext(ctx, sqlstr).Scan({{ names (print "&" (short $t) ".") $t }}); {
+	case errors.Is(err, sql.ErrNoRows):
+		_ = tx.Rollback()
+		return false, nil
+	case err != nil:
+		_ = tx.Rollback()
+		return false, logerror(err)
+	}
+	// handle
+	if err := h(ctx, &{{ short $t }}); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if err := {{ short $t }}.{{ func_name_context "Delete" }}({{ if context }}{{ call_args "ctx" "tx" (tenant_param $t) }}{{ else }}{{ names "" "tx" (tenant_param $t) }}{{ end }}); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, logerror(err)
+	}
+	return true, nil
+}
+
+// poll{{ $t.GoName }} handles and deletes up to limit [{{ $t.GoName }}] events in primary key
+// order, returning the number of handled events.
+func poll{{ $t.GoName }}(ctx context.Context, db *sql.DB, limit int, h {{ $t.GoName }}Handler) (int, error) {
+	for n := 0; n < limit; n++ {
+		ok, err := poll{{ $t.GoName }}Event(ctx, db, h)
+		if err != nil || !ok {
+			return n, err
+		}
+	}
+	return limit, nil
+}
+{{ if context }}
+// {{ func_name_context (print "Poll" $t.GoName) }} handles and deletes up to limit [{{ $t.GoName }}] events in
+// primary key order, returning the number of handled events. Each event is
+// handled and deleted within its own transaction, so an event is only deleted
+// once handled, and is retried by the next poll when the handler errors.
+func {{ func_name_context (print "Poll" $t.GoName) }}({{ call_args "ctx context.Context" "db *sql.DB" "limit int" (print "h " $t.GoName "Handler") }}) (int, error) {
+	return poll{{ $t.GoName }}(ctx, db, limit, h)
+}
+
+// Run{{ $t.GoName }} polls the [{{ $t.GoName }}] events every interval, handling and deleting
+// up to limit events per poll, until the context is done or the handler
+// errors.
+func Run{{ $t.GoName }}({{ call_args "ctx context.Context" "db *sql.DB" "interval time.Duration" "limit int" (print "h " $t.GoName "Handler") }}) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := poll{{ $t.GoName }}(ctx, db, limit, h)
+		if err != nil {
+			return err
+		}
+		// poll again immediately when events may remain
+		if n != 0 && n == limit {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+{{ end }}
+{{- if not context }}
+// Poll{{ $t.GoName }} handles and deletes up to limit [{{ $t.GoName }}] events in primary
+// key order, returning the number of handled events. Each event is handled and
+// deleted within its own transaction, so an event is only deleted once
+// handled, and is retried by the next poll when the handler errors.
+func Poll{{ $t.GoName }}(db *sql.DB, limit int, h {{ $t.GoName }}Handler) (int, error) {
+	return poll{{ $t.GoName }}(context.Background(), db, limit, h)
+}
+{{ else if context_both }}
+// Poll{{ $t.GoName }} handles and deletes up to limit [{{ $t.GoName }}] events in primary
+// key order, returning the number of handled events. Each event is handled and
+// deleted within its own transaction, so an event is only deleted once
+// handled, and is retried by the next poll when the handler errors.
+func Poll{{ $t.GoName }}(db *sql.DB, limit int, h {{ $t.GoName }}Handler) (int, error) {
+	return Poll{{ $t.GoName }}Context({{ call_args "context.Background()" "db" "limit" "h" }})
+}
+{{ end }}
+{{- end }}
+
+{{ define "notify" }}
+{{- $tables := .Data -}}
+// NotifyChannel is the channel notified of changes to table rows.
+const NotifyChannel = "{{ notify }}"
+
+// NotifyTriggers is the SQL creating the triggers notifying [NotifyChannel] of
+// changes to table rows, with [ChangeEvent] payloads decoded by
+// [DecodeNotify]. NOTIFY payloads are limited to 8000 bytes.
+const NotifyTriggers = `{{ notify_triggers $tables }}`
+{{ end }}
+
+{{ define "insert_many" }}
+{{- $t := .Data -}}
+{{- $s := short $t -}}
+{{- $seq := insert_many_returning $t -}}
+// {{ func_name_context (print "InsertMany" $t.GoName) }} inserts the [{{ $t.GoName }}] rows to the database with
+// multi-row INSERT statements of up to {{ insert_many_size $t }} rows. Each statement is run
+// separately, so rows are inserted atomically only when db is a transaction.
+{{- if $seq }}
+//
+// The {{ $seq.GoName }} of each row is set from the RETURNING clause.
+{{- else if not $t.Manual }}
+//
+// As the generated primary keys are not returned, the rows are not marked as
+// existing.
+{{- end }}
+{{- if hooks }}
+//
+// The BeforeInsert hook of every row is called before the first statement, and
+// the AfterInsert hook of each row after the statement inserting it.
+{{- end }}
+func {{ func_name_context (print "InsertMany" $t.GoName) }}({{ if context }}{{ call_args "ctx context.Context" (print "db " db_type) (print "rows []*" $t.GoName) }}{{ else }}db {{ db_type }}, rows []*{{ $t.GoName }}{{ end }}) error {
+	for _, {{ $s }} := range rows {
+		switch {
+		case {{ $s }}._exists: // already exists
+			return logerror(&ErrInsertFailed{ErrAlreadyExists})
+		case {{ $s }}._deleted: // deleted
+			return logerror(&ErrInsertFailed{ErrMarkedForDeletion})
+		}
+{{- hook "BeforeInsert" $t 2 }}
+	}
+	// insert
+	{{ sqlstr "insert_many" $t }}
+	for len(rows) != 0 {
+		n := len(rows)
+		if n > {{ insert_many_size $t }} {
+			n = {{ insert_many_size $t }}
+		}
+		batch := rows[:n]
+		rows = rows[n:]
+		var values []string
+		var args []any
+		for _, {{ $s }} := range batch {
+			values = append(values, {{ insert_many_values $t "len(args)" }})
+			args = append(args, {{ names (print $s ".") (insert_fields $t $t.Manual) }})
+		}
+		query := sqlstr + strings.Join(values, ", "){{ if $seq }} + ` RETURNING {{ colname $seq }}`{{ end }}
+		// run
+		logf(query, args...)
+{{- if $seq }}
+		res, err := db.{{ if context }}QueryContext(ctx, {{ else }}Query({{ end }}query, args...)
+		if err != nil {
+			return logerror(err)
+		}
+		for i := 0; res.Next() && i < len(batch); i++ {
+			if err = res.Scan(&batch[i].{{ $seq.GoName }}); err != nil {
+				res.Close()
+				return logerror(err)
+			}
+			batch[i]._exists = true
+		}
+		if err = res.Close(); err != nil {
+			return logerror(err)
+		}
+		if err = res.Err(); err != nil {
+			return logerror(err)
+		}
+{{- if hooks }}
+		for _, {{ $s }} := range batch {
+{{- hook "AfterInsert" $t 3 }}
+		}
+{{- end }}
+{{- else }}
+		if _, err := db.{{ if context }}ExecContext(ctx, {{ else }}Exec({{ end }}query, args...); err != nil {
+			return logerror(err)
+		}
+{{- if or $t.Manual hooks }}
+		for _, {{ $s }} := range batch {
+{{- if $t.Manual }}
+			{{ $s }}._exists = true
+{{- end }}
+{{- hook "AfterInsert" $t 3 }}
+		}
+{{- end }}
+{{- end }}
+	}
+	return nil
+}
+{{ if context_both }}
+// InsertMany{{ $t.GoName }} inserts the [{{ $t.GoName }}] rows to the database with
+// multi-row INSERT statements.
+func InsertMany{{ $t.GoName }}(db {{ db_type }}, rows []*{{ $t.GoName }}) error {
+	return {{ func_name_context (print "InsertMany" $t.GoName) }}({{ call_args "context.Background()" "db" "rows" }})
+}
+{{ end }}
+{{- end }}
+
+{{ define "reconcile" }}
+{{- $t := .Data -}}
+// {{ func_name_context (print "Reconcile" $t.GoName) }} compares the [{{ $t.GoName }}] rows of src and dst, streaming both
+// ordered by primary key, and reports the differences. When opts.Apply is set,
+// the differences are applied to dst, upserting missing rows, updating changed
+// rows and deleting extra rows.
+{{- if $t.Tenant }}
+//
+// Only the rows of the tenant are compared.
+{{- end }}
+{{- if $t.SoftDelete }}
+//
+// Soft deleted rows are not compared, and extra rows are soft deleted.
+{{- end }}
+//
+// Rows are matched by comparing primary keys in Go, so text primary keys must
+// be ordered bytewise by the database (i.e. with a C or binary collation).
+func {{ func_name_context (print "Reconcile" $t.GoName) }}({{ if context }}{{ call_args "ctx context.Context" (print "src " (reconcile_src) ", dst " db_type) "opts ReconcileOptions" (reconcile_tenant $t) }}{{ else }}{{ names "" (print "src " (reconcile_src)) (print "dst " db_type) "opts ReconcileOptions" (reconcile_tenant $t) }}{{ end }}) (ReconcileResult, error) {
+	// query
+	{{ sqlstr "reconcile" $t }}
+	// run
+	logf({{ names "" "sqlstr" (tenant_param $t) }})
+	srcRows, err := src.{{ if context }}QueryContext(ctx, {{ else }}Query({{ end }}{{ names "" "sqlstr" (tenant_param $t) }})
+	if err != nil {
+		return ReconcileResult{}, logerror(err)
+	}
+	defer srcRows.Close()
+	dstRows, err := dst.{{ if context }}QueryContext(ctx, {{ else }}Query({{ end }}{{ names "" "sqlstr" (tenant_param $t) }})
+	if err != nil {
+		return ReconcileResult{}, logerror(err)
+	}
+	defer dstRows.Close()
+	next := func(rows *sql.Rows) (_ *{{ $t.GoName }}, err error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		{{ short $t }} := {{ $t.GoName }}{
+			_exists: true,
+		}
+		if err = rows.Scan({{ names (print "&" (short $t) ".") $t }}); err != nil {
+			return nil, err
+		}
+		return &{{ short $t }}, nil
+	}
+	// compare
+	var res ReconcileResult
+	var missing, changed, extra []*{{ $t.GoName }}
+	s, err := next(srcRows)
+	if err != nil {
+		return ReconcileResult{}, logerror(err)
+	}
+	d, err := next(dstRows)
+	if err != nil {
+		return ReconcileResult{}, logerror(err)
+	}
+	for s != nil || d != nil {
+		var diff Diff
+		switch {
+		case d == nil || (s != nil && s.reconcileCompare(d) < 0):
+			diff = Diff{Table: "{{ $t.SQLName }}", Kind: DiffMissing, Src: s}
+			res.Missing, missing = res.Missing+1, append(missing, s)
+			if s, err = next(srcRows); err != nil {
+				return ReconcileResult{}, logerror(err)
+			}
+		case s == nil || s.reconcileCompare(d) > 0:
+			diff = Diff{Table: "{{ $t.SQLName }}", Kind: DiffExtra, Dst: d}
+			res.Extra, extra = res.Extra+1, append(extra, d)
+			if d, err = next(dstRows); err != nil {
+				return ReconcileResult{}, logerror(err)
+			}
+		default:
+			if !s.reconcileEqual(d) {
+				diff = Diff{Table: "{{ $t.SQLName }}", Kind: DiffChanged, Src: s, Dst: d}
+				res.Changed, changed = res.Changed+1, append(changed, s)
+			}
+			if s, err = next(srcRows); err != nil {
+				return ReconcileResult{}, logerror(err)
+			}
+			if d, err = next(dstRows); err != nil {
+				return ReconcileResult{}, logerror(err)
+			}
+		}
+		if diff.Kind != "" && opts.Report != nil {
+			opts.Report(diff)
+		}
+	}
+	if !opts.Apply {
+		return res, nil
+	}
+	// apply
+	srcRows.Close()
+	dstRows.Close()
+	for _, {{ short $t }} := range missing {
+		if err := {{ short $t }}.{{ func_name_context "Upsert" }}({{ if context }}{{ call_args "ctx" "dst" (tenant_param $t) }}{{ else }}{{ names "" "dst" (tenant_param $t) }}{{ end }}); err != nil {
+			return res, err
+		}
+	}
+{{- $updateOnly := false }}{{ range $t.Fields }}{{ if .UpdateOnly }}{{ $updateOnly = true }}{{ end }}{{ end }}
+{{- if $updateOnly }}
+	// update only fields are not set by upserts
+	changed = append(missing, changed...)
+{{- end }}
+	for _, {{ short $t }} := range changed {
+		if err := {{ short $t }}.{{ func_name_context "Update" }}({{ if context }}{{ call_args "ctx" "dst" (tenant_param $t) }}{{ else }}{{ names "" "dst" (tenant_param $t) }}{{ end }}); err != nil {
+			return res, err
+		}
+	}
+	for _, {{ short $t }} := range extra {
+		if err := {{ short $t }}.{{ func_name_context "Delete" }}({{ if context }}{{ call_args "ctx" "dst" (tenant_param $t) }}{{ else }}{{ names "" "dst" (tenant_param $t) }}{{ end }}); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+{{ if context_both }}
+// Reconcile{{ $t.GoName }} compares the [{{ $t.GoName }}] rows of src and dst, streaming both
+// ordered by primary key, and reports the differences. When opts.Apply is set,
+// the differences are applied to dst.
+func Reconcile{{ $t.GoName }}({{ names "" (print "src " (reconcile_src)) (print "dst " db_type) "opts ReconcileOptions" (reconcile_tenant $t) }}) (ReconcileResult, error) {
+	return {{ func_name_context (print "Reconcile" $t.GoName) }}({{ call_args "context.Background()" "src" "dst" "opts" (tenant_param $t) }})
+}
+{{ end }}
+// reconcileCompare compares the primary keys of the [{{ $t.GoName }}] rows.
+func ({{ short $t }} *{{ $t.GoName }}) reconcileCompare(other *{{ $t.GoName }}) int {
+{{- range $z := $t.PrimaryKeys }}
+{{- if go_version 21 }}
+	if c := cmp.Compare({{ short $t }}.{{ $z.GoName }}, other.{{ $z.GoName }}); c != 0 {
+		return c
+	}
+{{- else }}
+	switch {
+	case {{ short $t }}.{{ $z.GoName }} < other.{{ $z.GoName }}:
+		return -1
+	case {{ short $t }}.{{ $z.GoName }} > other.{{ $z.GoName }}:
+		return 1
+	}
+{{- end }}
+{{- end }}
+	return 0
+}
+
+// reconcileEqual returns true when the fields of the [{{ $t.GoName }}] rows set by
+// {{ func_name_context "Update" }} are equal.
+func ({{ short $t }} *{{ $t.GoName }}) reconcileEqual(other *{{ $t.GoName }}) bool {
+	return {{ range $i, $z := update_fields $t }}{{ if $i }} &&
+		{{ end }}{{ reconcile_equal $z (short $t) "other" }}{{ end }}
+}
+{{ end }}
+
+{{ define "unitofwork" }}
+{{- $tables := .Data -}}
+// uowOp is a buffered [UnitOfWork] operation.
+type uowOp func(context.Context, {{ db_type }}) error
+
+// UnitOfWork buffers Insert, Update, and Delete calls, and flushes them to the
+// database in foreign key dependency order. Inserts and updates are run for
+// referenced tables first, and deletes are run for referencing tables first.
+type UnitOfWork struct {
+	inserts [{{ len $tables }}][]uowOp
+	updates [{{ len $tables }}][]uowOp
+	deletes [{{ len $tables }}][]uowOp
+}
+{{ range $i, $t := $tables }}
+// Insert{{ $t.GoName }} buffers an insert of the [{{ $t.GoName }}].
+{{ uow_func "Insert" $t }} {
+	uow.inserts[{{ $i }}] = append(uow.inserts[{{ $i }}], func(ctx context.Context, db {{ db_type }}) error {
+{{- if not context }}
+		_ = ctx
+{{- end }}
+		return {{ short $t }}.{{ func_name_context "Insert" }}({{ if context }}{{ call_args "ctx" "db" }}{{ else }}db{{ end }})
+	})
+}
+{{ if update_fields $t }}
+// Update{{ $t.GoName }} buffers an update of the [{{ $t.GoName }}].
+{{ uow_func "Update" $t }} {
+	uow.updates[{{ $i }}] = append(uow.updates[{{ $i }}], func(ctx context.Context, db {{ db_type }}) error {
+{{- if not context }}
+		_ = ctx
+{{- end }}
+		return {{ short $t }}.{{ func_name_context "Update" }}({{ if context }}{{ call_args "ctx" "db" (tenant_param $t) }}{{ else }}{{ names "" "db" (tenant_param $t) }}{{ end }})
+	})
+}
+{{ end }}
+// Delete{{ $t.GoName }} buffers a delete of the [{{ $t.GoName }}].
+{{ uow_func "Delete" $t }} {
+	uow.deletes[{{ $i }}] = append(uow.deletes[{{ $i }}], func(ctx context.Context, db {{ db_type }}) error {
+{{- if not context }}
+		_ = ctx
+{{- end }}
+		return {{ short $t }}.{{ func_name_context "Delete" }}({{ if context }}{{ call_args "ctx" "db" (tenant_param $t) }}{{ else }}{{ names "" "db" (tenant_param $t) }}{{ end }})
+	})
+}
+{{ end }}
+// Reset discards the buffered operations.
+func (uow *UnitOfWork) Reset() {
+	*uow = UnitOfWork{}
+}
+
+// run runs the buffered operations on db.
+func (uow *UnitOfWork) run(ctx context.Context, db {{ db_type }}) error {
+	for _, ops := range uow.inserts {
+		for _, op := range ops {
+			if err := op(ctx, db); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ops := range uow.updates {
+		for _, op := range ops {
+			if err := op(ctx, db); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(uow.deletes) - 1; i >= 0; i-- {
+		for _, op := range uow.deletes[i] {
+			if err := op(ctx, db); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flush runs the buffered operations on tx within a savepoint, rolling back to
+// the savepoint on error.
+func (uow *UnitOfWork) flush(ctx context.Context, tx *sql.Tx) error {
+{{- if driver "sqlserver" }}
+	const savepoint, rollback = `SAVE TRANSACTION unit_of_work`, `ROLLBACK TRANSACTION unit_of_work`
+{{- else }}
+	const savepoint, rollback = `SAVEPOINT unit_of_work`, `ROLLBACK TO SAVEPOINT unit_of_work`
+{{- end }}
+	logf(savepoint)
+	if _, err := tx.ExecContext(ctx, savepoint); err != nil {
+		return logerror(err)
+	}
+	if err := uow.run(ctx, tx); err != nil {
+		logf(rollback)
+		if _, rollbackErr := tx.ExecContext(ctx, rollback); rollbackErr != nil {
+			return logerror(rollbackErr)
+		}
+		return err
+	}
+{{- if not (driver "sqlserver" "oracle") }}
+	const release = `RELEASE SAVEPOINT unit_of_work`
+	logf(release)
+	if _, err := tx.ExecContext(ctx, release); err != nil {
+		return logerror(err)
+	}
+{{- end }}
+	return nil
+}
+
+// commit runs the buffered operations in a new transaction on db, resetting
+// the unit of work once committed.
+func (uow *UnitOfWork) commit(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return logerror(err)
+	}
+	if err := uow.run(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return logerror(err)
+	}
+	uow.Reset()
+	return nil
+}
+{{ if context }}
+// {{ func_name_context "Flush" }} runs the buffered operations on tx within a savepoint.
+// The buffered operations are retained, and should be discarded with
+// [UnitOfWork.Reset] once tx is committed.
+func (uow *UnitOfWork) {{ func_name_context "Flush" }}({{ call_args "ctx context.Context" "tx *sql.Tx" }}) error {
+	return uow.flush(ctx, tx)
+}
+
+// {{ func_name_context "Commit" }} runs the buffered operations in a new transaction on db,
+// resetting the unit of work once committed.
+func (uow *UnitOfWork) {{ func_name_context "Commit" }}({{ call_args "ctx context.Context" "db *sql.DB" }}) error {
+	return uow.commit(ctx, db)
+}
+{{ end }}
+{{- if not context }}
+// Flush runs the buffered operations on tx within a savepoint. The buffered
+// operations are retained, and should be discarded with [UnitOfWork.Reset]
+// once tx is committed.
+func (uow *UnitOfWork) Flush(tx *sql.Tx) error {
+	return uow.flush(context.Background(), tx)
+}
+
+// Commit runs the buffered operations in a new transaction on db, resetting
+// the unit of work once committed.
+func (uow *UnitOfWork) Commit(db *sql.DB) error {
+	return uow.commit(context.Background(), db)
+}
+{{ else if context_both }}
+// Flush runs the buffered operations on tx within a savepoint. The buffered
+// operations are retained, and should be discarded with [UnitOfWork.Reset]
+// once tx is committed.
+func (uow *UnitOfWork) Flush(tx *sql.Tx) error {
+	return uow.FlushContext({{ call_args "context.Background()" "tx" }})
+}
+
+// Commit runs the buffered operations in a new transaction on db, resetting
+// the unit of work once committed.
+func (uow *UnitOfWork) Commit(db *sql.DB) error {
+	return uow.CommitContext({{ call_args "context.Background()" "db" }})
+}
+{{ end }}
+{{- end }}
+
+{{ define "test_db" -}}
+// canceledDriver is a [driver.Connector] failing all connections, as
+// [database/sql] returns the error of a canceled context before connecting.
+type canceledDriver struct{}
+
+// Open satisfies the [driver.Driver] interface.
+func (d canceledDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("unexpected connection")
+}
+
+// Connect satisfies the [driver.Connector] interface.
+func (d canceledDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+// Driver satisfies the [driver.Connector] interface.
+func (d canceledDriver) Driver() driver.Driver {
+	return d
+}
+
+// canceled returns a canceled context and a db that fails all connections.
+func canceled(t *testing.T) (context.Context, *sql.DB) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := sql.OpenDB(canceledDriver{})
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return ctx, db
+}
+{{ end }}
+
+{{ define "test" }}
+{{- $t := .Data.Table -}}
+// Test{{ $t.GoName }}Canceled tests that the [{{ $t.GoName }}] funcs return
+// the error of a canceled context.
+func Test{{ $t.GoName }}Canceled(t *testing.T) {
+	ctx, db := canceled(t)
+{{- if $t.PrimaryKeys }}
+	if err := (&{{ $t.GoName }}{}).{{ test_call "Insert" $t }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("Insert: expected context.Canceled, got: %v", err)
+	}
+{{- if update_fields $t }}
+	if err := (&{{ $t.GoName }}{_exists: true}).{{ test_call "Update" $t }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("Update: expected context.Canceled, got: %v", err)
+	}
+	if err := (&{{ $t.GoName }}{}).{{ test_call "Upsert" $t }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("Upsert: expected context.Canceled, got: %v", err)
+	}
+{{- end }}
+	if err := (&{{ $t.GoName }}{_exists: true}).{{ test_call "Delete" $t }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("Delete: expected context.Canceled, got: %v", err)
+	}
+{{- if $t.SoftDelete }}
+	if err := (&{{ $t.GoName }}{_exists: true}).{{ test_call "Destroy" $t }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("Destroy: expected context.Canceled, got: %v", err)
+	}
+{{- end }}
+{{- end }}
+{{- range $i := .Data.Indexes }}
+	if _, err := {{ test_call $i }}; !errors.Is(err, context.Canceled) {
+		t.Errorf("{{ func_name_context $i }}: expected context.Canceled, got: %v", err)
+	}
+{{- end }}
+}
+{{ end }}
+
+{{ define "explain" -}}
+import _ "github.com/lib/pq"
+
+// explainRows is the estimated row count of tables that statements must not
+// sequentially scan.
+const explainRows = {{ .Data }}
+
+// explainNode is a node of a query plan.
+type explainNode struct {
+	NodeType string        `json:"Node Type"`
+	Schema   string        `json:"Schema"`
+	Relation string        `json:"Relation Name"`
+	Plans    []explainNode `json:"Plans"`
+}
+
+// TestQueryPlans explains each statement of the query catalog on the database
+// of the DBTPL_TEST_DB environment variable, failing when a statement's plan
+// sequentially scans a table estimated to have more than explainRows rows.
+//
+// Statements are explained with GENERIC_PLAN, requiring PostgreSQL 16 or later.
+func TestQueryPlans(t *testing.T) {
+	dsn := os.Getenv("DBTPL_TEST_DB")
+	if dsn == "" {
+		t.Skip("DBTPL_TEST_DB not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	defer db.Close()
+	for _, q := range Queries() {
+		t.Run(q.Name, func(t *testing.T) {
+			var buf []byte
+			if err := db.QueryRow("EXPLAIN (FORMAT JSON, VERBOSE, GENERIC_PLAN) " + q.SQL).Scan(&buf); err != nil {
+				t.Fatalf("unable to explain %q: %v", q.SQL, err)
+			}
+			var plans []struct {
+				Plan explainNode `json:"Plan"`
+			}
+			if err := json.Unmarshal(buf, &plans); err != nil {
+				t.Fatalf("unable to decode plan: %v", err)
+			}
+			for _, plan := range plans {
+				checkPlan(t, db, plan.Plan)
+			}
+		})
+	}
+}
+
+// checkPlan checks that the plan node and its children do not sequentially
+// scan a table estimated to have more than explainRows rows.
+func checkPlan(t *testing.T, db *sql.DB, node explainNode) {
+	t.Helper()
+	if node.NodeType == "Seq Scan" {
+		name := node.Relation
+		if node.Schema != "" {
+			name = node.Schema + "." + name
+		}
+		var rows float64
+		if err := db.QueryRow(`SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)`, name).Scan(&rows); err != nil {
+			t.Fatalf("unable to get row count of %s: %v", name, err)
+		}
+		if rows > explainRows {
+			t.Errorf("sequential scan of %s (%.0f rows)", name, rows)
+		}
+	}
+	for _, child := range node.Plans {
+		checkPlan(t, db, child)
+	}
+}
 {{ end }}
@@ -2,16 +2,18 @@ package templates
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"embed"
 	"fmt"
 	"io/fs"
 	"maps"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"slices"
-	"sort"
 	"strings"
 	"text/template"
 
@@ -35,6 +37,7 @@ type Templates struct {
 	post     map[string][]byte
 	err      error
 	tpl      *template.Template
+	order    map[string]int
 }
 
 // New creates a template set.
@@ -203,6 +206,7 @@ func (ts *Templates) addFile(ctx context.Context) func(xo.Template) {
 			// Force all templates to be outputted in the specified file if xo is in single mode.
 			t.Dest = singleFile
 		}
+		xo.Logf(ctx, "EMIT: %s -> %s (sort: %q, %q)", t.Partial, t.Dest, t.SortType, t.SortName)
 		if _, ok := ts.files[t.Dest]; !ok {
 			ts.files[t.Dest] = &EmittedTemplate{}
 		}
@@ -229,8 +233,21 @@ func (ts *Templates) Pre(ctx context.Context, outDir string, mode string, set *x
 	}
 }
 
-// Process processes the template target.
+// Process processes the template target, emitting and executing the
+// templates for each file.
 func (ts *Templates) Process(ctx context.Context, outDir string, mode string, set *xo.Set) {
+	if ts.Emit(ctx, mode, set); ts.err != nil {
+		return
+	}
+	for _, file := range slices.Sorted(maps.Keys(ts.files)) {
+		ts.execute(file)
+	}
+}
+
+// Emit emits the templates for each file of the template target, and parses
+// the template target's templates, without executing them. Use with
+// [Templates.Stream].
+func (ts *Templates) Emit(ctx context.Context, mode string, set *xo.Set) {
 	target, ok := ts.targets[ts.target]
 	switch {
 	case !ok:
@@ -244,10 +261,10 @@ func (ts *Templates) Process(ctx context.Context, outDir string, mode string, se
 		return
 	}
 	// Determine template order.
-	order := make(map[string]int, 0)
+	ts.order = make(map[string]int, 0)
 	if target.Type.Order != nil {
 		for i, o := range target.Type.Order(ctx, mode) {
-			order[o] = i
+			ts.order[o] = i
 		}
 	}
 	fs, err := ts.Src()
@@ -272,47 +289,49 @@ func (ts *Templates) Process(ctx context.Context, outDir string, mode string, se
 		ts.err = err
 		return
 	}
-	// sort file output order
-	filenames := make([]string, 0, len(ts.files))
-	for k := range ts.files {
-		filenames = append(filenames, k)
-	}
-	slices.Sort(filenames)
-	// Generate all files with the constructed template.
-	for _, file := range filenames {
-		emitted := ts.files[file]
-		sort.Slice(emitted.Template, func(i int, j int) bool {
-			if emitted.Template[i].Partial != emitted.Template[j].Partial {
-				return order[emitted.Template[i].Partial] < order[emitted.Template[j].Partial]
-			}
-			if emitted.Template[i].SortType != emitted.Template[j].SortType {
-				return emitted.Template[i].SortType < emitted.Template[j].SortType
-			}
-			return emitted.Template[i].SortName < emitted.Template[j].SortName
-		})
-		for _, tpl := range emitted.Template {
-			if tpl.Src == "" {
-				err := ts.tpl.ExecuteTemplate(&emitted.Buf, tpl.Partial, tpl)
-				if err != nil {
-					ts.files[file].Err = append(ts.files[file].Err, err)
-				}
-				continue
-			}
-			gotpl, err := template.New("").Parse(tpl.Src)
+}
+
+// execute executes the emitted templates of the file, in template order.
+func (ts *Templates) execute(file string) {
+	if ts.tpl == nil {
+		return
+	}
+	emitted := ts.files[file]
+	// stable, keeping templates with the same sort keys in emit order
+	slices.SortStableFunc(emitted.Template, func(a, b xo.Template) int {
+		return cmp.Or(
+			cmp.Compare(ts.order[a.Partial], ts.order[b.Partial]),
+			cmp.Compare(a.SortType, b.SortType),
+			cmp.Compare(a.SortName, b.SortName),
+		)
+	})
+	for _, tpl := range emitted.Template {
+		if tpl.Src == "" {
+			err := ts.tpl.ExecuteTemplate(&emitted.Buf, tpl.Partial, tpl)
 			if err != nil {
-				ts.files[file].Err = append(ts.files[file].Err, err)
-				continue
-			}
-			if err = gotpl.Execute(&emitted.Buf, tpl); err != nil {
-				ts.files[file].Err = append(ts.files[file].Err, err)
-				continue
+				emitted.Err = append(emitted.Err, err)
 			}
+			continue
+		}
+		gotpl, err := template.New("").Parse(tpl.Src)
+		if err != nil {
+			emitted.Err = append(emitted.Err, err)
+			continue
+		}
+		if err = gotpl.Execute(&emitted.Buf, tpl); err != nil {
+			emitted.Err = append(emitted.Err, err)
+			continue
 		}
 	}
 }
 
 // Post performs post processing of the template target.
 func (ts *Templates) Post(ctx context.Context, mode string) {
+	ts.postFiles(ctx, mode, slices.Sorted(maps.Keys(ts.files))...)
+}
+
+// postFiles performs post processing of the template target on the files.
+func (ts *Templates) postFiles(ctx context.Context, mode string, fileNames ...string) {
 	target, ok := ts.targets[ts.target]
 	switch {
 	case !ok:
@@ -321,11 +340,12 @@ func (ts *Templates) Post(ctx context.Context, mode string) {
 	case target.Type.Post == nil:
 		return
 	}
-	files := make(map[string][]byte, len(ts.files))
-	for fileName, emitted := range ts.files {
-		files[fileName] = emitted.Buf.Bytes()
+	files := make(map[string][]byte, len(fileNames))
+	for _, fileName := range fileNames {
+		files[fileName] = ts.files[fileName].Buf.Bytes()
 	}
 	err := target.Type.Post(ctx, mode, files, func(fileName string, content []byte) {
+		xo.Logf(ctx, "POST: %s (%d -> %d bytes)", fileName, ts.files[fileName].Buf.Len(), len(content))
 		// Reset the buffer and fill it with the provided content.
 		ts.files[fileName].Buf.Reset()
 		ts.files[fileName].Buf.Write(content)
@@ -336,6 +356,87 @@ func (ts *Templates) Post(ctx context.Context, mode string) {
 	}
 }
 
+// Hook runs the post processing hook commands on each generated file, in
+// order. Each command is run by the system shell with the file's content on
+// stdin, and its stdout replaces the file's content. The DBTPL_FILE environment
+// variable is set to the name of the file.
+func (ts *Templates) Hook(ctx context.Context, cmds []string) {
+	for _, fileName := range slices.Sorted(maps.Keys(ts.files)) {
+		ts.hookFile(ctx, cmds, fileName)
+	}
+}
+
+// hookFile runs the post processing hook commands on the file.
+func (ts *Templates) hookFile(ctx context.Context, cmds []string, fileName string) {
+	emitted := ts.files[fileName]
+	for _, cmd := range cmds {
+		buf, err := runHook(ctx, cmd, fileName, emitted.Buf.Bytes())
+		if err != nil {
+			emitted.Err = append(emitted.Err, &ErrPostFailed{File: fileName, Err: err})
+			return
+		}
+		xo.Logf(ctx, "HOOK: %s %q (%d -> %d bytes)", fileName, cmd, emitted.Buf.Len(), len(buf))
+		emitted.Buf.Reset()
+		emitted.Buf.Write(buf)
+	}
+}
+
+// Stream executes the templates emitted by [Templates.Emit] for each file in
+// turn, post processing the file and running the hook commands on it (when
+// post is true), and writing it to out, releasing the file's content before
+// the next file. Memory use is bounded by the largest generated file instead
+// of all generated files. Stops at the first file with errors.
+//
+// The peak heap in use is reported with verbose output.
+func (ts *Templates) Stream(ctx context.Context, out, mode string, cmds []string, post bool) {
+	var stats runtime.MemStats
+	var peak uint64
+	for _, fileName := range slices.Sorted(maps.Keys(ts.files)) {
+		emitted := ts.files[fileName]
+		if ts.execute(fileName); post && len(emitted.Err) == 0 {
+			if ts.postFiles(ctx, mode, fileName); ts.err == nil {
+				ts.hookFile(ctx, cmds, fileName)
+			}
+		}
+		if ts.err != nil || len(emitted.Err) != 0 {
+			return
+		}
+		if err := os.WriteFile(filepath.Join(out, fileName), emitted.Buf.Bytes(), 0o644); err != nil {
+			emitted.Err = append(emitted.Err, err)
+			return
+		}
+		runtime.ReadMemStats(&stats)
+		peak = max(peak, stats.HeapInuse)
+		xo.Logf(ctx, "STREAM: %s (%d bytes, heap in use: %d KiB)", fileName, emitted.Buf.Len(), stats.HeapInuse>>10)
+		// release the file's templates and content
+		emitted.Template, emitted.Buf = nil, bytes.Buffer{}
+	}
+	xo.Logf(ctx, "STREAM: %d files (peak heap in use: %d KiB)", len(ts.files), peak>>10)
+}
+
+// runHook runs the hook command with content on stdin, returning its stdout.
+func runHook(ctx context.Context, cmd, fileName string, content []byte) ([]byte, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	c := exec.CommandContext(ctx, shell, flag, cmd)
+	c.Env = append(os.Environ(), "DBTPL_FILE="+fileName)
+	c.Stdin = bytes.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			return nil, fmt.Errorf("hook %q: %w: %s", cmd, err, s)
+		}
+		return nil, fmt.Errorf("hook %q: %w", cmd, err)
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("hook %q: no output", cmd)
+	}
+	return stdout.Bytes(), nil
+}
+
 // Dump dumps generated files to disk.
 func (ts *Templates) Dump(out string) {
 	for _, file := range slices.Sorted(maps.Keys(ts.files)) {
@@ -270,6 +270,8 @@ const (
 	OutKey    ContextKey = "out"
 	AppendKey ContextKey = "append"
 	SingleKey ContextKey = "single"
+	LogKey    ContextKey = "log"
+	WarnKey   ContextKey = "warn"
 )
 
 // DriverDbSchema returns the driver, database connection, and schema name from
@@ -299,6 +301,51 @@ func Single(ctx context.Context) string {
 	return s
 }
 
+// Logf logs a verbose output message using the logger (a func(string,
+// ...any)) in the context. Does nothing when verbose output is not enabled.
+func Logf(ctx context.Context, s string, v ...any) {
+	if f, ok := ctx.Value(LogKey).(func(string, ...any)); ok {
+		f(s, v...)
+	}
+}
+
+// Warning is a non-fatal issue encountered while loading or generating.
+type Warning struct {
+	// Level is the level of the warning (info or warn).
+	Level string `json:"level"`
+	// Kind is the kind of the warning (type, skip, collision, singularize).
+	Kind string `json:"kind"`
+	// Name is the name of the object the warning applies to.
+	Name string `json:"name,omitempty"`
+	// Message is the warning message.
+	Message string `json:"message"`
+}
+
+// Warnf reports a warning using the reporter (a func(Warning)) in the
+// context.
+func Warnf(ctx context.Context, kind, name, s string, v ...any) {
+	report(ctx, "warn", kind, name, fmt.Sprintf(s, v...))
+}
+
+// Infof reports an informational warning using the reporter (a
+// func(Warning)) in the context.
+func Infof(ctx context.Context, kind, name, s string, v ...any) {
+	report(ctx, "info", kind, name, fmt.Sprintf(s, v...))
+}
+
+// report reports a warning, logging it when verbose output is enabled.
+func report(ctx context.Context, level, kind, name, msg string) {
+	Logf(ctx, "%s: %s %s: %s", strings.ToUpper(level), kind, name, msg)
+	if f, ok := ctx.Value(WarnKey).(func(Warning)); ok {
+		f(Warning{
+			Level:   level,
+			Kind:    kind,
+			Name:    name,
+			Message: msg,
+		})
+	}
+}
+
 // forceLineEnd forces a \n on a string that doesn't contain one and is
 // non-empty.
 func forceLineEnd(s string) string {
@@ -342,6 +389,9 @@ func reflectStruct(z any) (any, error) {
 }
 
 // Flag is a option flag.
+//
+// Expand enables expanding ${VAR} environment variable references in the
+// flag's values, for flags that are paths or package names.
 type Flag struct {
 	ContextKey ContextKey
 	Type       string
@@ -351,6 +401,7 @@ type Flag struct {
 	Enums      []string
 	Aliases    []string
 	Hidden     bool
+	Expand     bool
 }
 
 // FlagSet is a set of option flags.